@@ -130,11 +130,22 @@ func (s V4Signer) Sign(req interfaces.IAWSRequest) {
 	header.Add("Host", request.URL.Host)
 	header.Add("Date", now.Format(time.RFC1123))
 	//header.Add("Content-Length", fmt.Sprintf("%v", contentSize))
-	header.Add("Content-Md5", contentMd5)
+	header.Set("Content-Md5", contentMd5)
 	header.Add("Authorization", ALGORITHM+" Credential="+s.Credentials.AccessKeyId()+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
 	header.Add("X-Amz-Content-Sha256", contentSha256)
 }
 
+// Returns the credential scope for the given time, e.g. "20110909/us-east-1/iam/aws4_request".
+func (s V4Signer) CredentialScope(t time.Time) string {
+	return t.UTC().Format(DATE_FORMAT) + "/" + s.AWSService.RegionName() + "/" + s.AWSService.ServiceName() + "/" + TERMINATOR
+}
+
+// Signs the string with the signing key derived for the given time and returns the hex encoded signature.
+// Used for requests signed outside of an HTTP request, such as browser-based POST policies.
+func (s V4Signer) SignString(t time.Time, sts string) string {
+	return signature(s.deriveSigningKey(t.UTC().Format(DATE_FORMAT)), sts)
+}
+
 // HMAC(HMAC(HMAC(HMAC("AWS4" + kSecret,"20110909"),"us-east-1"),"iam"),"aws4_request")
 func (s V4Signer) deriveSigningKey(dateFormat string) []byte {
 	h := hmacHash([]byte("AWS4"+s.Credentials.SecretKey()), []byte(dateFormat))
@@ -1,6 +1,7 @@
 package dynamodb
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 )
@@ -12,29 +13,81 @@ import (
 // Each book has one title but can have many authors. The multi-valued attribute is a set;
 // duplicate values are not allowed.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_AttributeValue.html]
+//
+// Documents are stored as a map (M) or a list (L) of nested AttributeValues.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/developerguide/DataModel.html#DataModel.DataTypes]
 type AttributeValue struct {
-	B  []byte   `json:"B,omitempty"`
-	BS [][]byte `json:"BS,omitempty"`
-	N  string   `json:"N,omitempty"`
-	NS []string `json:"NS,omitempty"`
-	S  string   `json:"S,omitempty"`
-	SS []string `json:"SS,omitempty"`
+	B    []byte                    `json:"B,omitempty"`
+	BOOL *bool                     `json:"BOOL,omitempty"`
+	BS   [][]byte                  `json:"BS,omitempty"`
+	L    []AttributeValue          `json:"L,omitempty"`
+	M    map[string]AttributeValue `json:"M,omitempty"`
+	N    string                    `json:"N,omitempty"`
+	NS   []string                  `json:"NS,omitempty"`
+	NULL bool                      `json:"NULL,omitempty"`
+	S    string                    `json:"S,omitempty"`
+	SS   []string                  `json:"SS,omitempty"`
+
+	emptyS bool // S is set to the empty string. Use NewEmptyStringAttributeValue().
+}
+
+// The AttributeValue without its methods, to use the default JSON encoding.
+type attributeValue AttributeValue
+
+// Encodes the AttributeValue. Unlike the other members, an empty but non-nil L or M and
+// an empty string set with NewEmptyStringAttributeValue() are encoded.
+func (v AttributeValue) MarshalJSON() ([]byte, error) {
+	switch {
+	case v.L != nil && len(v.L) == 0:
+		return []byte(`{"L":[]}`), nil
+	case v.M != nil && len(v.M) == 0:
+		return []byte(`{"M":{}}`), nil
+	case v.emptyS:
+		return []byte(`{"S":""}`), nil
+	}
+	return json.Marshal(attributeValue(v))
+}
+
+// Decodes the AttributeValue, keeping an {"S":""} apart from an empty AttributeValue.
+func (v *AttributeValue) UnmarshalJSON(data []byte) error {
+
+	v.emptyS = false
+	if err := json.Unmarshal(data, (*attributeValue)(v)); err != nil {
+		return err
+	}
+
+	if v.IsEmpty() {
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(data, &members); err == nil {
+			_, v.emptyS = members["S"]
+		}
+	}
+	return nil
 }
 
 // Returns the data type of the Attribute
 func (v AttributeValue) Type() AttributeType {
 	switch {
-	case len(v.S)+len(v.SS) > 0:
+	case len(v.S)+len(v.SS) > 0 || v.emptyS:
 		return STRING
 	case len(v.N)+len(v.NS) > 0:
 		return NUMBER
+	case v.M != nil:
+		return MAP
+	case v.L != nil:
+		return LIST
+	case v.BOOL != nil:
+		return BOOLEAN
+	case v.NULL:
+		return NULL_AT
 	}
 	return BINARY
 }
 
 // Checks if the Attribute contains any values.
 func (v AttributeValue) IsEmpty() bool {
-	return len(v.S)+len(v.SS)+len(v.N)+len(v.NS)+len(v.B)+len(v.BS) == 0
+	return len(v.S)+len(v.SS)+len(v.N)+len(v.NS)+len(v.B)+len(v.BS) == 0 &&
+		v.M == nil && v.L == nil && v.BOOL == nil && !v.NULL && !v.emptyS
 }
 
 // A Binary data type (0 to 255).
@@ -47,6 +100,26 @@ func (v AttributeValue) BinarySet() (val [][]byte) {
 	return v.BS
 }
 
+// A Boolean data type. False if not set.
+func (v AttributeValue) Bool() (val bool) {
+	return v.BOOL != nil && *v.BOOL
+}
+
+// Checks if the Attribute is of the Null data type.
+func (v AttributeValue) IsNull() bool {
+	return v.NULL
+}
+
+// A List data type.
+func (v AttributeValue) List() (val []AttributeValue) {
+	return v.L
+}
+
+// A Map data type.
+func (v AttributeValue) Map() (val map[string]AttributeValue) {
+	return v.M
+}
+
 // A Number data type as int64 (-9223372036854775808 to 9223372036854775807).
 func (v AttributeValue) Int() (val int64) {
 	val, _ = strconv.ParseInt(v.N, 10, 64)
@@ -107,6 +180,16 @@ func (v AttributeValue) ValueSet() (val []string) {
 
 // String representation of the AttributeValue.
 func (v AttributeValue) String() string {
+	switch v.Type() {
+	case MAP:
+		return fmt.Sprintf("AttributeValue[M:%v]", v.M)
+	case LIST:
+		return fmt.Sprintf("AttributeValue[L:%v]", v.L)
+	case BOOLEAN:
+		return fmt.Sprintf("AttributeValue[BOOL:%t]", *v.BOOL)
+	case NULL_AT:
+		return "AttributeValue[NULL:true]"
+	}
 	return fmt.Sprintf("AttributeValue[B:%s, BS:%s, N:%s, NS:%s, S:%s, SS:%s]", v.B, v.BS, v.N, v.NS, v.S, v.SS)
 }
 
@@ -124,6 +207,32 @@ func NewBinaryAttributeSet(val [][]byte) AttributeValue {
 	return AttributeValue{BS: val}
 }
 
+// Creates an AttributeValue of a Boolean data type.
+func NewBoolAttributeValue(val bool) AttributeValue {
+	return AttributeValue{BOOL: &val}
+}
+
+// Creates an AttributeValue of a List data type.
+func NewListAttributeValue(val []AttributeValue) AttributeValue {
+	if val == nil {
+		val = []AttributeValue{}
+	}
+	return AttributeValue{L: val}
+}
+
+// Creates an AttributeValue of a Map data type.
+func NewMapAttributeValue(val map[string]AttributeValue) AttributeValue {
+	if val == nil {
+		val = map[string]AttributeValue{}
+	}
+	return AttributeValue{M: val}
+}
+
+// Creates an AttributeValue of the Null data type.
+func NewNullAttributeValue() AttributeValue {
+	return AttributeValue{NULL: true}
+}
+
 // Creates an AttributeValue of a Number data type using float64.
 func NewFloatAttributeValue(val float64) AttributeValue {
 	return AttributeValue{N: strconv.FormatFloat(val, 'f', -1, 64)}
@@ -169,10 +278,17 @@ func NewUintAttributeSet(val []uint64) AttributeValue {
 }
 
 // Creates an AttributeValue of a String data type.
+// An empty val creates an empty AttributeValue; use NewEmptyStringAttributeValue() to store "".
 func NewAttributeValue(val string) AttributeValue {
 	return AttributeValue{S: val}
 }
 
+// Creates an AttributeValue of a String data type holding the empty string.
+// Unlike NewAttributeValue(""), it is not empty and is encoded as {"S":""}.
+func NewEmptyStringAttributeValue() AttributeValue {
+	return AttributeValue{emptyS: true}
+}
+
 // Creates an AttributeValue of a String set data type.
 func NewAttributeSet(val []string) AttributeValue {
 	return AttributeValue{SS: val}
@@ -0,0 +1,384 @@
+package datamodeling
+
+import (
+	"errors"
+	"fmt"
+	"github.com/twhello/aws-to-go/interfaces"
+	"github.com/twhello/aws-to-go/services"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"sync"
+	"time"
+)
+
+const (
+	MAX_BATCH_WRITE_ITEMS = 25  // The maximum number of put and delete requests in a BatchWriteItem request.
+	MAX_BATCH_GET_KEYS    = 100 // The maximum number of keys in a BatchGetItem request.
+)
+
+var errUnprocessed = errors.New("datamodeling: The item was still unprocessed after the maximum number of retries.")
+
+// An item of a batch operation that could not be processed.
+type BatchFailure struct {
+	Item interface{} // The struct passed to the batch operation.
+	Err  error
+}
+
+func (f BatchFailure) Error() string {
+	return fmt.Sprintf("%T: %s", f.Item, f.Err)
+}
+
+// A struct's request within a batch.
+type batchEntry struct {
+	item  interface{}
+	table string
+	id    string // Identifies the item's primary key within the batch.
+	key   map[string]dynamodb.AttributeValue
+	write dynamodb.WriteRequest
+}
+
+/*****************************************************************************/
+
+// Saves the structs, which can be of different types and tables, with BatchWriteItem.
+// Items are put whole, like the CLOBBER save behavior. Version attributes are not checked
+// or incremented, as BatchWriteItem does not support conditions.
+// Returns the items that could not be saved, or nil.
+func (m *DynamoDBMapper) BatchSave(v ...interface{}) []BatchFailure {
+	return m.BatchWrite(v, nil)
+}
+
+// Deletes the structs, which can be of different types and tables, with BatchWriteItem.
+// Returns the items that could not be deleted, or nil.
+func (m *DynamoDBMapper) BatchDelete(v ...interface{}) []BatchFailure {
+	return m.BatchWrite(nil, v)
+}
+
+// Saves and deletes the structs with BatchWriteItem. The requests are split into chunks of
+// MAX_BATCH_WRITE_ITEMS that run concurrently. UnprocessedItems are retried with an exponential
+// backoff, up to the configured services.Config().RetryAttempts().
+// BatchWriteItem rejects duplicate keys, so of the structs with the same primary key only the
+// last is written, deletes coming after saves.
+// Returns the items that could not be written, or nil.
+func (m *DynamoDBMapper) BatchWrite(saves, deletes []interface{}) []BatchFailure {
+
+	var failures []BatchFailure
+	entries := make([]*batchEntry, 0, len(saves)+len(deletes))
+	index := make(map[string]int, len(saves)+len(deletes))
+
+	add := func(entry *batchEntry) {
+		if i, ok := index[entry.id]; ok {
+			entries[i] = entry
+		} else {
+			index[entry.id] = len(entries)
+			entries = append(entries, entry)
+		}
+	}
+
+	for _, v := range saves {
+		entry, err := m.newBatchEntry(v)
+		if err != nil {
+			failures = append(failures, BatchFailure{v, err})
+			continue
+		}
+
+		item := make(map[string]dynamodb.AttributeValue)
+		for k, attr := range Marshal(v).Item {
+			if !attr.IsEmpty() {
+				item[k] = attr
+			}
+		}
+		entry.write.PutRequest = &dynamodb.PutRequest{Item: item}
+		add(entry)
+	}
+
+	for _, v := range deletes {
+		entry, err := m.newBatchEntry(v)
+		if err != nil {
+			failures = append(failures, BatchFailure{v, err})
+			continue
+		}
+		entry.write.DeleteRequest = &dynamodb.DeleteRequest{Key: entry.key}
+		add(entry)
+	}
+
+	m.runBatches(entries, MAX_BATCH_WRITE_ITEMS, &failures, m.writeChunk)
+
+	return failures
+}
+
+// Loads the structs, which can be of different types and tables, with BatchGetItem.
+// Each struct must have its hash and, if any, range key set; found items are unmarshalled into it.
+// The keys are split into chunks of MAX_BATCH_GET_KEYS that run concurrently. UnprocessedKeys are
+// retried with an exponential backoff, up to the configured services.Config().RetryAttempts().
+// Returns the structs that were found, in the given order, and the structs that could not be loaded.
+func (m *DynamoDBMapper) BatchLoad(v ...interface{}) (loaded []interface{}, failures []BatchFailure) {
+
+	entries := make([]*batchEntry, 0, len(v))
+	seen := make(map[string]bool, len(v))
+	unique := make([]*batchEntry, 0, len(v))
+
+	for _, item := range v {
+		entry, err := m.newBatchEntry(item)
+		if err != nil {
+			failures = append(failures, BatchFailure{item, err})
+			continue
+		}
+		entries = append(entries, entry)
+
+		// BatchGetItem rejects duplicate keys; load each key once.
+		if !seen[entry.id] {
+			unique = append(unique, entry)
+			seen[entry.id] = true
+		}
+	}
+
+	found := make(map[string]map[string]dynamodb.AttributeValue)
+	failed := make(map[string]error)
+	mu := &sync.Mutex{}
+
+	m.runBatches(unique, MAX_BATCH_GET_KEYS, nil, func(chunk []*batchEntry) []BatchFailure {
+		items, chunkFailures := m.getChunk(chunk)
+		mu.Lock()
+		for id, item := range items {
+			found[id] = item
+		}
+		for id, err := range chunkFailures {
+			failed[id] = err
+		}
+		mu.Unlock()
+		return nil
+	})
+
+	for _, entry := range entries {
+		if item, ok := found[entry.id]; ok {
+			Unmarshal(item, entry.item)
+			loaded = append(loaded, entry.item)
+		} else if err, ok := failed[entry.id]; ok {
+			failures = append(failures, BatchFailure{entry.item, err})
+		}
+	}
+
+	return loaded, failures
+}
+
+/******************************************************************************
+ * Private Methods
+ */
+
+// Splits the entries into chunks of size and runs them concurrently, collecting the failures if not nil.
+func (m *DynamoDBMapper) runBatches(entries []*batchEntry, size int, failures *[]BatchFailure, fn func([]*batchEntry) []BatchFailure) {
+
+	concurrency := m.DynamoDBMapperConfig.BatchConcurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	queue := make(chan []*batchEntry)
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range queue {
+				if f := fn(chunk); len(f) > 0 && failures != nil {
+					mu.Lock()
+					*failures = append(*failures, f...)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < len(entries); i += size {
+		end := i + size
+		if end > len(entries) {
+			end = len(entries)
+		}
+		queue <- entries[i:end]
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// Writes a chunk, retrying the UnprocessedItems.
+func (m *DynamoDBMapper) writeChunk(chunk []*batchEntry) []BatchFailure {
+
+	pending := chunk
+	maxRetries := services.Config().RetryAttempts()
+
+	for retries := uint(0); ; retries++ {
+
+		requestItems := make(map[string][]dynamodb.WriteRequest)
+		for _, entry := range pending {
+			requestItems[entry.table] = append(requestItems[entry.table], entry.write)
+		}
+
+		result, err := m.DynamoDBService.BatchWriteItem(dynamodb.NewBatchWriteItemRequest(requestItems))
+
+		if err == nil {
+			var unprocessed []*batchEntry
+			for table, writes := range result.UnprocessedItems {
+				for _, w := range writes {
+					var key map[string]dynamodb.AttributeValue
+					if w.PutRequest != nil {
+						key = w.PutRequest.Item
+					} else if w.DeleteRequest != nil {
+						key = w.DeleteRequest.Key
+					}
+					unprocessed = append(unprocessed, matchEntries(pending, table, key)...)
+				}
+			}
+			if len(unprocessed) == 0 {
+				return nil
+			}
+			pending, err = unprocessed, errUnprocessed
+
+		} else if !isRetryable(err) {
+			return batchFailures(pending, err)
+		}
+
+		if retries >= maxRetries {
+			return batchFailures(pending, err)
+		}
+		time.Sleep(services.ExponentialBackoff(50*time.Millisecond, retries))
+	}
+}
+
+// Gets a chunk, retrying the UnprocessedKeys. Returns the found items and the errors of the failed keys by entry id.
+func (m *DynamoDBMapper) getChunk(chunk []*batchEntry) (map[string]map[string]dynamodb.AttributeValue, map[string]error) {
+
+	found := make(map[string]map[string]dynamodb.AttributeValue)
+	pending := chunk
+	maxRetries := services.Config().RetryAttempts()
+	consistentRead := m.DynamoDBMapperConfig.ConsistentReads == CONSISTANT
+
+	for retries := uint(0); ; retries++ {
+
+		requestItems := make(map[string]dynamodb.KeysAndAttributes)
+		for _, entry := range pending {
+			ka := requestItems[entry.table]
+			ka.ConsistentRead = consistentRead
+			ka.Keys = append(ka.Keys, entry.key)
+			requestItems[entry.table] = ka
+		}
+
+		result, err := m.DynamoDBService.BatchGetItem(dynamodb.NewBatchGetItemRequest(requestItems))
+
+		if err == nil {
+			for table, items := range result.Responses {
+				for _, item := range items {
+					for _, entry := range matchEntries(pending, table, item) {
+						found[entry.id] = item
+					}
+				}
+			}
+
+			var unprocessed []*batchEntry
+			for table, ka := range result.UnprocessedKeys {
+				for _, key := range ka.Keys {
+					unprocessed = append(unprocessed, matchEntries(pending, table, key)...)
+				}
+			}
+			if len(unprocessed) == 0 {
+				return found, nil
+			}
+			pending, err = unprocessed, errUnprocessed
+
+		} else if !isRetryable(err) {
+			return found, failedIds(pending, err)
+		}
+
+		if retries >= maxRetries {
+			return found, failedIds(pending, err)
+		}
+		time.Sleep(services.ExponentialBackoff(50*time.Millisecond, retries))
+	}
+}
+
+// Creates the batch entry of a struct, which must have its keys set.
+func (m *DynamoDBMapper) newBatchEntry(v interface{}) (*batchEntry, error) {
+
+	model := m.marshal(v)
+	key, err := modelKey(model)
+	if err != nil {
+		return nil, err
+	}
+
+	return &batchEntry{item: v, table: model.TableName, id: entryId(model.TableName, key), key: key}, nil
+}
+
+/******************************************************************************
+ * Helper Functions
+ */
+
+// Returns the primary key attributes of the model.
+func modelKey(model *DataModel) (map[string]dynamodb.AttributeValue, error) {
+
+	if model.HashKey == "" {
+		return nil, errors.New("datamodeling: The struct has no DynamoDBHashKey.")
+	}
+
+	key := make(map[string]dynamodb.AttributeValue, 2)
+
+	for _, name := range []string{model.HashKey, model.RangeKey} {
+		if name == "" {
+			continue
+		}
+		attr, ok := model.Item[name]
+		if !ok || attr.IsEmpty() {
+			return nil, errors.New("datamodeling: The key attribute " + name + " is not set.")
+		}
+		key[name] = attr
+	}
+
+	return key, nil
+}
+
+// Identifies a primary key within a table.
+func entryId(table string, key map[string]dynamodb.AttributeValue) string {
+	return fmt.Sprintf("%s\x00%v", table, key)
+}
+
+// Returns the entries of the table whose key attributes match those of item.
+func matchEntries(entries []*batchEntry, table string, item map[string]dynamodb.AttributeValue) []*batchEntry {
+
+	var matches []*batchEntry
+	for _, entry := range entries {
+		if entry.table != table {
+			continue
+		}
+		key := make(map[string]dynamodb.AttributeValue, len(entry.key))
+		for name := range entry.key {
+			key[name] = item[name]
+		}
+		if entryId(table, key) == entry.id {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+func batchFailures(entries []*batchEntry, err error) []BatchFailure {
+	failures := make([]BatchFailure, len(entries))
+	for i, entry := range entries {
+		failures[i] = BatchFailure{entry.item, err}
+	}
+	return failures
+}
+
+func failedIds(entries []*batchEntry, err error) map[string]error {
+	failed := make(map[string]error, len(entries))
+	for _, entry := range entries {
+		failed[entry.id] = err
+	}
+	return failed
+}
+
+// Returns true for throttling and other retryable service errors.
+func isRetryable(err error) bool {
+	if srvErr, ok := err.(interfaces.IServiceError); ok {
+		return srvErr.IsRetry() || dynamodb.IsErrorType(err, dynamodb.PROVISIONED_THROUGHPUT_EXCEEDED_EXCEPTION)
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package datamodeling
+
+import (
+	"encoding/json"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// A table serving BatchWriteItem requests. The first request leaves its last 5 writes unprocessed
+// if unprocessed is set. Every request is kept.
+type batchTable struct {
+	t           *testing.T
+	mutex       sync.Mutex
+	requests    []map[string][]dynamodb.WriteRequest
+	items       map[string]map[string]dynamodb.AttributeValue
+	unprocessed bool
+}
+
+func (b *batchTable) handle(operation string, body []byte) (int, string) {
+
+	var req dynamodb.BatchWriteItemRequest
+	if err := json.Unmarshal(body, &req); err != nil || operation != "BatchWriteItem" {
+		b.t.Errorf("Unexpected %s request: %v", operation, err)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.requests = append(b.requests, req.RequestItems)
+	result := dynamodb.BatchWriteItemResult{}
+
+	for table, writes := range req.RequestItems {
+		if b.unprocessed {
+			b.unprocessed = false
+			result.UnprocessedItems = map[string][]dynamodb.WriteRequest{table: writes[len(writes)-5:]}
+			writes = writes[:len(writes)-5]
+		}
+		for _, w := range writes {
+			if w.PutRequest != nil {
+				b.items[w.PutRequest.Item["Id"].S] = w.PutRequest.Item
+			} else {
+				delete(b.items, w.DeleteRequest.Key["Id"].S)
+			}
+		}
+	}
+
+	response, _ := json.Marshal(result)
+	return 200, string(response)
+}
+
+func TestBatchWrite(t *testing.T) {
+
+	table := &batchTable{t: t, items: map[string]map[string]dynamodb.AttributeValue{"d": nil}, unprocessed: true}
+	m, restore := newTestMapper(table.handle)
+	defer restore()
+
+	var saves []interface{}
+	for i := 0; i < 60; i++ {
+		saves = append(saves, &savedItem{Id: strconv.Itoa(i), Name: "first"})
+	}
+	saves = append(saves, &savedItem{Id: "7", Name: "last"}, &savedItem{Id: "d", Name: "deleted"}, &savedItem{Name: "no key"})
+	deletes := []interface{}{&savedItem{Id: "d"}}
+
+	failures := m.BatchWrite(saves, deletes)
+	if len(failures) != 1 || failures[0].Item != saves[62] {
+		t.Fatalf("Expected the struct without key to fail, was %v", failures)
+	}
+
+	// 61 unique keys in chunks of 25, and the retry of the 5 unprocessed writes.
+	if len(table.requests) != 4 {
+		t.Errorf("Expected 4 requests, was %d", len(table.requests))
+	}
+	for _, requestItems := range table.requests {
+		writes := requestItems[m.TableName(&savedItem{})]
+		seen := map[string]bool{}
+		for _, w := range writes {
+			id := ""
+			if w.PutRequest != nil {
+				id = w.PutRequest.Item["Id"].S
+			} else {
+				id = w.DeleteRequest.Key["Id"].S
+			}
+			if seen[id] {
+				t.Errorf("Duplicate key %s in a request", id)
+			}
+			seen[id] = true
+		}
+		if len(writes) > MAX_BATCH_WRITE_ITEMS {
+			t.Errorf("Expected at most %d writes, was %d", MAX_BATCH_WRITE_ITEMS, len(writes))
+		}
+	}
+
+	if len(table.items) != 60 || table.items["7"]["Name"].S != "last" || table.items["8"]["Name"].S != "first" {
+		t.Errorf("Expected 60 items with the last save of 7, was %d: %v", len(table.items), table.items["7"])
+	}
+	if _, ok := table.items["d"]; ok {
+		t.Error("Expected the delete after the save of d to win.")
+	}
+}
+
+func TestBatchWriteFailures(t *testing.T) {
+
+	m, restore := newTestMapper(func(operation string, body []byte) (int, string) {
+		return 400, `{"__type":"com.amazonaws.dynamodb.v20120810#ValidationException","message":"Invalid"}`
+	})
+	defer restore()
+
+	a, b := &savedItem{Id: "a"}, &savedItem{Id: "b"}
+	failures := m.BatchSave(a, b)
+
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failures, was %v", failures)
+	}
+	for _, f := range failures {
+		if (f.Item != a && f.Item != b) || !dynamodb.IsErrorType(f.Err, "ValidationException") {
+			t.Errorf("Unexpected failure %v", f)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package datamodeling
 
-import ()
+import (
+	"reflect"
+)
 
 /*	Consistent read behavior.
 	CONSISTANT
@@ -37,11 +39,27 @@ const (
 	ITERATION_ONLY
 )
 
+// Resolves the table name of a struct type, e.g. from a naming scheme of the environment.
+// (t reflect.Type) The struct type.
+// (tableName string) The DynamoDBTable tag of the struct or, if not present, the struct name.
+type TableNameResolver func(t reflect.Type, tableName string) string
+
 // Configuration struct for service call behavior. An instance of this configuration is supplied to every DynamoDBMapper at construction;
 // if not provided explicitly, DEFAULT is used. New instances can be given to the mapper object on individual save, load, and delete
-// operations to override the defaults.
+// operations to override the defaults, with DynamoDBMapper.WithConfig().
 type DynamoDBMapperConfig struct {
 	ConsistentReads           ConsistentReads           // Defaults to EVENTUAL
 	SaveBehavior              SaveBehavior              // Defaults to UPDATE
 	PaginationLoadingStrategy PaginationLoadingStrategy // Defaults to LAZY_LOADING
+	BatchConcurrency          int                       // The number of concurrent batch requests. Defaults to 4
+	TableNamePrefix           string                    // Prepended to every table name, e.g. "dev_". Defaults to none
+	TableNameResolver         TableNameResolver         // Resolves table names before the prefix is prepended. Defaults to none
+}
+
+// Returns the table name of the struct type t, resolved and prefixed by the config.
+func (c *DynamoDBMapperConfig) resolveTableName(t reflect.Type, tableName string) string {
+	if c.TableNameResolver != nil {
+		tableName = c.TableNameResolver(t, tableName)
+	}
+	return c.TableNamePrefix + tableName
 }
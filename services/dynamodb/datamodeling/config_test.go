@@ -0,0 +1,44 @@
+package datamodeling
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTableNameResolution(t *testing.T) {
+
+	m := &DynamoDBMapper{DynamoDBMapperConfig: DynamoDBMapperConfig{TableNamePrefix: "dev_"}}
+
+	if name := m.TableName(&schemaItem{}); name != "dev_Orders" {
+		t.Errorf("Expected dev_Orders, got %s", name)
+	}
+	if name := m.TableName(transactionItem{}); name != "dev_transactionItem" {
+		t.Errorf("Expected dev_transactionItem, got %s", name)
+	}
+
+	ctr, err := m.GenerateCreateTableRequest(&schemaItem{})
+	if err != nil || ctr.TableName != "dev_Orders" {
+		t.Errorf("Expected a dev_Orders table, got %v (%v)", ctr, err)
+	}
+
+	entry, err := m.newBatchEntry(&transactionItem{Id: "a"})
+	if err != nil || entry.table != "dev_transactionItem" {
+		t.Errorf("Expected a dev_transactionItem entry, got %+v (%v)", entry, err)
+	}
+
+	resolved := m.WithConfig(DynamoDBMapperConfig{
+		TableNamePrefix: "prod_",
+		TableNameResolver: func(t reflect.Type, tableName string) string {
+			return strings.ToLower(tableName)
+		},
+	})
+
+	item, _, err := transactionOperation{deleteTransaction, &transactionItem{Id: "a"}, nil}.build(resolved, false)
+	if err != nil || item.Delete.TableName != "prod_transactionitem" {
+		t.Errorf("Expected a prod_transactionitem delete, got %+v (%v)", item.Delete, err)
+	}
+	if m.DynamoDBMapperConfig.TableNamePrefix != "dev_" {
+		t.Error("Expected WithConfig to leave the mapper's config unchanged.")
+	}
+}
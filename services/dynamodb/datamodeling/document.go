@@ -0,0 +1,210 @@
+package datamodeling
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"math"
+	"reflect"
+	"regexp"
+)
+
+// A String set (SS) in a document. A []string is stored as a List.
+type StringSet []string
+
+// A Number set (NS) in a document. A []json.Number is stored as a List.
+type NumberSet []json.Number
+
+// A Binary set (BS) in a document. A [][]byte is stored as a List.
+type BinarySet [][]byte
+
+// The decimal numbers accepted by DynamoDB.
+var numberPattern = regexp.MustCompile(`^[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?$`)
+
+var (
+	ErrEmptySet      = errors.New("datamodeling: Sets must not be empty.")
+	ErrInvalidNumber = errors.New("datamodeling: Invalid number.")
+)
+
+// Converts a schemaless document into an item, e.g. to patch a document read by UnmarshalDocument and write it back.
+// Values are converted by their type:
+//
+//	nil                                   NULL
+//	bool                                  BOOL
+//	string                                S
+//	json.Number, ints, uints and floats   N, keeping the precision of a json.Number
+//	[]byte                                B
+//	StringSet, NumberSet, BinarySet       SS, NS, BS
+//	[]interface{}                         L
+//	map[string]interface{}                M
+//
+// Other types are encoded like the nested values of Marshal.
+// An error is returned for empty sets, invalid numbers, NaN and infinities.
+func MarshalDocument(doc map[string]interface{}) (map[string]dynamodb.AttributeValue, error) {
+
+	item := make(map[string]dynamodb.AttributeValue, len(doc))
+
+	for name, v := range doc {
+		attr, err := documentValue(v)
+		if err != nil {
+			return nil, err
+		}
+		item[name] = attr
+	}
+
+	return item, nil
+}
+
+// Converts an item into a schemaless document, the reverse of MarshalDocument. Numbers become a json.Number
+// to keep their precision, binaries a []byte, sets a StringSet, NumberSet or BinarySet, lists an []interface{}
+// and maps a map[string]interface{}.
+func UnmarshalDocument(item map[string]dynamodb.AttributeValue) map[string]interface{} {
+
+	doc := make(map[string]interface{}, len(item))
+	for name, attr := range item {
+		doc[name] = documentInterface(attr)
+	}
+	return doc
+}
+
+// Converts a JSON object into an item. Numbers keep their precision and arrays become lists.
+func MarshalJSONDocument(data []byte) (map[string]dynamodb.AttributeValue, error) {
+
+	var doc map[string]interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return MarshalDocument(doc)
+}
+
+// Converts an item into a JSON object. Sets become arrays and binaries base64 encoded strings.
+func UnmarshalJSONDocument(item map[string]dynamodb.AttributeValue) ([]byte, error) {
+	return json.Marshal(UnmarshalDocument(item))
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+// Converts a document value into an attribute.
+func documentValue(v interface{}) (dynamodb.AttributeValue, error) {
+
+	switch val := v.(type) {
+	case nil:
+		return dynamodb.NewNullAttributeValue(), nil
+
+	case bool:
+		return dynamodb.NewBoolAttributeValue(val), nil
+
+	case string:
+		return stringValue(val), nil
+
+	case json.Number:
+		if !isNumber(string(val)) {
+			return dynamodb.AttributeValue{}, ErrInvalidNumber
+		}
+		return dynamodb.AttributeValue{N: string(val)}, nil
+
+	case float64:
+		return floatValue(val)
+
+	case float32:
+		return floatValue(float64(val))
+
+	case []byte:
+		return dynamodb.NewBinaryAttributeValue(val), nil
+
+	case StringSet:
+		if len(val) == 0 {
+			return dynamodb.AttributeValue{}, ErrEmptySet
+		}
+		return dynamodb.NewAttributeSet(val), nil
+
+	case NumberSet:
+		if len(val) == 0 {
+			return dynamodb.AttributeValue{}, ErrEmptySet
+		}
+		ns := make([]string, len(val))
+		for i, n := range val {
+			if !isNumber(string(n)) {
+				return dynamodb.AttributeValue{}, ErrInvalidNumber
+			}
+			ns[i] = string(n)
+		}
+		return dynamodb.AttributeValue{NS: ns}, nil
+
+	case BinarySet:
+		if len(val) == 0 {
+			return dynamodb.AttributeValue{}, ErrEmptySet
+		}
+		return dynamodb.NewBinaryAttributeSet(val), nil
+
+	case []interface{}:
+		l := make([]dynamodb.AttributeValue, len(val))
+		for i, elem := range val {
+			attr, err := documentValue(elem)
+			if err != nil {
+				return dynamodb.AttributeValue{}, err
+			}
+			l[i] = attr
+		}
+		return dynamodb.NewListAttributeValue(l), nil
+
+	case map[string]interface{}:
+		m, err := MarshalDocument(val)
+		if err != nil {
+			return dynamodb.AttributeValue{}, err
+		}
+		return dynamodb.NewMapAttributeValue(m), nil
+	}
+
+	return encodeValue(reflect.ValueOf(v)), nil
+}
+
+// Converts an attribute into a document value.
+func documentInterface(av dynamodb.AttributeValue) interface{} {
+
+	switch {
+	case av.SS != nil:
+		return StringSet(av.SS)
+	case av.NS != nil:
+		ns := make(NumberSet, len(av.NS))
+		for i, n := range av.NS {
+			ns[i] = json.Number(n)
+		}
+		return ns
+	case av.BS != nil:
+		return BinarySet(av.BS)
+	}
+
+	switch av.Type() {
+	case dynamodb.NUMBER:
+		return json.Number(av.N)
+	case dynamodb.LIST:
+		l := make([]interface{}, len(av.L))
+		for i, attr := range av.L {
+			l[i] = documentInterface(attr)
+		}
+		return l
+	case dynamodb.MAP:
+		return UnmarshalDocument(av.M)
+	}
+
+	return decodeInterface(av)
+}
+
+func floatValue(f float64) (dynamodb.AttributeValue, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return dynamodb.AttributeValue{}, ErrInvalidNumber
+	}
+	return dynamodb.NewFloatAttributeValue(f), nil
+}
+
+func isNumber(n string) bool {
+	return numberPattern.MatchString(n)
+}
@@ -0,0 +1,91 @@
+package datamodeling
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"reflect"
+	"testing"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+
+	doc := map[string]interface{}{
+		"Id":      "a",
+		"Blank":   "",
+		"Big":     json.Number("12345678901234567890.123456789"),
+		"Count":   3,
+		"Ratio":   0.5,
+		"Active":  true,
+		"None":    nil,
+		"Data":    []byte{1, 2},
+		"Tags":    StringSet{"x", "y"},
+		"Scores":  NumberSet{"1", "2.5"},
+		"Blobs":   BinarySet{{1}, {2}},
+		"Names":   []interface{}{"x", json.Number("1")},
+		"Strings": []string{"p", "q"},
+		"Nested":  map[string]interface{}{"Empty": []interface{}{}},
+	}
+
+	item, err := MarshalDocument(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item["Big"].N != "12345678901234567890.123456789" || item["Count"].N != "3" || item["Ratio"].N != "0.5" {
+		t.Errorf("Unexpected numbers %v %v %v", item["Big"], item["Count"], item["Ratio"])
+	}
+	if len(item["Tags"].SS) != 2 || len(item["Scores"].NS) != 2 || len(item["Blobs"].BS) != 2 {
+		t.Errorf("Expected sets, got %v %v %v", item["Tags"], item["Scores"], item["Blobs"])
+	}
+	if len(item["Names"].L) != 2 || len(item["Strings"].L) != 2 || item["Nested"].M["Empty"].L == nil {
+		t.Errorf("Expected lists, got %v %v %v", item["Names"], item["Strings"], item["Nested"])
+	}
+	if !bytes.Equal(item["Data"].B, []byte{1, 2}) || !item["None"].NULL || !item["Active"].Bool() {
+		t.Errorf("Unexpected values %v %v %v", item["Data"], item["None"], item["Active"])
+	}
+
+	out := UnmarshalDocument(item)
+	doc["Count"], doc["Ratio"] = json.Number("3"), json.Number("0.5")
+	doc["Strings"] = []interface{}{"p", "q"}
+
+	if !reflect.DeepEqual(doc, out) {
+		t.Errorf("Round trip mismatch:\n%v\n%v", doc, out)
+	}
+}
+
+func TestJSONDocument(t *testing.T) {
+
+	item, err := MarshalJSONDocument([]byte(`{"Id":"a","Price":19.990000000000000001,"Tags":["x"],"Meta":{"Ok":true,"Gone":null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item["Price"].N != "19.990000000000000001" || item["Tags"].L == nil || item["Meta"].M["Gone"].NULL != true {
+		t.Errorf("Unexpected item %v", item)
+	}
+
+	item["Set"] = dynamodb.NewIntAttributeSet([]int64{7})
+	item["Data"] = dynamodb.NewBinaryAttributeValue([]byte("hi"))
+
+	data, err := UnmarshalJSONDocument(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"Data":"aGk=","Id":"a","Meta":{"Gone":null,"Ok":true},"Price":19.990000000000000001,"Set":[7],"Tags":["x"]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestDocumentErrors(t *testing.T) {
+
+	if _, err := MarshalDocument(map[string]interface{}{"Tags": StringSet{}}); err != ErrEmptySet {
+		t.Errorf("Expected ErrEmptySet, got %v", err)
+	}
+	if _, err := MarshalDocument(map[string]interface{}{"N": []interface{}{json.Number("NaN")}}); err != ErrInvalidNumber {
+		t.Errorf("Expected ErrInvalidNumber, got %v", err)
+	}
+	if _, err := MarshalJSONDocument([]byte(`[1]`)); err == nil {
+		t.Error("Expected an error for a JSON array.")
+	}
+}
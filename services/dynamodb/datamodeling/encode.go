@@ -0,0 +1,308 @@
+package datamodeling
+
+import (
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"log"
+	"reflect"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Returns true if values of the type are stored natively as an M, L or nested attribute
+// rather than gob encoded. Supports structs, slices, arrays, maps with string keys and pointers to them.
+func isNativeType(t reflect.Type) bool {
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return isNativeType(t.Elem())
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Interface:
+		return true
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String
+	}
+	return false
+}
+
+// Encodes a nested value. Structs and maps with string keys become an M, slices and
+// arrays an L, bool a BOOL, time.Time an RFC3339Nano S and nil a NULL.
+// Struct fields are named by their DynamoDBAttribute tag, or else by the field name.
+// Unexported fields and fields tagged DynamoDBIgnore:"true" are skipped.
+// A Marshaler encodes itself, and an encoding.TextMarshaler becomes an S.
+func encodeValue(rv reflect.Value) dynamodb.AttributeValue {
+
+	if m, ok := marshalerOf(rv); ok {
+		attr, err := m.MarshalAttributeValue()
+		if err != nil {
+			log.Printf("The value `%s` could not be marshalled: %s\n", rv.Type(), err)
+		}
+		return attr
+	}
+	if rv.Type() != timeType {
+		if tm, ok := textMarshalerOf(rv); ok {
+			text, err := tm.MarshalText()
+			if err != nil {
+				log.Printf("The value `%s` could not be marshalled: %s\n", rv.Type(), err)
+			}
+			return stringValue(string(text))
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return dynamodb.NewNullAttributeValue()
+		}
+		return encodeValue(rv.Elem())
+
+	case reflect.Bool:
+		return dynamodb.NewBoolAttributeValue(rv.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return dynamodb.NewIntAttributeValue(rv.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return dynamodb.NewUintAttributeValue(rv.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		return dynamodb.NewFloatAttributeValue(rv.Float())
+
+	case reflect.String:
+		return stringValue(rv.String())
+
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			return dynamodb.NewAttributeValue(rv.Interface().(time.Time).UTC().Format(time.RFC3339Nano))
+		}
+		m := make(map[string]dynamodb.AttributeValue, rv.NumField())
+		t := rv.Type()
+		for i := 0; i < rv.NumField(); i++ {
+			if name, ok := nestedFieldName(t.Field(i)); ok {
+				m[name] = encodeValue(rv.Field(i))
+			}
+		}
+		return dynamodb.NewMapAttributeValue(m)
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return dynamodb.NewNullAttributeValue()
+		}
+		m := make(map[string]dynamodb.AttributeValue, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[k.String()] = encodeValue(rv.MapIndex(k))
+		}
+		return dynamodb.NewMapAttributeValue(m)
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() {
+				return dynamodb.NewNullAttributeValue()
+			}
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				return dynamodb.NewBinaryAttributeValue(rv.Bytes())
+			}
+		}
+		l := make([]dynamodb.AttributeValue, rv.Len())
+		for i := range l {
+			l[i] = encodeValue(rv.Index(i))
+		}
+		return dynamodb.NewListAttributeValue(l)
+	}
+
+	return dynamodb.NewNullAttributeValue()
+}
+
+// Returns an S attribute, which holds "" rather than being empty for an empty string.
+func stringValue(s string) dynamodb.AttributeValue {
+	if s == "" {
+		return dynamodb.NewEmptyStringAttributeValue()
+	}
+	return dynamodb.NewAttributeValue(s)
+}
+
+// Decodes a nested value into rv, the reverse of encodeValue. Sets are decoded into slices,
+// and an interface{} receives a string, float64, []byte, bool, nil, []interface{} or map[string]interface{}.
+func decodeValue(av dynamodb.AttributeValue, rv reflect.Value) {
+
+	if av.NULL {
+		rv.Set(reflect.Zero(rv.Type()))
+		return
+	}
+
+	if u, ok := unmarshalerOf(rv); ok {
+		if err := u.UnmarshalAttributeValue(av); err != nil {
+			log.Printf("The value `%s` could not be unmarshalled: %s\n", rv.Type(), err)
+		}
+		return
+	}
+	if tu, ok := textUnmarshalerOf(rv); ok && rv.Type() != timeType && av.Type() == dynamodb.STRING {
+		if err := tu.UnmarshalText([]byte(av.S)); err != nil {
+			log.Printf("The value `%s` could not be unmarshalled: %s\n", rv.Type(), err)
+		}
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		decodeValue(av, rv.Elem())
+
+	case reflect.Interface:
+		if rv.NumMethod() == 0 {
+			if v := decodeInterface(av); v != nil {
+				rv.Set(reflect.ValueOf(v))
+			}
+		}
+
+	case reflect.Bool:
+		switch av.Type() {
+		case dynamodb.BOOLEAN:
+			rv.SetBool(av.Bool())
+		case dynamodb.STRING:
+			rv.SetBool(av.S == "true")
+		case dynamodb.NUMBER:
+			rv.SetBool(av.N == "1")
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		rv.SetInt(av.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		rv.SetUint(av.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		rv.SetFloat(av.Float())
+
+	case reflect.String:
+		rv.SetString(av.S)
+
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			if av.Type() == dynamodb.NUMBER {
+				rv.Set(reflect.ValueOf(time.Unix(0, av.Int())))
+			} else if t, err := time.Parse(time.RFC3339Nano, av.S); err == nil {
+				rv.Set(reflect.ValueOf(t))
+			}
+			return
+		}
+		t := rv.Type()
+		for i := 0; i < rv.NumField(); i++ {
+			if name, ok := nestedFieldName(t.Field(i)); ok {
+				if attr, ok := av.M[name]; ok {
+					decodeValue(attr, rv.Field(i))
+				}
+			}
+		}
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String || av.M == nil {
+			return
+		}
+		m := reflect.MakeMap(rv.Type())
+		for k, attr := range av.M {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			decodeValue(attr, elem)
+			m.SetMapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()), elem)
+		}
+		rv.Set(m)
+
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 && av.Type() == dynamodb.BINARY {
+			rv.SetBytes(av.B)
+			return
+		}
+		l := listOf(av)
+		slice := reflect.MakeSlice(rv.Type(), len(l), len(l))
+		for i, attr := range l {
+			decodeValue(attr, slice.Index(i))
+		}
+		rv.Set(slice)
+
+	case reflect.Array:
+		for i, attr := range listOf(av) {
+			if i >= rv.Len() {
+				break
+			}
+			decodeValue(attr, rv.Index(i))
+		}
+	}
+}
+
+// Returns the elements of a list or set attribute.
+func listOf(av dynamodb.AttributeValue) []dynamodb.AttributeValue {
+
+	switch {
+	case av.L != nil:
+		return av.L
+	case len(av.SS) > 0:
+		l := make([]dynamodb.AttributeValue, len(av.SS))
+		for i, s := range av.SS {
+			l[i] = dynamodb.NewAttributeValue(s)
+		}
+		return l
+	case len(av.NS) > 0:
+		l := make([]dynamodb.AttributeValue, len(av.NS))
+		for i, n := range av.NS {
+			l[i] = dynamodb.AttributeValue{N: n}
+		}
+		return l
+	case len(av.BS) > 0:
+		l := make([]dynamodb.AttributeValue, len(av.BS))
+		for i, b := range av.BS {
+			l[i] = dynamodb.NewBinaryAttributeValue(b)
+		}
+		return l
+	}
+	return nil
+}
+
+// Decodes an attribute into the generic Go type of its data type.
+func decodeInterface(av dynamodb.AttributeValue) interface{} {
+
+	switch av.Type() {
+	case dynamodb.STRING:
+		if av.SS != nil {
+			return av.SS
+		}
+		return av.S
+	case dynamodb.NUMBER:
+		if av.NS != nil {
+			return av.FloatSet()
+		}
+		return av.Float()
+	case dynamodb.BOOLEAN:
+		return av.Bool()
+	case dynamodb.NULL_AT:
+		return nil
+	case dynamodb.LIST:
+		l := make([]interface{}, len(av.L))
+		for i, attr := range av.L {
+			l[i] = decodeInterface(attr)
+		}
+		return l
+	case dynamodb.MAP:
+		m := make(map[string]interface{}, len(av.M))
+		for k, attr := range av.M {
+			m[k] = decodeInterface(attr)
+		}
+		return m
+	}
+	if av.BS != nil {
+		return av.BS
+	}
+	return av.B
+}
+
+// Returns the attribute name of a nested struct field.
+func nestedFieldName(f reflect.StructField) (string, bool) {
+
+	if f.PkgPath != "" || f.Tag.Get("DynamoDBIgnore") == "true" {
+		return "", false
+	}
+	if name := f.Tag.Get("DynamoDBAttribute"); name != "" {
+		return name, true
+	}
+	return f.Name, true
+}
@@ -0,0 +1,116 @@
+package datamodeling
+
+import (
+	"encoding/json"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	Street string `DynamoDBAttribute:"street"`
+	Zip    int
+	Active bool
+	secret string
+}
+
+type documentItem struct {
+	Id       string                 `DynamoDBHashKey:"Id"`
+	Home     address                `DynamoDBAttribute:"Home"`
+	Previous []address              `DynamoDBAttribute:"Previous"`
+	Tags     map[string][]int       `DynamoDBAttribute:"Tags"`
+	Manager  *address               `DynamoDBAttribute:"Manager"`
+	Extra    map[string]interface{} `DynamoDBAttribute:"Extra"`
+	Empty    []address              `DynamoDBAttribute:"Empty"`
+	NilMap   map[string]string      `DynamoDBAttribute:"NilMap"`
+	IntArr   []int                  `DynamoDBAttribute:"IntArr"`
+	Ignored  address                `DynamoDBAttribute:"Ignored" DynamoDBIgnore:"true"`
+}
+
+func TestMarshalDocuments(t *testing.T) {
+
+	in := &documentItem{
+		Id:       "a",
+		Home:     address{Street: "Main", Zip: 12345, Active: true, secret: "x"},
+		Previous: []address{{Street: "Old"}},
+		Tags:     map[string][]int{"x": {1, 2}},
+		Manager:  &address{Street: "Boss"},
+		Extra:    map[string]interface{}{"s": "v", "n": 1.5, "l": []interface{}{true, nil}},
+		Empty:    []address{},
+		IntArr:   []int{3},
+	}
+
+	model := Marshal(in)
+
+	home := model.Item["Home"]
+	if home.Type() != dynamodb.MAP || home.M["street"].S != "Main" || home.M["Zip"].N != "12345" || !home.M["Active"].Bool() {
+		t.Fatalf("Unexpected Home: %v", home)
+	}
+	if _, ok := home.M["secret"]; ok {
+		t.Fatal("Expected unexported fields to be skipped.")
+	}
+	if model.Item["Previous"].Type() != dynamodb.LIST || model.Item["IntArr"].Type() != dynamodb.NUMBER {
+		t.Fatalf("Unexpected Previous or IntArr: %v %v", model.Item["Previous"], model.Item["IntArr"])
+	}
+	if !model.Item["NilMap"].IsEmpty() {
+		t.Fatalf("Expected a nil map to be empty, got %v", model.Item["NilMap"])
+	}
+
+	out := &documentItem{}
+	Unmarshal(model.Item, out)
+	in.Home.secret = ""
+	in.NilMap = nil
+
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("Round trip mismatch:\n%+v\n%+v", in, out)
+	}
+}
+
+func TestAttributeValueJSON(t *testing.T) {
+
+	tests := map[string]dynamodb.AttributeValue{
+		`{"L":[]}`:                      dynamodb.NewListAttributeValue(nil),
+		`{"M":{}}`:                      dynamodb.NewMapAttributeValue(nil),
+		`{"BOOL":false}`:                dynamodb.NewBoolAttributeValue(false),
+		`{"NULL":true}`:                 dynamodb.NewNullAttributeValue(),
+		`{"S":""}`:                      dynamodb.NewEmptyStringAttributeValue(),
+		`{"M":{"a":{"S":""}}}`:          dynamodb.NewMapAttributeValue(map[string]dynamodb.AttributeValue{"a": dynamodb.NewEmptyStringAttributeValue()}),
+		`{"M":{"a":{"L":[{"S":"b"}]}}}`: dynamodb.NewMapAttributeValue(map[string]dynamodb.AttributeValue{"a": dynamodb.NewListAttributeValue([]dynamodb.AttributeValue{dynamodb.NewAttributeValue("b")})}),
+	}
+
+	for expected, av := range tests {
+		b, err := json.Marshal(av)
+		if err != nil || string(b) != expected {
+			t.Errorf("Expected %s, got %s (%v)", expected, b, err)
+		}
+
+		var decoded dynamodb.AttributeValue
+		if err = json.Unmarshal(b, &decoded); err != nil || !reflect.DeepEqual(decoded, av) {
+			t.Errorf("Expected %v, got %v (%v)", av, decoded, err)
+		}
+	}
+}
+
+func TestEmptyStringJSON(t *testing.T) {
+
+	in := &documentItem{Id: "a", Home: address{Street: ""}, Extra: map[string]interface{}{"s": "", "l": []interface{}{""}}}
+
+	b, err := json.Marshal(Marshal(in).Item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var item map[string]dynamodb.AttributeValue
+	if err = json.Unmarshal(b, &item); err != nil {
+		t.Fatal(err)
+	}
+	if street := item["Home"].M["street"]; street.Type() != dynamodb.STRING || street.IsEmpty() {
+		t.Fatalf("Expected an empty S, got %v in %s", street, b)
+	}
+
+	out := &documentItem{}
+	Unmarshal(item, out)
+	if out.Home.Street != "" || out.Extra["s"] != "" || !reflect.DeepEqual(out.Extra["l"], []interface{}{""}) {
+		t.Errorf("Expected the empty strings, got %+v", out)
+	}
+}
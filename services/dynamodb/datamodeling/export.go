@@ -0,0 +1,204 @@
+package datamodeling
+
+import (
+	"encoding/json"
+	"github.com/twhello/aws-to-go/services"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"github.com/twhello/aws-to-go/util/ratelimiter"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// Exports the items of a scan to w in the JSON Lines format: one item per line in DynamoDB's typed JSON
+// encoding, e.g. {"Id":{"S":"a"},"Count":{"N":"1"}}. The segments, throughput limit and checkpoint of the
+// scan apply; a stopped export can be resumed with the checkpoint and appended to the same file.
+// Returns the number of exported items and the checkpoint of the scan.
+// (scan *ParallelScan) The scan of the table, e.g. NewParallelScan(service, dynamodb.NewScanRequest(tableName), 4).
+// (w io.Writer) The JSON Lines output. Writes are serialized.
+func ExportTable(scan *ParallelScan, w io.Writer) (int64, *ScanCheckpoint, error) {
+
+	var (
+		mutex sync.Mutex
+		count int64
+	)
+
+	encoder := json.NewEncoder(w)
+
+	checkpoint, err := scan.Run(func(item map[string]dynamodb.AttributeValue) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+
+	return count, checkpoint, err
+}
+
+/*****************************************************************************/
+
+// Imports items in the JSON Lines format of ExportTable into a table with BatchWriteItem.
+// Unprocessed items and throttled requests are retried with an exponential backoff.
+// Use datamodeling.NewTableImport().
+type TableImport struct {
+	batchWrite    func(*dynamodb.BatchWriteItemRequest) (*dynamodb.BatchWriteItemResult, error)
+	describeTable func(*dynamodb.DescribeTableRequest) (*dynamodb.DescribeTableResult, error)
+	tableName     string
+	limiter       *ratelimiter.RateLimiter
+	onProgress    func(written int64)
+}
+
+// Creates a new TableImport.
+// (service *dynamodb.DynamoDBService) The service to write with.
+// (tableName string) The name of the table to import into.
+func NewTableImport(service *dynamodb.DynamoDBService, tableName string) *TableImport {
+	return newTableImport(service.BatchWriteItem, service.DescribeTable, tableName)
+}
+
+func newTableImport(batchWrite func(*dynamodb.BatchWriteItemRequest) (*dynamodb.BatchWriteItemResult, error),
+	describeTable func(*dynamodb.DescribeTableRequest) (*dynamodb.DescribeTableResult, error), tableName string) *TableImport {
+	return &TableImport{batchWrite: batchWrite, describeTable: describeTable, tableName: tableName}
+}
+
+// Limits the write capacity units consumed per second. The import waits for the capacity
+// each batch consumed before it writes the next.
+func (i *TableImport) SetWriteCapacityLimit(unitsPerSecond uint32) {
+	if unitsPerSecond == 0 {
+		i.limiter = nil
+	} else {
+		i.limiter = ratelimiter.New(unitsPerSecond)
+	}
+}
+
+// Sets a function that is called with the total number of written items after every batch.
+func (i *TableImport) SetProgressHandler(fn func(written int64)) {
+	i.onProgress = fn
+}
+
+// Reads the items from r and puts them in batches of MAX_BATCH_WRITE_ITEMS.
+// BatchWriteItem rejects duplicate keys, so an item replaces an earlier one with the same primary key
+// in the batch, the last line winning as it would with PutItem. The key schema is read with DescribeTable.
+// Stops at the first error, which is returned with the number of items written before.
+// (r io.Reader) The JSON Lines input.
+func (i *TableImport) Run(r io.Reader) (int64, error) {
+
+	var written int64
+
+	keyNames, err := i.keyNames()
+	if err != nil {
+		return 0, err
+	}
+
+	decoder := json.NewDecoder(r)
+	batch := make([]dynamodb.WriteRequest, 0, MAX_BATCH_WRITE_ITEMS)
+	index := make(map[string]int, MAX_BATCH_WRITE_ITEMS)
+
+	for {
+
+		var item map[string]dynamodb.AttributeValue
+
+		err := decoder.Decode(&item)
+		if err != nil && err != io.EOF {
+			return written, err
+		}
+
+		if err == nil {
+			key := make(map[string]dynamodb.AttributeValue, len(keyNames))
+			for _, name := range keyNames {
+				key[name] = item[name]
+			}
+			id := entryId(i.tableName, key)
+
+			write := dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}}
+			if n, ok := index[id]; ok {
+				batch[n] = write
+				continue
+			}
+			index[id] = len(batch)
+			batch = append(batch, write)
+			if len(batch) < MAX_BATCH_WRITE_ITEMS {
+				continue
+			}
+		}
+
+		if len(batch) > 0 {
+			if werr := i.writeBatch(batch); werr != nil {
+				return written, werr
+			}
+			written += int64(len(batch))
+			batch = batch[:0]
+			index = make(map[string]int, MAX_BATCH_WRITE_ITEMS)
+
+			if i.onProgress != nil {
+				i.onProgress(written)
+			}
+		}
+
+		if err == io.EOF {
+			return written, nil
+		}
+	}
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+// Returns the names of the table's primary key attributes.
+func (i *TableImport) keyNames() ([]string, error) {
+
+	result, err := i.describeTable(dynamodb.NewDescribeTableRequest(i.tableName))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, 2)
+	for _, element := range result.Table.KeySchema {
+		names = append(names, element.AttributeName)
+	}
+	return names, nil
+}
+
+// Writes a batch, retrying the UnprocessedItems.
+func (i *TableImport) writeBatch(batch []dynamodb.WriteRequest) error {
+
+	pending := batch
+	maxRetries := services.Config().RetryAttempts()
+
+	for retries := uint(0); ; retries++ {
+
+		bwir := dynamodb.NewBatchWriteItemRequest(map[string][]dynamodb.WriteRequest{i.tableName: pending})
+		if i.limiter != nil {
+			bwir.ReturnConsumedCapacity = dynamodb.TOTAL
+		}
+
+		result, err := i.batchWrite(bwir)
+
+		if err == nil {
+			if i.limiter != nil {
+				for _, cc := range result.ConsumedCapacity {
+					if cc.CapacityUnits > 0 {
+						i.limiter.Aquire(int(math.Ceil(float64(cc.CapacityUnits))))
+					}
+				}
+			}
+			if pending = result.UnprocessedItems[i.tableName]; len(pending) == 0 {
+				return nil
+			}
+			err = errUnprocessed
+
+		} else if !isRetryable(err) {
+			return err
+		}
+
+		if retries >= maxRetries {
+			return err
+		}
+		time.Sleep(services.ExponentialBackoff(50*time.Millisecond, retries))
+	}
+}
@@ -0,0 +1,123 @@
+package datamodeling
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	count, checkpoint, err := ExportTable(newParallelScan(segmentedScan(30, 0), dynamodb.NewScanRequest("Source"), 2), &buf)
+	if err != nil || count != 60 || !checkpoint.IsDone() {
+		t.Fatalf("Unexpected export %d %+v (%v)", count, checkpoint, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 60 || !strings.HasPrefix(lines[0], `{"Id":{"S":"`) {
+		t.Fatalf("Expected 60 typed JSON lines, got %d: %s", len(lines), lines[0])
+	}
+
+	imported := make(map[string]bool)
+	unprocessed := true
+	batches := 0
+
+	imp := newTableImport(func(bwir *dynamodb.BatchWriteItemRequest) (*dynamodb.BatchWriteItemResult, error) {
+		batches++
+		writes := bwir.RequestItems["Target"]
+		if len(writes) > MAX_BATCH_WRITE_ITEMS || bwir.ReturnConsumedCapacity != dynamodb.TOTAL {
+			t.Errorf("Unexpected request %+v", bwir)
+		}
+		result := &dynamodb.BatchWriteItemResult{ConsumedCapacity: []dynamodb.ConsumedCapacity{{CapacityUnits: 1}}}
+		if unprocessed {
+			unprocessed = false
+			result.UnprocessedItems = map[string][]dynamodb.WriteRequest{"Target": writes[20:]}
+			writes = writes[:20]
+		}
+		for _, w := range writes {
+			imported[w.PutRequest.Item["Id"].S] = true
+		}
+		return result, nil
+	}, describeTable("Id"), "Target")
+	imp.SetWriteCapacityLimit(1000)
+
+	var progress []int64
+	imp.SetProgressHandler(func(written int64) { progress = append(progress, written) })
+
+	written, err := imp.Run(&buf)
+	if err != nil || written != 60 || len(imported) != 60 {
+		t.Fatalf("Expected 60 imported items, got %d %d (%v)", written, len(imported), err)
+	}
+	if batches != 4 || len(progress) != 3 || progress[2] != 60 {
+		t.Errorf("Unexpected batches %d and progress %v", batches, progress)
+	}
+}
+
+func TestImportDuplicateKeys(t *testing.T) {
+
+	var requests [][]dynamodb.WriteRequest
+	imported := make(map[string]string)
+
+	imp := newTableImport(func(bwir *dynamodb.BatchWriteItemRequest) (*dynamodb.BatchWriteItemResult, error) {
+		writes := bwir.RequestItems["Target"]
+		requests = append(requests, writes)
+		for _, w := range writes {
+			imported[w.PutRequest.Item["Id"].S+"/"+w.PutRequest.Item["Sort"].N] = w.PutRequest.Item["Name"].S
+		}
+		return &dynamodb.BatchWriteItemResult{}, nil
+	}, describeTable("Id", "Sort"), "Target")
+
+	var lines []string
+	for n := 0; n < 30; n++ {
+		lines = append(lines, fmt.Sprintf(`{"Id":{"S":"a"},"Sort":{"N":"%d"},"Name":{"S":"first"}}`, n))
+	}
+	lines = append(lines, `{"Id":{"S":"a"},"Sort":{"N":"26"},"Name":{"S":"last"}}`, `{"Id":{"S":"a"},"Sort":{"N":"26"},"Name":{"S":"again"}}`)
+
+	written, err := imp.Run(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil || written != 30 || len(imported) != 30 {
+		t.Fatalf("Expected 30 imported items, got %d %d (%v)", written, len(imported), err)
+	}
+	if len(requests) != 2 || len(requests[0]) != MAX_BATCH_WRITE_ITEMS || len(requests[1]) != 5 {
+		t.Errorf("Expected batches of 25 and 5 unique keys, got %d batches", len(requests))
+	}
+	if imported["a/26"] != "again" || imported["a/25"] != "first" {
+		t.Errorf("Expected the last line of a key to win, got %v", imported)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+
+	imp := newTableImport(func(*dynamodb.BatchWriteItemRequest) (*dynamodb.BatchWriteItemResult, error) {
+		return nil, errors.New("denied")
+	}, describeTable("Id"), "Target")
+
+	if written, err := imp.Run(strings.NewReader(`{"Id":{"S":"a"}}` + "\n")); err == nil || written != 0 {
+		t.Errorf("Expected the write error, got %d (%v)", written, err)
+	}
+
+	imp = newTableImport(func(*dynamodb.BatchWriteItemRequest) (*dynamodb.BatchWriteItemResult, error) {
+		return &dynamodb.BatchWriteItemResult{}, nil
+	}, describeTable("Id"), "Target")
+
+	if written, err := imp.Run(strings.NewReader(`{"Id":{"S":"a"}}` + "\nnot json\n")); err == nil || written != 0 {
+		t.Errorf("Expected a syntax error, got %d (%v)", written, err)
+	}
+}
+
+// Returns a DescribeTable function for a table with the key attributes.
+func describeTable(hashKey string, rangeKey ...string) func(*dynamodb.DescribeTableRequest) (*dynamodb.DescribeTableResult, error) {
+	return func(dtr *dynamodb.DescribeTableRequest) (*dynamodb.DescribeTableResult, error) {
+		result := new(dynamodb.DescribeTableResult)
+		result.Table.TableName = dtr.TableName
+		result.Table.KeySchema = []dynamodb.KeySchemaElement{{AttributeName: hashKey, KeyType: dynamodb.HASH}}
+		for _, name := range rangeKey {
+			result.Table.KeySchema = append(result.Table.KeySchema, dynamodb.KeySchemaElement{AttributeName: name, KeyType: dynamodb.RANGE})
+		}
+		return result, nil
+	}
+}
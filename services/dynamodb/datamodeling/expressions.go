@@ -40,7 +40,7 @@ type QueryExpression struct {
 
 // Creates a new QueryExpressoin.
 func NewQueryExpression(hashKeyValues ...dynamodb.AttributeValue) *QueryExpression {
-	return &QueryExpression{HashKeyValues: hashKeyValues, Limit: 1000}
+	return &QueryExpression{HashKeyValues: hashKeyValues, Limit: 1000, ScanIndexForward: true}
 }
 
 // Adds a  exclusive start key for this query.
@@ -129,7 +129,7 @@ type ScanExpression struct {
 func NewScanExpression(attributeName string, condition dynamodb.Condition) *ScanExpression {
 	return &ScanExpression{
 		ScanFilter: map[string]dynamodb.Condition{attributeName: condition},
-		Limit:      1000, Segment: 0, TotalSegments: 100,
+		Limit:      1000,
 	}
 }
 
@@ -143,6 +143,9 @@ func (e *ScanExpression) AddExclusiveStartKeyEntry(attributeName string, attribu
 
 // Adds a new filter condition to the current scan filter.
 func (e *ScanExpression) AddFilterConditionEntry(attributeName string, condition dynamodb.Condition) {
+	if len(e.ScanFilter) == 0 {
+		e.ScanFilter = make(map[string]dynamodb.Condition)
+	}
 	e.ScanFilter[attributeName] = condition
 }
 
@@ -162,6 +165,7 @@ func (e *ScanExpression) SetSegment(segment int64) {
 }
 
 // Sets the total number of segments into which the scan will be divided.
+// A value of 0 or 1 scans the table sequentially.
 func (e *ScanExpression) SetTotalSegments(totalSegments int64) {
 	e.TotalSegments = totalSegments
 }
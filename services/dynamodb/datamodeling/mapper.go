@@ -1,13 +1,15 @@
 package datamodeling
 
 import (
+	"errors"
 	"github.com/twhello/aws-to-go/services/dynamodb"
+	"reflect"
 )
 
 // Struct mapper for domain-object interaction with DynamoDB.
 //
 // Default configuration uses UPDATE behavior for saves and EVENTUALly consistent reads,
-// with no table name override and lazy-loading strategy.
+// with no table name prefix or resolver and lazy-loading strategy.
 type DynamoDBMapper struct {
 	DynamoDBService      *dynamodb.DynamoDBService
 	DynamoDBMapperConfig DynamoDBMapperConfig
@@ -15,15 +17,36 @@ type DynamoDBMapper struct {
 
 /*****************************************************************************/
 
+// Returns a copy of the mapper that uses the config, to override the mapper's configuration
+// on individual operations, e.g. m.WithConfig(config).Save(v).
+func (m *DynamoDBMapper) WithConfig(config DynamoDBMapperConfig) *DynamoDBMapper {
+	return &DynamoDBMapper{m.DynamoDBService, config}
+}
+
+// Returns the table name of v, resolved by the mapper's config.
+// (v interface{}) A pointer to a struct of the item type.
+func (m *DynamoDBMapper) TableName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return m.DynamoDBMapperConfig.resolveTableName(t, cachedStructInfo(t).tableName)
+}
+
+/*****************************************************************************/
+
 // Deletes the given object from its DynamoDB table.
 func (m *DynamoDBMapper) Delete(v interface{}) (*dynamodb.DeleteItemResult, error) {
 	return m.DeleteWithExpression(v, nil)
 }
 
 // Deletes the given object from its DynamoDB table using the provided deleteExpression.
+// If the struct has a DynamoDBVersionAttribute, the delete only succeeds if the item's version matches,
+// otherwise a *VersionConflictError is returned. If the expression has Expected conditions of its own,
+// the ConditionalCheckFailedException is returned instead, as any of the conditions may have failed.
 func (m *DynamoDBMapper) DeleteWithExpression(v interface{}, expression *DeleteExpression) (*dynamodb.DeleteItemResult, error) {
 
-	model := Marshal(v)
+	model := m.marshal(v)
 
 	dir := dynamodb.NewDeleteItemRequest(model.TableName)
 
@@ -39,8 +62,21 @@ func (m *DynamoDBMapper) DeleteWithExpression(v interface{}, expression *DeleteE
 		dir.Expected = expression.Expected
 	}
 
+	ver, err := newVersioning(v, model)
+	if err != nil {
+		return nil, err
+	}
+	if ver != nil {
+		if dir.Expected, err = ver.expect(dir.ConditionalOperator, dir.Expected); err != nil {
+			return nil, err
+		}
+	}
+
 	result, err := m.DynamoDBService.DeleteItem(dir)
 	if err != nil {
+		if ver != nil {
+			err = ver.conflict(model.TableName, err)
+		}
 		return nil, err
 	}
 
@@ -52,7 +88,7 @@ func (m *DynamoDBMapper) DeleteWithExpression(v interface{}, expression *DeleteE
 // Loads an object with a hash and, if provided, range key.
 func (m *DynamoDBMapper) Load(v interface{}) (*dynamodb.GetItemResult, error) {
 
-	model := Marshal(v)
+	model := m.marshal(v)
 
 	gir := dynamodb.NewGetItemRequest(model.TableName)
 	gir.ConsistentRead = m.DynamoDBMapperConfig.ConsistentReads == CONSISTANT
@@ -81,26 +117,107 @@ func (m *DynamoDBMapper) Load(v interface{}) (*dynamodb.GetItemResult, error) {
 	return result, nil
 }
 
-/*****************************************************************************
+/*****************************************************************************/
 
-//
-func (m *DynamoDBMapper) Query(request dynamodb.QueryRequest, v interface{}) ([]interface{}, dynamodb.QueryResult, error) {
+// Queries a table or an index with the request and returns a PaginatedList of
+// pointers to new structs of v's type. An empty TableName defaults to v's table.
+// (v interface{}) A pointer to a struct of the item type.
+func (m *DynamoDBMapper) Query(request *dynamodb.QueryRequest, v interface{}) (*PaginatedList, error) {
 
-	return nil, dynamodb.QueryResult{}, nil
+	qr := *request
+	if qr.TableName == "" {
+		qr.TableName = m.TableName(v)
+	}
+	return newPaginatedList(v, m.DynamoDBMapperConfig.PaginationLoadingStrategy, request.ExclusiveStartKey,
+		func(exclusiveStartKey map[string]dynamodb.AttributeValue) ([]map[string]dynamodb.AttributeValue, map[string]dynamodb.AttributeValue, error) {
+			qr.ExclusiveStartKey = exclusiveStartKey
+			result, err := m.DynamoDBService.Query(&qr)
+			if err != nil {
+				return nil, nil, err
+			}
+			return result.Items, result.LastEvaluatedKey, nil
+		})
 }
 
-//
-func (m *DynamoDBMapper) QueryWithExpression(request dynamodb.QueryRequest, v interface{}) ([]interface{}, dynamodb.QueryResult, error) {
+// Queries v's table, or the expression's index, and returns a PaginatedList of
+// pointers to new structs of v's type. The hash key value is taken from the
+// expression's HashKeyValues or, if empty, from v.
+// (v interface{}) A pointer to a struct of the item type.
+func (m *DynamoDBMapper) QueryWithExpression(v interface{}, expression *QueryExpression) (*PaginatedList, error) {
 
-	return nil, dynamodb.QueryResult{}, nil
+	qr, err := m.newQueryRequest(v, expression)
+	if err != nil {
+		return nil, err
+	}
+	return m.Query(qr, v)
 }
 
-//
-func (m *DynamoDBMapper) QueryCount(request dynamodb.QueryRequest) (int, error) {
+// Returns the number of items matching the query expression. Does not load any items.
+// (v interface{}) A pointer to a struct of the item type.
+func (m *DynamoDBMapper) QueryCount(v interface{}, expression *QueryExpression) (int, error) {
 
-	return 0, nil
+	qr, err := m.newQueryRequest(v, expression)
+	if err != nil {
+		return 0, err
+	}
+	qr.Select = dynamodb.COUNT
+
+	count := 0
+	for {
+		result, err := m.DynamoDBService.Query(qr)
+		if err != nil {
+			return 0, err
+		}
+		count += result.Count
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return count, nil
+		}
+		qr.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// Builds the QueryRequest for the expression.
+func (m *DynamoDBMapper) newQueryRequest(v interface{}, expression *QueryExpression) (*dynamodb.QueryRequest, error) {
+
+	model := m.marshal(v)
+
+	if expression == nil {
+		expression = NewQueryExpression()
+	}
+
+	hashKey := model.HashKey
+	if expression.IndexName != "" && model.IndexHashKey != "" {
+		hashKey = model.IndexHashKey
+	}
+
+	hashValue, ok := model.Item[hashKey]
+	if len(expression.HashKeyValues) > 0 {
+		hashValue, ok = expression.HashKeyValues[0], true
+	}
+	if hashKey == "" || !ok || hashValue.IsEmpty() {
+		return nil, errors.New("datamodeling: A query requires a hash key value.")
+	}
+
+	qr := dynamodb.NewQueryRequest(model.TableName)
+	qr.KeyConditions[hashKey] = dynamodb.Condition{
+		AttributeValueList: []dynamodb.AttributeValue{hashValue},
+		ComparisonOperator: dynamodb.EQUAL,
+	}
+	for name, condition := range expression.RangeKeyConditions {
+		qr.KeyConditions[name] = condition
+	}
+
+	qr.ConditionalOperator = expression.ConditionalOperator
+	qr.ConsistentRead = expression.ConsistentRead || m.DynamoDBMapperConfig.ConsistentReads == CONSISTANT
+	qr.ExclusiveStartKey = expression.ExclusiveStartKey
+	qr.IndexName = expression.IndexName
+	qr.Limit = int(expression.Limit)
+	qr.QueryFilter = expression.QueryFilter
+	qr.SetScanIndexForward(expression.ScanIndexForward)
+
+	return qr, nil
 }
-*/
 /*****************************************************************************/
 
 // Saves the object given into DynamoDB.
@@ -109,22 +226,52 @@ func (m *DynamoDBMapper) Save(v interface{}) error {
 }
 
 // Saves the object given into DynamoDB, the specified saveExpression.
+// If the struct has a DynamoDBVersionAttribute, the save only succeeds if the item's version matches
+// (or, for a zero version, if the item does not exist) and the version field is incremented.
+// A *VersionConflictError is returned if the versions do not match. If the expression has Expected conditions
+// of its own, the ConditionalCheckFailedException is returned instead, as any of the conditions may have failed.
 func (m *DynamoDBMapper) SaveWithExpression(v interface{}, expression *SaveExpression) error {
 
+	model := m.marshal(v)
+
+	ver, err := newVersioning(v, model)
+	if err != nil {
+		return err
+	}
+
+	if ver != nil {
+		saveExpression := &SaveExpression{}
+		if expression != nil {
+			*saveExpression = *expression
+		}
+		if saveExpression.Expected, err = ver.expect(saveExpression.ConditionalOperator, saveExpression.Expected); err != nil {
+			return err
+		}
+		expression = saveExpression
+		model.Item[ver.attribute] = ver.next
+	}
+
 	switch m.DynamoDBMapperConfig.SaveBehavior {
 	case CLOBBER:
-		return m.saveClobber(v, expression)
+		err = m.saveClobber(model, expression)
 	case UPDATE_SKIP_NULL_ATTRIBUTES:
-		return m.saveUpdateSkipNullAttributes(v, expression)
+		err = m.saveUpdate(model, expression, true)
 	default:
-		return m.saveUpdate(v, expression)
+		err = m.saveUpdate(model, expression, false)
 	}
+
+	if ver != nil {
+		if err != nil {
+			return ver.conflict(model.TableName, err)
+		}
+		ver.increment()
+	}
+
+	return err
 }
 
 // CLOBBER will clear and replace all attributes, included unmodeled ones, (delete and recreate) on save.
-func (m *DynamoDBMapper) saveClobber(v interface{}, expression *SaveExpression) error {
-
-	model := Marshal(v)
+func (m *DynamoDBMapper) saveClobber(model *DataModel, expression *SaveExpression) error {
 
 	pir := dynamodb.NewPutItemRequest(model.TableName)
 
@@ -149,37 +296,22 @@ func (m *DynamoDBMapper) saveClobber(v interface{}, expression *SaveExpression)
 
 // UPDATE will not affect unmodeled attributes on a save operation and a null value for the
 // modeled attribute will remove it from that item in DynamoDB.
-func (m *DynamoDBMapper) saveUpdate(v interface{}, expression *SaveExpression) error {
-
-	model := Marshal(v)
-
-	uir := dynamodb.NewUpdateItemRequest(model.TableName)
-	uir.Key = model.Item
-
-	if expression != nil {
-		uir.ConditionalOperator = expression.ConditionalOperator
-		uir.Expected = expression.Expected
-	}
-	
-	_, err := m.DynamoDBService.UpdateItem(uir)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// UPDATE_SKIP_NULL_ATTRIBUTES is similar to UPDATE, except that it ignores any null value attribute(s)
+//
+// UPDATE_SKIP_NULL_ATTRIBUTES (skipNull) is similar to UPDATE, except that it ignores any null value attribute(s)
 // and will NOT remove them from that item in DynamoDB.
-func (m *DynamoDBMapper) saveUpdateSkipNullAttributes(v interface{}, expression *SaveExpression) error {
-
-	model := Marshal(v)
+func (m *DynamoDBMapper) saveUpdate(model *DataModel, expression *SaveExpression, skipNull bool) error {
 
 	uir := dynamodb.NewUpdateItemRequest(model.TableName)
 
 	for k, v := range model.Item {
-		if !v.IsEmpty() {
+		switch {
+		case k == model.HashKey || k == model.RangeKey:
 			uir.AddKey(k, v)
+		case !v.IsEmpty():
+			attr := v
+			uir.AddAttributeUpdate(k, dynamodb.PUT, &attr)
+		case !skipNull:
+			uir.AddAttributeUpdate(k, dynamodb.DELETE, nil)
 		}
 	}
 
@@ -196,23 +328,82 @@ func (m *DynamoDBMapper) saveUpdateSkipNullAttributes(v interface{}, expression
 	return nil
 }
 
-/*****************************************************************************
+/*****************************************************************************/
 
-//
-func (m *DynamoDBMapper) Scan(request dynamodb.ScanRequest, v interface{}) ([]interface{}, dynamodb.ScanResult, error) {
+// Scans a table with the request and returns a PaginatedList of pointers to
+// new structs of v's type. An empty TableName defaults to v's table.
+// (v interface{}) A pointer to a struct of the item type.
+func (m *DynamoDBMapper) Scan(request *dynamodb.ScanRequest, v interface{}) (*PaginatedList, error) {
 
-	return nil, dynamodb.ScanResult{}, nil
+	sr := *request
+	if sr.TableName == "" {
+		sr.TableName = m.TableName(v)
+	}
+	return newPaginatedList(v, m.DynamoDBMapperConfig.PaginationLoadingStrategy, request.ExclusiveStartKey,
+		func(exclusiveStartKey map[string]dynamodb.AttributeValue) ([]map[string]dynamodb.AttributeValue, map[string]dynamodb.AttributeValue, error) {
+			sr.ExclusiveStartKey = exclusiveStartKey
+			result, err := m.DynamoDBService.Scan(&sr)
+			if err != nil {
+				return nil, nil, err
+			}
+			return result.Items, result.LastEvaluatedKey, nil
+		})
 }
 
-//
-func (m *DynamoDBMapper) ScanWithExpression(request dynamodb.ScanRequest, v interface{}) ([]interface{}, dynamodb.ScanResult, error) {
+// Scans v's table and returns a PaginatedList of pointers to new structs of v's type.
+// (v interface{}) A pointer to a struct of the item type.
+// (expression *ScanExpression) Can be nil to scan the whole table.
+func (m *DynamoDBMapper) ScanWithExpression(v interface{}, expression *ScanExpression) (*PaginatedList, error) {
+	return m.Scan(m.newScanRequest(v, expression), v)
+}
+
+// Returns the number of items matching the scan expression. Does not load any items.
+// (v interface{}) A pointer to a struct of the item type.
+// (expression *ScanExpression) Can be nil to count the whole table.
+func (m *DynamoDBMapper) ScanCount(v interface{}, expression *ScanExpression) (int, error) {
+
+	sr := m.newScanRequest(v, expression)
+	sr.Select = dynamodb.COUNT
+
+	count := 0
+	for {
+		result, err := m.DynamoDBService.Scan(sr)
+		if err != nil {
+			return 0, err
+		}
+		count += result.Count
 
-	return nil, dynamodb.ScanResult{}, nil
+		if len(result.LastEvaluatedKey) == 0 {
+			return count, nil
+		}
+		sr.ExclusiveStartKey = result.LastEvaluatedKey
+	}
 }
 
-//
-func (m *DynamoDBMapper) ScanCount(request dynamodb.ScanRequest) (int, error) {
+// Builds the ScanRequest for the expression.
+func (m *DynamoDBMapper) newScanRequest(v interface{}, expression *ScanExpression) *dynamodb.ScanRequest {
 
-	return 0, nil
+	sr := dynamodb.NewScanRequest(m.TableName(v))
+
+	if expression != nil {
+		sr.ConditionalOperator = expression.ConditionalOperator
+		sr.ExclusiveStartKey = expression.ExclusiveStartKey
+		sr.Limit = int(expression.Limit)
+		sr.ScanFilter = expression.ScanFilter
+		if expression.TotalSegments > 1 {
+			sr.SetSegment(expression.Segment, int(expression.TotalSegments))
+		}
+	}
+
+	return sr
+}
+/*****************************************************************************
+ * Private Methods
+ */
+
+// Marshals v with the table name resolved by the mapper's config.
+func (m *DynamoDBMapper) marshal(v interface{}) *DataModel {
+	model := Marshal(v)
+	model.TableName = m.DynamoDBMapperConfig.resolveTableName(reflect.TypeOf(v).Elem(), model.TableName)
+	return model
 }
-*/
\ No newline at end of file
@@ -0,0 +1,142 @@
+package datamodeling
+
+import (
+	"encoding/json"
+	"github.com/twhello/aws-to-go/auth"
+	"github.com/twhello/aws-to-go/regions"
+	"github.com/twhello/aws-to-go/services"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Returns a mapper whose requests are served by the handler instead of DynamoDB. The handler is called
+// with the operation of the X-Amz-Target header and the JSON request, and returns the status and JSON response.
+// The returned function restores the shared http.Client.
+func newTestMapper(handler func(operation string, body []byte) (int, string)) (*DynamoDBMapper, func()) {
+
+	client := services.HttpClient()
+	transport := client.Transport
+
+	client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		operation := strings.TrimPrefix(req.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+		status, response := handler(operation, body)
+
+		w := httptest.NewRecorder()
+		w.WriteHeader(status)
+		w.WriteString(response)
+		return w.Result(), nil
+	})
+
+	service := dynamodb.NewService(auth.NewCredentials("AKID", "SECRET"), regions.Config(regions.DEFAULT_REGION))
+	return &DynamoDBMapper{DynamoDBService: service}, func() { client.Transport = transport }
+}
+
+const conditionalCheckFailed = `{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`
+
+type savedItem struct {
+	Id      string `DynamoDBHashKey:"Id"`
+	Name    string `DynamoDBAttribute:"Name"`
+	Note    string `DynamoDBAttribute:"Note"`
+	Version int64  `DynamoDBVersionAttribute:"Version"`
+}
+
+func TestSaveBehavior(t *testing.T) {
+
+	var update *dynamodb.UpdateItemRequest
+	var put *dynamodb.PutItemRequest
+
+	m, restore := newTestMapper(func(operation string, body []byte) (int, string) {
+		switch operation {
+		case "UpdateItem":
+			update = new(dynamodb.UpdateItemRequest)
+			json.Unmarshal(body, update)
+		case "PutItem":
+			put = new(dynamodb.PutItemRequest)
+			json.Unmarshal(body, put)
+		}
+		return 200, "{}"
+	})
+	defer restore()
+
+	// UPDATE puts the attributes, deletes the null ones and conditions on the version.
+	item := &savedItem{Id: "a", Name: "name"}
+	if err := m.Save(item); err != nil {
+		t.Fatal(err)
+	}
+	if len(update.Key) != 1 || update.Key["Id"].S != "a" {
+		t.Errorf("Expected only the hash key in the Key, was %v", update.Key)
+	}
+	if u := update.AttributeUpdates; len(u) != 3 || u["Name"].Action != string(dynamodb.PUT) || u["Name"].Value.S != "name" ||
+		u["Note"].Action != string(dynamodb.DELETE) || u["Version"].Value.N != "1" {
+		t.Errorf("Unexpected attribute updates %v", u)
+	}
+	if update.Expected["Version"].ComparisonOperator != dynamodb.NULL || item.Version != 1 {
+		t.Errorf("Expected a new item condition and version 1, was %v and %d", update.Expected, item.Version)
+	}
+
+	// UPDATE_SKIP_NULL_ATTRIBUTES leaves the null attributes as they are.
+	if err := m.WithConfig(DynamoDBMapperConfig{SaveBehavior: UPDATE_SKIP_NULL_ATTRIBUTES}).Save(item); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := update.AttributeUpdates["Note"]; ok || len(update.AttributeUpdates) != 2 {
+		t.Errorf("Expected the null attribute to be skipped, was %v", update.AttributeUpdates)
+	}
+	if c := update.Expected["Version"]; c.ComparisonOperator != dynamodb.EQUAL || c.AttributeValueList[0].N != "1" || item.Version != 2 {
+		t.Errorf("Expected a version 1 condition and version 2, was %v and %d", update.Expected, item.Version)
+	}
+
+	// CLOBBER replaces the item.
+	if err := m.WithConfig(DynamoDBMapperConfig{SaveBehavior: CLOBBER}).Save(item); err != nil {
+		t.Fatal(err)
+	}
+	if len(put.Item) != 3 || put.Item["Id"].S != "a" || put.Item["Name"].S != "name" || put.Item["Version"].N != "3" {
+		t.Errorf("Unexpected item %v", put.Item)
+	}
+}
+
+func TestVersionConflict(t *testing.T) {
+
+	m, restore := newTestMapper(func(operation string, body []byte) (int, string) {
+		return 400, conditionalCheckFailed
+	})
+	defer restore()
+
+	item := &savedItem{Id: "a", Version: 3}
+	if err, ok := m.Save(item).(*VersionConflictError); !ok || err.Version != "3" {
+		t.Errorf("Expected a VersionConflictError, was %v", err)
+	}
+	if _, err := m.Delete(item); err == nil {
+		t.Error("Expected an error.")
+	} else if _, ok := err.(*VersionConflictError); !ok {
+		t.Errorf("Expected a VersionConflictError, was %v", err)
+	}
+
+	// With conditions of its own, the exception does not tell which condition failed.
+	expected := dynamodb.ExpectedAttributeValue{ComparisonOperator: dynamodb.NOT_NULL}
+	err := m.SaveWithExpression(item, NewSaveExpression(dynamodb.AND, "Name", expected))
+	if _, ok := err.(*VersionConflictError); ok || !dynamodb.IsErrorType(err, dynamodb.CONDITIONAL_CHECK_FAILED_EXCEPTION) {
+		t.Errorf("Expected the ConditionalCheckFailedException, was %v", err)
+	}
+	_, err = m.DeleteWithExpression(item, NewDeleteExpression(dynamodb.AND, "Name", expected))
+	if _, ok := err.(*VersionConflictError); ok || !dynamodb.IsErrorType(err, dynamodb.CONDITIONAL_CHECK_FAILED_EXCEPTION) {
+		t.Errorf("Expected the ConditionalCheckFailedException, was %v", err)
+	}
+
+	if item.Version != 3 {
+		t.Errorf("Expected the version to be unchanged, was %d", item.Version)
+	}
+}
@@ -50,7 +50,21 @@ bool, byte, float32, float64, int, int8, int16, int32, int64, rune, string, uint
 
 Also supports the `time.Time` type.
 
-Does not support pointers.
+Nested structs, slices, arrays, maps with string keys, interface{} and pointers to them are stored
+natively as Map (M) and List (L) documents, so they can be read by other SDKs and used in expressions.
+Nested fields are named by their DynamoDBAttribute tag, or else by the field name; fields tagged
+DynamoDBIgnore:"true" and unexported fields are skipped. Nested bool values are stored as BOOL,
+time.Time as an RFC3339Nano string and nil as NULL. A nil top-level field is an empty attribute.
+
+Pointer fields are stored as the value they point to. A nil pointer is an empty attribute, which is
+omitted on save and removed by UPDATE, or NULL if the field is tagged DynamoDBNullable:"true".
+
+The fields of an untagged embedded struct, or pointer to struct, are flattened into the item as if they
+were fields of the outer struct, which hide embedded fields of the same attribute name.
+
+Types implementing Marshaler and Unmarshaler control their own AttributeValue encoding. Otherwise,
+types implementing encoding.TextMarshaler and encoding.TextUnmarshaler are stored as a STRING, and
+named string and number types as their underlying type.
 
 Other types are serialized into a BINARY attribute. Only compatible with this Go SDK.
 
@@ -76,11 +90,19 @@ DynamoDBType:"STRING"
 Tags a field as an attribute type in a DynamoDB table. Required for bool and time.Time types.
 Valid values: STRING or S, NUMBER or N, and BINARY or B. Default: STRING.
 
+DynamoDBOmitEmpty:"true"
+Omits the field from the item if it has an empty value: false, 0, a nil pointer, interface, map or slice,
+an empty array, map, slice or string, or a zero time.Time. An UPDATE then leaves the attribute unchanged.
+
+DynamoDBNullable:"true"
+Stores a nil pointer field as NULL rather than omitting it.
+
 DynamoDBTimeFormat:"2006-01-02T15:04:05Z07:00"
 Tags a time.Time field mapping to a STRING attribute with a format layout. Default: time.RFC3339Nano.
 
 DynamoDBVersionAttribute:"attributeName"
-Tags a field as an optimistic locking version attribute.
+Tags a field as an optimistic locking version attribute. The field must be an integer type.
+Saves and deletes are conditioned on the version and saves increment it; a zero version is a new item.
 
 DynamoDBHashKey:"attributeName"
 Tags a field as the hash key for a modeled struct.
@@ -94,7 +116,21 @@ global secondary indexes on a DynamoDB table.
 
 DynamoDBIndexRangeKey:"attributeName"
 Tags a field in a struct as the attribute to be used as range key for one or more
-local secondary indexes on a DynamoDB table.
+local or global secondary indexes on a DynamoDB table.
+
+DynamoDBGlobalSecondaryIndexNames:"IndexA,IndexB"
+Names the global secondary indexes of a DynamoDBIndexHashKey or DynamoDBIndexRangeKey field.
+Default for a DynamoDBIndexHashKey field: "attributeName-index".
+
+DynamoDBLocalSecondaryIndexNames:"IndexA,IndexB"
+Names the local secondary indexes of a DynamoDBIndexRangeKey field. Default, if neither index names
+tag is present: "attributeName-index".
+
+DynamoDBProjectionType:"KEYS_ONLY"
+Sets the projection of the indexes named on an index key field. Valid values: ALL, KEYS_ONLY. Default: ALL.
+
+DynamoDBProjectedIndexes:"IndexA,IndexB"
+Projects the field's attribute into the named indexes, which then use an INCLUDE projection.
 
 DynamoDBAutoGeneratedKey:"true"
 Tag for marking a hash key or range key property in a struct to auto-generate this key.
@@ -104,175 +140,34 @@ Only String typed keys can be auto generated, and are given a random UUID.
 func Marshal(v interface{}) *DataModel {
 
 	e := reflect.ValueOf(v).Elem()
-	t := e.Type()
+	info := cachedStructInfo(e.Type())
 
-	model := &DataModel{TableName: t.Name(), Item: make(map[string]dynamodb.AttributeValue)}
+	model := &DataModel{TableName: info.tableName, Item: make(map[string]dynamodb.AttributeValue, len(info.fields))}
 
-	for i := 0; i < e.NumField(); i++ {
+	for i := range info.fields {
 
-		f, ft := e.Field(i), t.Field(i).Tag
+		field := &info.fields[i]
 
-		if val := ft.Get("DynamoDBTable"); val != "" {
-			model.TableName = val
-			continue
+		switch field.tag {
+		case "DynamoDBHashKey":
+			model.HashKey = field.name
+		case "DynamoDBRangeKey":
+			model.RangeKey = field.name
+		case "DynamoDBIndexHashKey":
+			model.IndexHashKey = field.name
+		case "DynamoDBIndexRangeKey":
+			model.IndexRangeKey = field.name
+		case "DynamoDBVersionAttribute":
+			model.VersionAttribute = field.name
 		}
 
-		for _, tag := range range_list {
-
-			// Check if the field contains the tag.
-			if val := ft.Get(tag); val != "" {
-
-				switch tag {
-				case "DynamoDBHashKey":
-					model.HashKey = val
-				case "DynamoDBRangeKey":
-					model.RangeKey = val
-				case "DynamoDBIndexHashKey":
-					model.IndexHashKey = val
-				case "DynamoDBIndexRangeKey":
-					model.IndexRangeKey = val
-				case "DynamoDBVersionAttribute":
-					model.VersionAttribute = val
-				}
-
-				switch f.Type().String() {
-				case "time.Time":
-					if datetime, ok := f.Interface().(time.Time); ok {
-						switch ft.Get("DynamoDBType") {
-						case "N", "NUMBER", "n", "number":
-							model.Item[val] = dynamodb.NewIntAttributeValue(datetime.UnixNano())
-						case "B", "BINARY", "b", "binary":
-							b, _ := datetime.GobEncode()
-							model.Item[val] = dynamodb.NewBinaryAttributeValue(b)
-						default:
-							if timeFormat := ft.Get("DynamoDBTimeFormat"); timeFormat != "" {
-								model.Item[val] = dynamodb.NewAttributeValue(datetime.UTC().Format(timeFormat))
-							} else {
-								model.Item[val] = dynamodb.NewAttributeValue(datetime.UTC().Format(time.RFC3339Nano))
-							}
-						}
-					} else {
-						log.Printf("The field `%s` could not be converted to time.Time.\n", f.Type().Name())
-						log.Printf("%+v\n", v)
-					}
-
-				case "string":
-					if f.String() == "" && ft.Get("DynamoDBAutoGeneratedKey") != "" {
-						model.Item[val] = dynamodb.NewAttributeValue(uuid.NewUUID().String()[1:37])
-					} else {
-						model.Item[val] = dynamodb.NewAttributeValue(f.String())
-					}
-
-				case "[]string":
-					if arr, ok := f.Interface().([]string); ok {
-						model.Item[val] = dynamodb.NewAttributeSet(arr)
-					}
-
-				case "int", "int8", "int16", "int32", "int64", "rune":
-					model.Item[val] = dynamodb.NewIntAttributeValue(f.Int())
-
-				case "[]int", "[]int8", "[]int16", "[]int32", "[]int64", "[]rune":
-					intArr := make([]int64, f.Len())
-					for j := 0; j < f.Len(); j++ {
-						intArr[j] = f.Index(j).Int()
-					}
-					model.Item[val] = dynamodb.NewIntAttributeSet(intArr)
-
-				case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
-					model.Item[val] = dynamodb.NewUintAttributeValue(f.Uint())
-
-				case "[]uint", "[]uint8", "[]uint16", "[]uint32", "[]uint64":
-					uintArr := make([]uint64, f.Len())
-					for j := 0; j < f.Len(); j++ {
-						uintArr[j] = f.Index(j).Uint()
-					}
-					model.Item[val] = dynamodb.NewUintAttributeSet(uintArr)
-
-				case "float32", "float64":
-					model.Item[val] = dynamodb.NewFloatAttributeValue(f.Float())
-
-				case "[]float32", "[]float64":
-					fltArr := make([]float64, f.Len())
-					for j := 0; j < f.Len(); j++ {
-						fltArr[j] = f.Index(j).Float()
-					}
-					model.Item[val] = dynamodb.NewFloatAttributeSet(fltArr)
-
-				case "[]byte":
-					model.Item[val] = dynamodb.NewBinaryAttributeValue(f.Bytes())
-
-				case "[][]byte":
-					if arr, ok := f.Interface().([][]byte); ok {
-						model.Item[val] = dynamodb.NewBinaryAttributeSet(arr)
-					}
-
-				case "bool":
-					switch ft.Get("DynamoDBType") {
-					case "N", "NUMBER", "n", "number":
-						if f.Bool() {
-							model.Item[val] = dynamodb.AttributeValue{N: "1"}
-						} else {
-							model.Item[val] = dynamodb.AttributeValue{N: "0"}
-						}
-					case "B", "BINARY", "b", "binary":
-						if f.Bool() {
-							model.Item[val] = dynamodb.AttributeValue{B: []byte{1}}
-						} else {
-							model.Item[val] = dynamodb.AttributeValue{B: []byte{0}}
-						}
-					default:
-						if f.Bool() {
-							model.Item[val] = dynamodb.AttributeValue{S: "true"}
-						} else {
-							model.Item[val] = dynamodb.AttributeValue{S: "false"}
-						}
-					}
-
-				case "[]bool":
-					switch ft.Get("DynamoDBType") {
-					case "N", "NUMBER", "n", "number":
-						numArr := make([]string, f.Len())
-						for j := 0; j < f.Len(); j++ {
-							if f.Index(j).Bool() {
-								numArr[j] = "1"
-							} else {
-								numArr[j] = "0"
-							}
-						}
-						model.Item[val] = dynamodb.AttributeValue{NS: numArr}
-
-					case "B", "BINARY", "b", "binary":
-						binArr := make([][]byte, f.Len())
-						for j := 0; j < f.Len(); j++ {
-							if f.Index(j).Bool() {
-								binArr[j] = []byte{1}
-							} else {
-								binArr[j] = []byte{0}
-							}
-						}
-						model.Item[val] = dynamodb.AttributeValue{BS: binArr}
-
-					default:
-						strArr := make([]string, f.Len())
-						for j := 0; j < f.Len(); j++ {
-							if f.Index(j).Bool() {
-								strArr[j] = "true"
-							} else {
-								strArr[j] = "false"
-							}
-						}
-						model.Item[val] = dynamodb.AttributeValue{SS: strArr}
-					}
-
-				default:
-					var buf bytes.Buffer
-					enc := gob.NewEncoder(&buf)
-					enc.Encode(f.Interface())
-					model.Item[val] = dynamodb.NewBinaryAttributeValue(buf.Bytes())
-				}
-				break
-			}
+		// The fields of a nil embedded struct are omitted.
+		f, ok := fieldByIndex(e, field.index, false)
+		if !ok || (field.omitEmpty && isEmptyValue(f)) {
+			continue
 		}
+
+		model.Item[field.name] = marshalField(f, field)
 	}
 
 	return model
@@ -282,193 +177,395 @@ func Marshal(v interface{}) *DataModel {
 func Unmarshal(item map[string]dynamodb.AttributeValue, v interface{}) {
 
 	e := reflect.ValueOf(v).Elem()
-	t := e.Type()
-
-	for i := 0; i < e.NumField(); i++ {
-
-		f, ft := e.Field(i), t.Field(i).Tag
-
-		for _, tag := range range_list {
-
-			// Check if the field contains the tag.
-			if val := ft.Get(tag); val != "" {
-
-				if attribute, ok := item[val]; ok {
-
-					attrType := attribute.Type()
-
-					switch f.Type().String() {
-					case "time.Time":
-						if attrType == dynamodb.STRING {
-							timeFormat := time.RFC3339Nano
-							if ft.Get("DynamoDBTimeFormat") != "" {
-								timeFormat = ft.Get("DynamoDBTimeFormat")
-							}
-							datetime, _ := time.Parse(timeFormat, attribute.Value())
-							f.Set(reflect.ValueOf(datetime))
-						} else if attrType == dynamodb.NUMBER {
-							datetime := time.Unix(0, attribute.Int())
-							f.Set(reflect.ValueOf(datetime))
-						} else {
-							datetime := time.Now()
-							datetime.GobDecode(attribute.Binary())
-							f.Set(reflect.ValueOf(datetime))
-						}
-
-					case "string":
-						if attrType == dynamodb.STRING {
-							f.SetString(attribute.Value())
-						} else {
-							log.Printf("Field `%s` was expecting a STRING, but got a %s.", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "[]string":
-						if attrType == dynamodb.STRING {
-							vLen := len(attribute.SS)
-							slice := reflect.MakeSlice(f.Type(), vLen, vLen)
-							for i, sVal := range attribute.ValueSet() {
-								slice.Index(i).SetString(sVal)
-							}
-							f.Set(slice)
-						} else {
-							log.Printf("Field `%s` was expecting a STRING, but got a %s.", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "int", "int8", "int16", "int32", "int64", "rune":
-						if attrType == dynamodb.NUMBER {
-							f.SetInt(attribute.Int())
-						} else {
-							log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "[]int", "[]int8", "[]int16", "[]int32", "[]int64", "[]rune":
-						if attrType == dynamodb.NUMBER {
-							vLen := len(attribute.NS)
-							slice := reflect.MakeSlice(f.Type(), vLen, vLen)
-							for i, sVal := range attribute.IntSet() {
-								slice.Index(i).SetInt(sVal)
-							}
-							f.Set(slice)
-						} else {
-							log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
-						if attrType == dynamodb.NUMBER {
-							f.SetUint(attribute.Uint())
-						} else {
-							log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "[]uint", "[]uint8", "[]uint16", "[]uint32", "[]uint64":
-						if attrType == dynamodb.NUMBER {
-							vLen := len(attribute.NS)
-							slice := reflect.MakeSlice(f.Type(), vLen, vLen)
-							for i, sVal := range attribute.UintSet() {
-								slice.Index(i).SetUint(sVal)
-							}
-							f.Set(slice)
-						} else {
-							log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "float32", "float64":
-						if attrType == dynamodb.NUMBER {
-							f.SetFloat(attribute.Float())
-						} else {
-							log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "[]float32", "[]float64":
-						if attrType == dynamodb.NUMBER {
-							vLen := len(attribute.NS)
-							slice := reflect.MakeSlice(f.Type(), vLen, vLen)
-							for i, sVal := range attribute.FloatSet() {
-								slice.Index(i).SetFloat(sVal)
-							}
-							f.Set(slice)
-						} else {
-							log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "[]byte":
-						if attrType == dynamodb.BINARY {
-							f.SetBytes(attribute.Binary())
-						} else {
-							log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "[][]byte":
-						if attrType == dynamodb.BINARY {
-							vLen := len(attribute.BS)
-							slice := reflect.MakeSlice(f.Type(), vLen, vLen)
-							for i, sVal := range attribute.BinarySet() {
-								slice.Index(i).SetBytes(sVal)
-							}
-							f.Set(slice)
-						} else {
-							log.Printf("Field `%s` was expecting a BINARY, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-
-					case "bool":
-						if attrType == dynamodb.STRING {
-							f.SetBool(strings.ToLower(attribute.Value()) == "true")
-						} else if attrType == dynamodb.NUMBER {
-							f.SetBool(attribute.N == "1")
-						} else {
-							f.SetBool(attribute.Binary()[0] == 1)
-						}
-
-					case "[]bool":
-						if attrType == dynamodb.STRING {
-							vLen := len(attribute.SS)
-							slice := reflect.MakeSlice(f.Type(), vLen, vLen)
-							for i, sVal := range attribute.SS {
-								slice.Index(i).SetBool(sVal == "true")
-							}
-							f.Set(slice)
-						} else if attrType == dynamodb.NUMBER {
-							vLen := len(attribute.NS)
-							slice := reflect.MakeSlice(f.Type(), vLen, vLen)
-							for i, sVal := range attribute.NS {
-								slice.Index(i).SetBool(strings.ToLower(sVal) == "1")
-							}
-							f.Set(slice)
-						} else {
-							vLen := len(attribute.BS)
-							slice := reflect.MakeSlice(f.Type(), vLen, vLen)
-							for i, sVal := range attribute.BinarySet() {
-								slice.Index(i).SetBool(sVal[0] == 1)
-							}
-							f.Set(slice)
-						}
-
-					default:
-						if attrType == dynamodb.BINARY {
-							reader := bytes.NewReader(attribute.Binary())
-							q := reflect.New(f.Type()).Interface()
-							gob.NewDecoder(reader).Decode(q)
-							f.Set(reflect.Indirect(reflect.ValueOf(q)))
-						} else {
-							log.Printf("Field `%s` was expecting a BINARY, but got a %s.\n", f.Type().Name(), attrType)
-							log.Printf("%+v\n", item)
-						}
-					}
+	info := cachedStructInfo(e.Type())
+
+	for i := range info.fields {
+
+		field := &info.fields[i]
+
+		if attribute, ok := item[field.name]; ok {
+			f, _ := fieldByIndex(e, field.index, true)
+			unmarshalField(attribute, f, field)
+		}
+	}
+
+	return
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+// Returns the AttributeValue of the field f. A nil pointer is an empty attribute, which UPDATE removes
+// from the item, or NULL if the field is tagged DynamoDBNullable:"true".
+func marshalField(f reflect.Value, field *fieldInfo) (attr dynamodb.AttributeValue) {
+
+	if m, ok := marshalerOf(f); ok {
+		var err error
+		if attr, err = m.MarshalAttributeValue(); err != nil {
+			log.Printf("The field `%s` could not be marshalled: %s\n", field.field.Name, err)
+		}
+		return attr
+	}
+
+	if f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			if field.nullable {
+				return dynamodb.NewNullAttributeValue()
+			}
+			return dynamodb.AttributeValue{}
+		}
+		return marshalField(f.Elem(), field)
+	}
+
+	switch f.Type().String() {
+	case "time.Time":
+		if datetime, ok := f.Interface().(time.Time); ok {
+			switch field.dynamoType {
+			case "N", "NUMBER", "n", "number":
+				attr = dynamodb.NewIntAttributeValue(datetime.UnixNano())
+			case "B", "BINARY", "b", "binary":
+				b, _ := datetime.GobEncode()
+				attr = dynamodb.NewBinaryAttributeValue(b)
+			default:
+				if timeFormat := field.timeFormat; timeFormat != "" {
+					attr = dynamodb.NewAttributeValue(datetime.UTC().Format(timeFormat))
+				} else {
+					attr = dynamodb.NewAttributeValue(datetime.UTC().Format(time.RFC3339Nano))
 				}
-				break
 			}
+		} else {
+			log.Printf("The field `%s` could not be converted to time.Time.\n", f.Type().Name())
+			log.Printf("%+v\n", f.Interface())
+		}
+
+	case "string":
+		if f.String() == "" && field.autoGenerated {
+			attr = dynamodb.NewAttributeValue(uuid.NewUUID().String()[1:37])
+		} else {
+			attr = dynamodb.NewAttributeValue(f.String())
+		}
+
+	case "[]string":
+		if arr, ok := f.Interface().([]string); ok {
+			attr = dynamodb.NewAttributeSet(arr)
+		}
+
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		attr = dynamodb.NewIntAttributeValue(f.Int())
+
+	case "[]int", "[]int8", "[]int16", "[]int32", "[]int64", "[]rune":
+		intArr := make([]int64, f.Len())
+		for j := 0; j < f.Len(); j++ {
+			intArr[j] = f.Index(j).Int()
+		}
+		attr = dynamodb.NewIntAttributeSet(intArr)
+
+	case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		attr = dynamodb.NewUintAttributeValue(f.Uint())
+
+	case "[]uint", "[]uint8", "[]uint16", "[]uint32", "[]uint64":
+		uintArr := make([]uint64, f.Len())
+		for j := 0; j < f.Len(); j++ {
+			uintArr[j] = f.Index(j).Uint()
+		}
+		attr = dynamodb.NewUintAttributeSet(uintArr)
+
+	case "float32", "float64":
+		attr = dynamodb.NewFloatAttributeValue(f.Float())
+
+	case "[]float32", "[]float64":
+		fltArr := make([]float64, f.Len())
+		for j := 0; j < f.Len(); j++ {
+			fltArr[j] = f.Index(j).Float()
+		}
+		attr = dynamodb.NewFloatAttributeSet(fltArr)
+
+	case "[]byte":
+		attr = dynamodb.NewBinaryAttributeValue(f.Bytes())
+
+	case "[][]byte":
+		if arr, ok := f.Interface().([][]byte); ok {
+			attr = dynamodb.NewBinaryAttributeSet(arr)
+		}
+
+	case "bool":
+		switch field.dynamoType {
+		case "N", "NUMBER", "n", "number":
+			if f.Bool() {
+				attr = dynamodb.AttributeValue{N: "1"}
+			} else {
+				attr = dynamodb.AttributeValue{N: "0"}
+			}
+		case "B", "BINARY", "b", "binary":
+			if f.Bool() {
+				attr = dynamodb.AttributeValue{B: []byte{1}}
+			} else {
+				attr = dynamodb.AttributeValue{B: []byte{0}}
+			}
+		default:
+			if f.Bool() {
+				attr = dynamodb.AttributeValue{S: "true"}
+			} else {
+				attr = dynamodb.AttributeValue{S: "false"}
+			}
+		}
+
+	case "[]bool":
+		switch field.dynamoType {
+		case "N", "NUMBER", "n", "number":
+			numArr := make([]string, f.Len())
+			for j := 0; j < f.Len(); j++ {
+				if f.Index(j).Bool() {
+					numArr[j] = "1"
+				} else {
+					numArr[j] = "0"
+				}
+			}
+			attr = dynamodb.AttributeValue{NS: numArr}
+
+		case "B", "BINARY", "b", "binary":
+			binArr := make([][]byte, f.Len())
+			for j := 0; j < f.Len(); j++ {
+				if f.Index(j).Bool() {
+					binArr[j] = []byte{1}
+				} else {
+					binArr[j] = []byte{0}
+				}
+			}
+			attr = dynamodb.AttributeValue{BS: binArr}
+
+		default:
+			strArr := make([]string, f.Len())
+			for j := 0; j < f.Len(); j++ {
+				if f.Index(j).Bool() {
+					strArr[j] = "true"
+				} else {
+					strArr[j] = "false"
+				}
+			}
+			attr = dynamodb.AttributeValue{SS: strArr}
+		}
+
+	default:
+		if tm, ok := textMarshalerOf(f); ok {
+			text, err := tm.MarshalText()
+			if err != nil {
+				log.Printf("The field `%s` could not be marshalled: %s\n", field.field.Name, err)
+			}
+			attr = dynamodb.NewAttributeValue(string(text))
+		} else if isNativeType(f.Type()) || isScalarKind(f.Kind()) {
+			// A nil value is empty, which UPDATE removes from the item.
+			if attr = encodeValue(f); attr.IsNull() {
+				attr = dynamodb.AttributeValue{}
+			}
+		} else {
+			var buf bytes.Buffer
+			enc := gob.NewEncoder(&buf)
+			enc.Encode(f.Interface())
+			attr = dynamodb.NewBinaryAttributeValue(buf.Bytes())
 		}
 	}
 
 	return
 }
+
+// Stores the attribute in the field f. A NULL attribute sets a pointer field to nil.
+func unmarshalField(attribute dynamodb.AttributeValue, f reflect.Value, field *fieldInfo) {
+
+	if f.Kind() == reflect.Ptr && !attribute.NULL {
+		if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+		unmarshalField(attribute, f.Elem(), field)
+		return
+	}
+
+	if u, ok := unmarshalerOf(f); ok {
+		if err := u.UnmarshalAttributeValue(attribute); err != nil {
+			log.Printf("The field `%s` could not be unmarshalled: %s\n", field.field.Name, err)
+		}
+		return
+	}
+
+	attrType := attribute.Type()
+
+	if attrType == dynamodb.MAP || attrType == dynamodb.LIST || attrType == dynamodb.BOOLEAN || attrType == dynamodb.NULL_AT {
+		decodeValue(attribute, f)
+		return
+	}
+
+	switch f.Type().String() {
+	case "time.Time":
+		if attrType == dynamodb.STRING {
+			timeFormat := time.RFC3339Nano
+			if field.timeFormat != "" {
+				timeFormat = field.timeFormat
+			}
+			datetime, _ := time.Parse(timeFormat, attribute.Value())
+			f.Set(reflect.ValueOf(datetime))
+		} else if attrType == dynamodb.NUMBER {
+			datetime := time.Unix(0, attribute.Int())
+			f.Set(reflect.ValueOf(datetime))
+		} else {
+			datetime := time.Now()
+			datetime.GobDecode(attribute.Binary())
+			f.Set(reflect.ValueOf(datetime))
+		}
+
+	case "string":
+		if attrType == dynamodb.STRING {
+			f.SetString(attribute.Value())
+		} else {
+			log.Printf("Field `%s` was expecting a STRING, but got a %s.", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "[]string":
+		if attrType == dynamodb.STRING {
+			vLen := len(attribute.SS)
+			slice := reflect.MakeSlice(f.Type(), vLen, vLen)
+			for i, sVal := range attribute.ValueSet() {
+				slice.Index(i).SetString(sVal)
+			}
+			f.Set(slice)
+		} else {
+			log.Printf("Field `%s` was expecting a STRING, but got a %s.", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		if attrType == dynamodb.NUMBER {
+			f.SetInt(attribute.Int())
+		} else {
+			log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "[]int", "[]int8", "[]int16", "[]int32", "[]int64", "[]rune":
+		if attrType == dynamodb.NUMBER {
+			vLen := len(attribute.NS)
+			slice := reflect.MakeSlice(f.Type(), vLen, vLen)
+			for i, sVal := range attribute.IntSet() {
+				slice.Index(i).SetInt(sVal)
+			}
+			f.Set(slice)
+		} else {
+			log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		if attrType == dynamodb.NUMBER {
+			f.SetUint(attribute.Uint())
+		} else {
+			log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "[]uint", "[]uint8", "[]uint16", "[]uint32", "[]uint64":
+		if attrType == dynamodb.NUMBER {
+			vLen := len(attribute.NS)
+			slice := reflect.MakeSlice(f.Type(), vLen, vLen)
+			for i, sVal := range attribute.UintSet() {
+				slice.Index(i).SetUint(sVal)
+			}
+			f.Set(slice)
+		} else {
+			log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "float32", "float64":
+		if attrType == dynamodb.NUMBER {
+			f.SetFloat(attribute.Float())
+		} else {
+			log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "[]float32", "[]float64":
+		if attrType == dynamodb.NUMBER {
+			vLen := len(attribute.NS)
+			slice := reflect.MakeSlice(f.Type(), vLen, vLen)
+			for i, sVal := range attribute.FloatSet() {
+				slice.Index(i).SetFloat(sVal)
+			}
+			f.Set(slice)
+		} else {
+			log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "[]byte":
+		if attrType == dynamodb.BINARY {
+			f.SetBytes(attribute.Binary())
+		} else {
+			log.Printf("Field `%s` was expecting a NUMBER, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "[][]byte":
+		if attrType == dynamodb.BINARY {
+			vLen := len(attribute.BS)
+			slice := reflect.MakeSlice(f.Type(), vLen, vLen)
+			for i, sVal := range attribute.BinarySet() {
+				slice.Index(i).SetBytes(sVal)
+			}
+			f.Set(slice)
+		} else {
+			log.Printf("Field `%s` was expecting a BINARY, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+
+	case "bool":
+		if attrType == dynamodb.STRING {
+			f.SetBool(strings.ToLower(attribute.Value()) == "true")
+		} else if attrType == dynamodb.NUMBER {
+			f.SetBool(attribute.N == "1")
+		} else {
+			f.SetBool(attribute.Binary()[0] == 1)
+		}
+
+	case "[]bool":
+		if attrType == dynamodb.STRING {
+			vLen := len(attribute.SS)
+			slice := reflect.MakeSlice(f.Type(), vLen, vLen)
+			for i, sVal := range attribute.SS {
+				slice.Index(i).SetBool(sVal == "true")
+			}
+			f.Set(slice)
+		} else if attrType == dynamodb.NUMBER {
+			vLen := len(attribute.NS)
+			slice := reflect.MakeSlice(f.Type(), vLen, vLen)
+			for i, sVal := range attribute.NS {
+				slice.Index(i).SetBool(strings.ToLower(sVal) == "1")
+			}
+			f.Set(slice)
+		} else {
+			vLen := len(attribute.BS)
+			slice := reflect.MakeSlice(f.Type(), vLen, vLen)
+			for i, sVal := range attribute.BinarySet() {
+				slice.Index(i).SetBool(sVal[0] == 1)
+			}
+			f.Set(slice)
+		}
+
+	default:
+		if tu, ok := textUnmarshalerOf(f); ok && attrType == dynamodb.STRING {
+			if err := tu.UnmarshalText([]byte(attribute.Value())); err != nil {
+				log.Printf("The field `%s` could not be unmarshalled: %s\n", field.field.Name, err)
+			}
+		} else if attrType == dynamodb.BINARY {
+			reader := bytes.NewReader(attribute.Binary())
+			q := reflect.New(f.Type()).Interface()
+			gob.NewDecoder(reader).Decode(q)
+			f.Set(reflect.Indirect(reflect.ValueOf(q)))
+		} else if isNativeType(f.Type()) || isScalarKind(f.Kind()) {
+			decodeValue(attribute, f)
+		} else {
+			log.Printf("Field `%s` was expecting a BINARY, but got a %s.\n", f.Type().Name(), attrType)
+			log.Printf("%+v\n", attribute)
+		}
+	}
+}
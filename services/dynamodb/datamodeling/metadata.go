@@ -0,0 +1,264 @@
+package datamodeling
+
+import (
+	"encoding"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Marshaler is implemented by types that encode themselves into an AttributeValue.
+// It takes precedence over the DynamoDBType tag and the built-in encodings, also in nested documents.
+type Marshaler interface {
+	MarshalAttributeValue() (dynamodb.AttributeValue, error)
+}
+
+// Unmarshaler is implemented by types that decode themselves from an AttributeValue,
+// usually the one their MarshalAttributeValue returned. It is called with a pointer receiver.
+type Unmarshaler interface {
+	UnmarshalAttributeValue(dynamodb.AttributeValue) error
+}
+
+var (
+	marshalerType       = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// The mapping of a struct type, parsed from its tags once and cached.
+type structInfo struct {
+	tableName  string
+	tableDepth int
+	fields     []fieldInfo
+}
+
+// The mapping of an attribute field. The fields of untagged embedded structs are flattened
+// into their parent, so index can be the path to a field of an embedded struct.
+type fieldInfo struct {
+	index         []int
+	field         reflect.StructField
+	name          string // The attribute name.
+	tag           string // The first tag of range_list on the field.
+	dynamoType    string
+	timeFormat    string
+	autoGenerated bool
+	omitEmpty     bool
+	nullable      bool
+}
+
+var structInfoCache = struct {
+	sync.RWMutex
+	m map[reflect.Type]*structInfo
+}{m: make(map[reflect.Type]*structInfo)}
+
+// Returns the cached mapping of the struct type t.
+func cachedStructInfo(t reflect.Type) *structInfo {
+
+	structInfoCache.RLock()
+	info, ok := structInfoCache.m[t]
+	structInfoCache.RUnlock()
+
+	if ok {
+		return info
+	}
+
+	info = &structInfo{tableName: t.Name()}
+	info.fields = parseFields(t, nil, info)
+
+	// An attribute of the struct itself hides the attributes of the same name in embedded structs.
+	depth := make(map[string]int, len(info.fields))
+	for _, field := range info.fields {
+		if d, ok := depth[field.name]; !ok || len(field.index) < d {
+			depth[field.name] = len(field.index)
+		}
+	}
+	fields := info.fields[:0]
+	for _, field := range info.fields {
+		if depth[field.name] == len(field.index) {
+			fields = append(fields, field)
+			depth[field.name] = -1
+		}
+	}
+	info.fields = fields
+
+	structInfoCache.Lock()
+	structInfoCache.m[t] = info
+	structInfoCache.Unlock()
+
+	return info
+}
+
+// Returns the attribute fields of t, descending into untagged embedded structs.
+func parseFields(t reflect.Type, index []int, info *structInfo) []fieldInfo {
+
+	var fields []fieldInfo
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if val := f.Tag.Get("DynamoDBTable"); val != "" {
+			// The table name of the struct itself takes precedence over an embedded struct's.
+			if info.tableDepth == 0 || len(fieldIndex) < info.tableDepth {
+				info.tableName, info.tableDepth = val, len(fieldIndex)
+			}
+			continue
+		}
+
+		field := fieldInfo{index: fieldIndex, field: f}
+		for _, tag := range range_list {
+			if val := f.Tag.Get(tag); val != "" {
+				field.name, field.tag = val, tag
+				break
+			}
+		}
+
+		if field.tag == "" {
+			if et := embeddedStruct(f); et != nil {
+				fields = append(fields, parseFields(et, fieldIndex, info)...)
+			}
+			continue
+		}
+
+		field.dynamoType = f.Tag.Get("DynamoDBType")
+		field.timeFormat = f.Tag.Get("DynamoDBTimeFormat")
+		field.autoGenerated = f.Tag.Get("DynamoDBAutoGeneratedKey") != ""
+		field.omitEmpty = f.Tag.Get("DynamoDBOmitEmpty") == "true"
+		field.nullable = f.Tag.Get("DynamoDBNullable") == "true"
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// Returns the struct type of an embedded field that is flattened, or nil.
+func embeddedStruct(f reflect.StructField) reflect.Type {
+
+	if !f.Anonymous || f.Tag.Get("DynamoDBIgnore") == "true" {
+		return nil
+	}
+
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		// A nil embedded pointer is allocated on unmarshal, which requires an exported field.
+		if f.PkgPath != "" {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+	return t
+}
+
+// Returns the field of the struct v at the index path. Nil embedded pointers on the path are allocated
+// if alloc is true; otherwise false is returned for a field of a nil embedded struct.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+// Returns the Marshaler implemented by v or, if addressable, by its pointer.
+func marshalerOf(v reflect.Value) (Marshaler, bool) {
+
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, false
+	}
+	if v.Type().Implements(marshalerType) && v.CanInterface() {
+		return v.Interface().(Marshaler), true
+	}
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(marshalerType) && v.Addr().CanInterface() {
+		return v.Addr().Interface().(Marshaler), true
+	}
+	return nil, false
+}
+
+// Returns the Unmarshaler implemented by the pointer to the addressable v.
+func unmarshalerOf(v reflect.Value) (Unmarshaler, bool) {
+
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(unmarshalerType) && v.Addr().CanInterface() {
+		return v.Addr().Interface().(Unmarshaler), true
+	}
+	return nil, false
+}
+
+// Returns the encoding.TextMarshaler implemented by v or, if addressable, by its pointer.
+func textMarshalerOf(v reflect.Value) (encoding.TextMarshaler, bool) {
+
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, false
+	}
+	if v.Type().Implements(textMarshalerType) && v.CanInterface() {
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(textMarshalerType) && v.Addr().CanInterface() {
+		return v.Addr().Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
+
+// Returns the encoding.TextUnmarshaler implemented by the pointer to the addressable v.
+func textUnmarshalerOf(v reflect.Value) (encoding.TextUnmarshaler, bool) {
+
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) && v.Addr().CanInterface() {
+		return v.Addr().Interface().(encoding.TextUnmarshaler), true
+	}
+	return nil, false
+}
+
+// Returns true if v is the zero value of a scalar, a nil pointer, interface, map or slice,
+// an empty map, slice, array or string, or a zero time.Time.
+func isEmptyValue(v reflect.Value) bool {
+
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
+}
+
+// Returns true for the kinds stored as a scalar S or N attribute, whatever the name of their type.
+func isScalarKind(k reflect.Kind) bool {
+
+	switch k {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package datamodeling
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Stored as an upper case S, to tell it from the built-in encoding.
+type shouting string
+
+func (s shouting) MarshalAttributeValue() (dynamodb.AttributeValue, error) {
+	return dynamodb.NewAttributeValue(strings.ToUpper(string(s))), nil
+}
+
+func (s *shouting) UnmarshalAttributeValue(av dynamodb.AttributeValue) error {
+	if av.S == "" {
+		return errors.New("empty")
+	}
+	*s = shouting(strings.ToLower(av.S))
+	return nil
+}
+
+type status string
+
+type Audit struct {
+	Created string `DynamoDBAttribute:"Created"`
+	Name    string `DynamoDBAttribute:"Name"`
+}
+
+type Owner struct {
+	Owner string `DynamoDBAttribute:"Owner"`
+}
+
+type customItem struct {
+	Audit
+	*Owner
+	Id       string              `DynamoDBHashKey:"Id"`
+	Name     string              `DynamoDBAttribute:"Name"`
+	Greeting shouting            `DynamoDBAttribute:"Greeting"`
+	Nested   map[string]shouting `DynamoDBAttribute:"Nested"`
+	Status   status              `DynamoDBAttribute:"Status"`
+	Address  net.IP              `DynamoDBAttribute:"Address"`
+	Count    *int                `DynamoDBAttribute:"Count"`
+	Nickname *string             `DynamoDBAttribute:"Nickname" DynamoDBNullable:"true"`
+	Note     string              `DynamoDBAttribute:"Note" DynamoDBOmitEmpty:"true"`
+	Version  int64               `DynamoDBVersionAttribute:"Version"`
+}
+
+func TestMarshalCustomTypes(t *testing.T) {
+
+	count := 3
+	in := &customItem{
+		Audit:    Audit{Created: "today", Name: "hidden"},
+		Owner:    &Owner{"me"},
+		Id:       "a",
+		Name:     "n",
+		Greeting: "hello",
+		Nested:   map[string]shouting{"x": "hi"},
+		Status:   "open",
+		Address:  net.ParseIP("10.0.0.1"),
+		Count:    &count,
+		Version:  1,
+	}
+
+	model := Marshal(in)
+
+	if model.Item["Greeting"].S != "HELLO" || model.Item["Nested"].M["x"].S != "HI" {
+		t.Errorf("Expected the Marshaler encoding, got %v %v", model.Item["Greeting"], model.Item["Nested"])
+	}
+	if model.Item["Status"].S != "open" || model.Item["Address"].S != "10.0.0.1" {
+		t.Errorf("Expected STRING attributes, got %v %v", model.Item["Status"], model.Item["Address"])
+	}
+	if model.Item["Count"].N != "3" || !model.Item["Nickname"].NULL {
+		t.Errorf("Unexpected pointers %v %v", model.Item["Count"], model.Item["Nickname"])
+	}
+	if _, ok := model.Item["Note"]; ok {
+		t.Error("Expected an empty Note to be omitted.")
+	}
+	if model.Item["Created"].S != "today" || model.Item["Owner"].S != "me" || model.Item["Name"].S != "n" {
+		t.Errorf("Expected flattened embedded fields, got %v", model.Item)
+	}
+	if model.HashKey != "Id" || model.VersionAttribute != "Version" || model.TableName != "customItem" {
+		t.Errorf("Unexpected model %+v", model)
+	}
+
+	out := &customItem{}
+	Unmarshal(model.Item, out)
+	in.Audit.Name = ""
+
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("Round trip mismatch:\n%+v\n%+v", in, out)
+	}
+
+	if ver, err := newVersioning(out, model); err != nil || ver == nil || ver.current.N != "1" {
+		t.Errorf("Expected the version field, got %+v (%v)", ver, err)
+	}
+}
+
+func TestMarshalNilPointers(t *testing.T) {
+
+	model := Marshal(&customItem{Id: "a"})
+
+	if !model.Item["Count"].IsEmpty() {
+		t.Errorf("Expected a nil pointer to be empty, got %v", model.Item["Count"])
+	}
+	if _, ok := model.Item["Owner"]; ok {
+		t.Error("Expected the fields of a nil embedded pointer to be omitted.")
+	}
+
+	nickname := "nick"
+	out := &customItem{Nickname: &nickname}
+	Unmarshal(map[string]dynamodb.AttributeValue{
+		"Nickname": dynamodb.NewNullAttributeValue(),
+		"Owner":    dynamodb.NewAttributeValue("me"),
+	}, out)
+
+	if out.Nickname != nil || out.Owner == nil || out.Owner.Owner != "me" {
+		t.Errorf("Unexpected pointers %v %+v", out.Nickname, out.Owner)
+	}
+}
+
+func TestCachedStructInfo(t *testing.T) {
+
+	info := cachedStructInfo(reflect.TypeOf(customItem{}))
+	if info != cachedStructInfo(reflect.TypeOf(customItem{})) {
+		t.Error("Expected the struct info to be cached.")
+	}
+
+	names := 0
+	for _, field := range info.fields {
+		if field.name == "Name" {
+			names++
+			if len(field.index) != 1 {
+				t.Error("Expected the outer Name field to hide the embedded one.")
+			}
+		}
+	}
+	if names != 1 || len(info.fields) != 12 {
+		t.Errorf("Unexpected fields %+v", info.fields)
+	}
+}
@@ -0,0 +1,255 @@
+package datamodeling
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"reflect"
+)
+
+var (
+	errIterationOnly   = errors.New("datamodeling: The list only supports iteration with the ITERATION_ONLY loading strategy.")
+	errIteratedOnce    = errors.New("datamodeling: The list can only be iterated once with the ITERATION_ONLY loading strategy.")
+	errInvalidSlicePtr = errors.New("datamodeling: LoadInto requires a pointer to a slice of structs or struct pointers.")
+)
+
+// Fetches one page of results starting after exclusiveStartKey.
+type pageFetcher func(exclusiveStartKey map[string]dynamodb.AttributeValue) (items []map[string]dynamodb.AttributeValue, lastEvaluatedKey map[string]dynamodb.AttributeValue, err error)
+
+// A list of query or scan results that follows LastEvaluatedKey to load the next page.
+// Each item is a pointer to a new struct of the type passed to the mapper.
+// How pages are loaded follows the mapper's PaginationLoadingStrategy:
+//
+// LAZY_LOADING loads pages as they are accessed and keeps all loaded items in memory.
+//
+// EAGER_LOADING loads all pages when the list is created.
+//
+// ITERATION_ONLY supports a single Iterator() and only keeps the current page in memory.
+//
+// A PaginatedList is not safe for concurrent use.
+type PaginatedList struct {
+	itemType         reflect.Type
+	strategy         PaginationLoadingStrategy
+	fetch            pageFetcher
+	items            []interface{}
+	offset           int // The number of items discarded by ITERATION_ONLY.
+	lastEvaluatedKey map[string]dynamodb.AttributeValue
+	exhausted        bool
+	iterated         bool
+}
+
+// Creates the list and loads the first page, or all pages with EAGER_LOADING.
+func newPaginatedList(v interface{}, strategy PaginationLoadingStrategy, exclusiveStartKey map[string]dynamodb.AttributeValue, fetch pageFetcher) (*PaginatedList, error) {
+
+	l := &PaginatedList{
+		itemType:         reflect.TypeOf(v).Elem(),
+		strategy:         strategy,
+		fetch:            fetch,
+		lastEvaluatedKey: exclusiveStartKey,
+	}
+
+	if err := l.loadNextPage(); err != nil {
+		return nil, err
+	}
+
+	if strategy == EAGER_LOADING {
+		if err := l.loadAll(); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// Returns the item at index i, loading pages as needed.
+// Returns nil when i is out of range. Not supported with ITERATION_ONLY.
+func (l *PaginatedList) Get(i int) (interface{}, error) {
+
+	if l.strategy == ITERATION_ONLY {
+		return nil, errIterationOnly
+	}
+
+	for i >= len(l.items) && !l.exhausted {
+		if err := l.loadNextPage(); err != nil {
+			return nil, err
+		}
+	}
+
+	if i < 0 || i >= len(l.items) {
+		return nil, nil
+	}
+	return l.items[i], nil
+}
+
+// Loads all pages and returns the number of items. Not supported with ITERATION_ONLY.
+func (l *PaginatedList) Size() (int, error) {
+
+	if l.strategy == ITERATION_ONLY {
+		return 0, errIterationOnly
+	}
+
+	if err := l.loadAll(); err != nil {
+		return 0, err
+	}
+	return len(l.items), nil
+}
+
+// Loads all pages and returns all items. Not supported with ITERATION_ONLY.
+func (l *PaginatedList) All() ([]interface{}, error) {
+
+	if l.strategy == ITERATION_ONLY {
+		return nil, errIterationOnly
+	}
+
+	if err := l.loadAll(); err != nil {
+		return nil, err
+	}
+	return l.items, nil
+}
+
+// Loads all pages into the slice pointed to by slicePtr, which must be
+// of type *[]T or *[]*T, where T is the struct type passed to the mapper.
+// With ITERATION_ONLY, this consumes the list's only iterator.
+func (l *PaginatedList) LoadInto(slicePtr interface{}) error {
+
+	rv := reflect.ValueOf(slicePtr)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errInvalidSlicePtr
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	byPointer := elemType == reflect.PtrTo(l.itemType)
+	if !byPointer && elemType != l.itemType {
+		return errInvalidSlicePtr
+	}
+
+	slice.SetLen(0)
+	it := l.Iterator()
+	for it.Next() {
+		item := reflect.ValueOf(it.Value())
+		if !byPointer {
+			item = item.Elem()
+		}
+		slice.Set(reflect.Append(slice, item))
+	}
+
+	return it.Err()
+}
+
+// Returns whether all pages have been loaded.
+func (l *PaginatedList) IsLoaded() bool {
+	return l.exhausted
+}
+
+// Returns the LastEvaluatedKey of the most recently loaded page,
+// which can be used as the ExclusiveStartKey of a later request. Empty when all pages are loaded.
+func (l *PaginatedList) LastEvaluatedKey() map[string]dynamodb.AttributeValue {
+	return l.lastEvaluatedKey
+}
+
+// Returns an iterator over the list, loading pages as needed.
+func (l *PaginatedList) Iterator() *PaginatedListIterator {
+
+	it := &PaginatedListIterator{list: l, index: -1}
+
+	if l.strategy == ITERATION_ONLY {
+		if l.iterated {
+			it.err = errIteratedOnce
+		}
+		l.iterated = true
+	}
+
+	return it
+}
+
+func (l *PaginatedList) loadAll() error {
+
+	for !l.exhausted {
+		if err := l.loadNextPage(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *PaginatedList) loadNextPage() error {
+
+	if l.exhausted {
+		return nil
+	}
+
+	items, lastKey, err := l.fetch(l.lastEvaluatedKey)
+	if err != nil {
+		return err
+	}
+
+	if l.strategy == ITERATION_ONLY {
+		l.offset += len(l.items)
+		l.items = nil
+	}
+
+	for _, item := range items {
+		v := reflect.New(l.itemType)
+		Unmarshal(item, v.Interface())
+		l.items = append(l.items, v.Interface())
+	}
+
+	l.lastEvaluatedKey = lastKey
+	l.exhausted = len(lastKey) == 0
+
+	return nil
+}
+
+/*****************************************************************************/
+
+// Iterates over a PaginatedList.
+//
+//	it := list.Iterator()
+//	for it.Next() {
+//	    item := it.Value().(*SampleMapper)
+//	}
+//	if err := it.Err(); err != nil {
+//	    ...
+//	}
+type PaginatedListIterator struct {
+	list  *PaginatedList
+	index int
+	err   error
+}
+
+// Advances to the next item, loading the next page if needed.
+// Returns false when the list is exhausted or an error occurred.
+func (it *PaginatedListIterator) Next() bool {
+
+	if it.err != nil {
+		return false
+	}
+
+	l := it.list
+	it.index++
+
+	for it.index >= l.offset+len(l.items) {
+		if l.exhausted {
+			return false
+		}
+		if it.err = l.loadNextPage(); it.err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the current item.
+func (it *PaginatedListIterator) Value() interface{} {
+	l := it.list
+	if i := it.index - l.offset; i >= 0 && i < len(l.items) {
+		return l.items[i]
+	}
+	return nil
+}
+
+// Returns the error that stopped the iteration, if any.
+func (it *PaginatedListIterator) Err() error {
+	return it.err
+}
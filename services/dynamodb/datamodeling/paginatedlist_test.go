@@ -0,0 +1,92 @@
+package datamodeling
+
+import (
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"strconv"
+	"testing"
+)
+
+type pagedItem struct {
+	Id string `DynamoDBHashKey:"Id"`
+}
+
+// Returns a fetcher serving pages of size items out of total, counting the calls.
+func pagedFetcher(total, size int, calls *int) pageFetcher {
+	return func(start map[string]dynamodb.AttributeValue) ([]map[string]dynamodb.AttributeValue, map[string]dynamodb.AttributeValue, error) {
+		*calls++
+		first := 0
+		if attr, ok := start["Id"]; ok {
+			first, _ = strconv.Atoi(attr.Value())
+			first++
+		}
+		var items []map[string]dynamodb.AttributeValue
+		for i := first; i < first+size && i < total; i++ {
+			items = append(items, map[string]dynamodb.AttributeValue{"Id": dynamodb.NewAttributeValue(strconv.Itoa(i))})
+		}
+		var last map[string]dynamodb.AttributeValue
+		if first+size < total {
+			last = items[len(items)-1]
+		}
+		return items, last, nil
+	}
+}
+
+func TestPaginatedListLazy(t *testing.T) {
+
+	calls := 0
+	l, err := newPaginatedList(&pagedItem{}, LAZY_LOADING, nil, pagedFetcher(5, 2, &calls))
+	if err != nil || calls != 1 {
+		t.Fatalf("Expected 1 page loaded, got %d (%v)", calls, err)
+	}
+
+	item, _ := l.Get(2)
+	if item.(*pagedItem).Id != "2" || calls != 2 {
+		t.Fatalf("Expected item 2 after 2 pages, got %+v after %d", item, calls)
+	}
+
+	if size, _ := l.Size(); size != 5 || calls != 3 || !l.IsLoaded() {
+		t.Fatalf("Expected 5 items after 3 pages, got %d after %d", size, calls)
+	}
+
+	var items []pagedItem
+	if err := l.LoadInto(&items); err != nil || len(items) != 5 || items[4].Id != "4" || calls != 3 {
+		t.Fatalf("Unexpected LoadInto: %+v %v", items, err)
+	}
+}
+
+func TestPaginatedListIterationOnly(t *testing.T) {
+
+	calls := 0
+	l, _ := newPaginatedList(&pagedItem{}, ITERATION_ONLY, nil, pagedFetcher(5, 2, &calls))
+
+	if _, err := l.Get(0); err == nil {
+		t.Fatal("Expected Get to fail with ITERATION_ONLY.")
+	}
+
+	n, it := 0, l.Iterator()
+	for it.Next() {
+		if id := it.Value().(*pagedItem).Id; id != strconv.Itoa(n) {
+			t.Fatalf("Expected item %d, got %s", n, id)
+		}
+		n++
+		if len(l.items) > 2 {
+			t.Fatal("Expected only the current page in memory.")
+		}
+	}
+	if n != 5 || it.Err() != nil {
+		t.Fatalf("Expected 5 items, got %d (%v)", n, it.Err())
+	}
+
+	if it = l.Iterator(); it.Next() || it.Err() == nil {
+		t.Fatal("Expected a second iterator to fail.")
+	}
+}
+
+func TestPaginatedListEager(t *testing.T) {
+
+	calls := 0
+	newPaginatedList(&pagedItem{}, EAGER_LOADING, nil, pagedFetcher(4, 2, &calls))
+	if calls != 2 {
+		t.Fatalf("Expected 2 pages loaded, got %d", calls)
+	}
+}
@@ -0,0 +1,257 @@
+package datamodeling
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"github.com/twhello/aws-to-go/util/ratelimiter"
+	"math"
+	"reflect"
+	"sync"
+)
+
+// Returned by ParallelScan.Run when the scan was stopped with Stop.
+var ErrScanStopped = errors.New("datamodeling: The scan was stopped.")
+
+// The progress of a parallel scan, which can be saved as JSON and resumed with SetCheckpoint.
+type ScanCheckpoint struct {
+	TotalSegments int
+	Segments      []SegmentCheckpoint
+}
+
+// The progress of one segment. A segment without a LastEvaluatedKey that is not done starts at the beginning.
+type SegmentCheckpoint struct {
+	Done             bool
+	LastEvaluatedKey map[string]dynamodb.AttributeValue `json:",omitempty"`
+}
+
+// Returns true if all segments have been scanned.
+func (c *ScanCheckpoint) IsDone() bool {
+	for _, segment := range c.Segments {
+		if !segment.Done {
+			return false
+		}
+	}
+	return true
+}
+
+/*****************************************************************************/
+
+// Scans a table with one worker per segment. Each worker pages through its segment on LastEvaluatedKey.
+// Use datamodeling.NewParallelScan() or DynamoDBMapper.NewParallelScan().
+type ParallelScan struct {
+	request       dynamodb.ScanRequest
+	scan          func(*dynamodb.ScanRequest) (*dynamodb.ScanResult, error)
+	totalSegments int
+	limiter       *ratelimiter.RateLimiter
+	checkpoint    *ScanCheckpoint
+	onCheckpoint  func(ScanCheckpoint)
+	stop          chan struct{}
+	stopOnce      sync.Once
+}
+
+// Creates a new ParallelScan.
+// (service *dynamodb.DynamoDBService) The service to scan with.
+// (request *dynamodb.ScanRequest) The scan of each segment. Its ExclusiveStartKey, Segment and TotalSegments are ignored.
+// (totalSegments int) The number of segments and concurrent workers.
+func NewParallelScan(service *dynamodb.DynamoDBService, request *dynamodb.ScanRequest, totalSegments int) *ParallelScan {
+	return newParallelScan(service.Scan, request, totalSegments)
+}
+
+// Creates a new ParallelScan of v's table.
+// (v interface{}) A pointer to a struct of the item type.
+// (expression *ScanExpression) Can be nil to scan the whole table. Its segments are ignored.
+// (totalSegments int) The number of segments and concurrent workers.
+func (m *DynamoDBMapper) NewParallelScan(v interface{}, expression *ScanExpression, totalSegments int) *ParallelScan {
+	return NewParallelScan(m.DynamoDBService, m.newScanRequest(v, expression), totalSegments)
+}
+
+func newParallelScan(scan func(*dynamodb.ScanRequest) (*dynamodb.ScanResult, error), request *dynamodb.ScanRequest, totalSegments int) *ParallelScan {
+
+	if totalSegments < 1 {
+		totalSegments = 1
+	}
+
+	return &ParallelScan{
+		request:       *request,
+		scan:          scan,
+		totalSegments: totalSegments,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Limits the read capacity units consumed per second by all segments together.
+// Each worker waits for the capacity its last page consumed before it requests the next.
+func (s *ParallelScan) SetReadCapacityLimit(unitsPerSecond uint32) {
+	if unitsPerSecond == 0 {
+		s.limiter = nil
+	} else {
+		s.limiter = ratelimiter.New(unitsPerSecond)
+	}
+}
+
+// Resumes the scan from a checkpoint of a previous scan with the same number of segments.
+func (s *ParallelScan) SetCheckpoint(checkpoint *ScanCheckpoint) {
+	s.checkpoint = checkpoint
+}
+
+// Sets a function that is called with a copy of the checkpoint after every processed page.
+// The calls are serialized.
+func (s *ParallelScan) SetCheckpointHandler(fn func(checkpoint ScanCheckpoint)) {
+	s.onCheckpoint = fn
+}
+
+// Stops the scan after the pages in progress. Run returns ErrScanStopped.
+// A stopped scan cannot be run again; resume it with a new ParallelScan and the checkpoint.
+func (s *ParallelScan) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Scans the segments and calls fn for each item. fn is called concurrently by the segment workers.
+// A segment's checkpoint advances after fn has returned for all items of a page, so a resumed scan
+// may repeat the items of the pages in progress. If fn returns an error, the scan stops and returns it.
+// Returns the checkpoint of the scan, which is done unless an error is returned.
+func (s *ParallelScan) Run(fn func(item map[string]dynamodb.AttributeValue) error) (*ScanCheckpoint, error) {
+
+	checkpoint := &ScanCheckpoint{s.totalSegments, make([]SegmentCheckpoint, s.totalSegments)}
+
+	if s.checkpoint != nil {
+		if s.checkpoint.TotalSegments != s.totalSegments || len(s.checkpoint.Segments) != s.totalSegments {
+			return nil, errors.New("datamodeling: The checkpoint has a different number of segments.")
+		}
+		copy(checkpoint.Segments, s.checkpoint.Segments)
+	}
+
+	var (
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	fail := func(err error) {
+		mutex.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mutex.Unlock()
+		s.Stop()
+	}
+
+	for segment := 0; segment < s.totalSegments; segment++ {
+
+		if checkpoint.Segments[segment].Done {
+			continue
+		}
+
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+
+			sr := s.request
+			sr.SetSegment(int64(segment), s.totalSegments)
+			if s.limiter != nil {
+				sr.ReturnConsumedCapacity = dynamodb.TOTAL
+			}
+
+			mutex.Lock()
+			sr.ExclusiveStartKey = checkpoint.Segments[segment].LastEvaluatedKey
+			mutex.Unlock()
+
+			for {
+				select {
+				case <-s.stop:
+					return
+				default:
+				}
+
+				result, err := s.scan(&sr)
+				if err != nil {
+					fail(err)
+					return
+				}
+
+				if s.limiter != nil && result.ConsumedCapacity.CapacityUnits > 0 {
+					s.limiter.Aquire(int(math.Ceil(float64(result.ConsumedCapacity.CapacityUnits))))
+				}
+
+				for _, item := range result.Items {
+					if err = fn(item); err != nil {
+						fail(err)
+						return
+					}
+				}
+
+				mutex.Lock()
+				checkpoint.Segments[segment] = SegmentCheckpoint{len(result.LastEvaluatedKey) == 0, result.LastEvaluatedKey}
+				if s.onCheckpoint != nil {
+					s.onCheckpoint(checkpoint.copy())
+				}
+				mutex.Unlock()
+
+				if len(result.LastEvaluatedKey) == 0 {
+					return
+				}
+				sr.ExclusiveStartKey = result.LastEvaluatedKey
+			}
+		}(segment)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return checkpoint, firstErr
+	}
+	if !checkpoint.IsDone() {
+		return checkpoint, ErrScanStopped
+	}
+	return checkpoint, nil
+}
+
+// Scans the segments and calls fn with a pointer to a new struct of v's type for each item.
+// See Run.
+// (v interface{}) A pointer to a struct of the item type.
+func (s *ParallelScan) RunWith(v interface{}, fn func(item interface{}) error) (*ScanCheckpoint, error) {
+
+	itemType := reflect.TypeOf(v).Elem()
+
+	return s.Run(func(item map[string]dynamodb.AttributeValue) error {
+		ptr := reflect.New(itemType).Interface()
+		Unmarshal(item, ptr)
+		return fn(ptr)
+	})
+}
+
+// Runs the scan in a goroutine and streams the items into the returned channel, which is closed
+// when the scan ends. The result of Run is then sent on the error channel; nil if the scan is done.
+// Call Stop if the items are no longer received, or the workers block.
+// (buffer int) The capacity of the items channel.
+func (s *ParallelScan) Stream(buffer int) (<-chan map[string]dynamodb.AttributeValue, <-chan error) {
+
+	items := make(chan map[string]dynamodb.AttributeValue, buffer)
+	errc := make(chan error, 1)
+
+	go func() {
+		_, err := s.Run(func(item map[string]dynamodb.AttributeValue) error {
+			select {
+			case items <- item:
+				return nil
+			case <-s.stop:
+				return ErrScanStopped
+			}
+		})
+		close(items)
+		errc <- err
+		close(errc)
+	}()
+
+	return items, errc
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+func (c *ScanCheckpoint) copy() ScanCheckpoint {
+	segments := make([]SegmentCheckpoint, len(c.Segments))
+	copy(segments, c.Segments)
+	return ScanCheckpoint{c.TotalSegments, segments}
+}
@@ -0,0 +1,135 @@
+package datamodeling
+
+import (
+	"errors"
+	"fmt"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// Returns a scan serving pages of two items out of perSegment items in every segment.
+// It fails once when it reaches failAt in segment 0, if failAt > 0.
+func segmentedScan(perSegment, failAt int) func(*dynamodb.ScanRequest) (*dynamodb.ScanResult, error) {
+
+	failed := false
+	var mutex sync.Mutex
+
+	return func(sr *dynamodb.ScanRequest) (*dynamodb.ScanResult, error) {
+
+		segment := int(*sr.Segment)
+		first := 0
+		if attr, ok := sr.ExclusiveStartKey["N"]; ok {
+			first, _ = strconv.Atoi(attr.N)
+			first++
+		}
+
+		mutex.Lock()
+		if segment == 0 && failAt > 0 && first >= failAt && !failed {
+			failed = true
+			mutex.Unlock()
+			return nil, errors.New("scan failed")
+		}
+		mutex.Unlock()
+
+		result := &dynamodb.ScanResult{ConsumedCapacity: dynamodb.ConsumedCapacity{CapacityUnits: 0.5}}
+		for i := first; i < first+2 && i < perSegment; i++ {
+			result.Items = append(result.Items, map[string]dynamodb.AttributeValue{
+				"Id": dynamodb.NewAttributeValue(fmt.Sprintf("%d-%d", segment, i)),
+				"N":  dynamodb.NewIntAttributeValue(int64(i)),
+			})
+		}
+		if first+2 < perSegment {
+			result.LastEvaluatedKey = result.Items[len(result.Items)-1]
+		}
+		return result, nil
+	}
+}
+
+func TestParallelScan(t *testing.T) {
+
+	scan := newParallelScan(segmentedScan(5, 0), dynamodb.NewScanRequest("Table"), 3)
+	scan.SetReadCapacityLimit(1000)
+
+	checkpoints := 0
+	scan.SetCheckpointHandler(func(ScanCheckpoint) { checkpoints++ })
+
+	var mutex sync.Mutex
+	seen := make(map[string]bool)
+
+	checkpoint, err := scan.RunWith(&pagedItem{}, func(item interface{}) error {
+		mutex.Lock()
+		seen[item.(*pagedItem).Id] = true
+		mutex.Unlock()
+		return nil
+	})
+
+	if err != nil || !checkpoint.IsDone() {
+		t.Fatalf("Expected a done scan, got %+v (%v)", checkpoint, err)
+	}
+	if len(seen) != 15 || !seen["2-4"] || checkpoints != 9 {
+		t.Fatalf("Expected 15 items and 9 checkpoints, got %d items and %d checkpoints", len(seen), checkpoints)
+	}
+}
+
+func TestParallelScanResume(t *testing.T) {
+
+	fetch := segmentedScan(6, 2)
+	count := func(n *int, mutex *sync.Mutex) func(map[string]dynamodb.AttributeValue) error {
+		return func(map[string]dynamodb.AttributeValue) error {
+			mutex.Lock()
+			*n++
+			mutex.Unlock()
+			return nil
+		}
+	}
+
+	var mutex sync.Mutex
+	first := 0
+	checkpoint, err := newParallelScan(fetch, dynamodb.NewScanRequest("Table"), 2).Run(count(&first, &mutex))
+	if err == nil || checkpoint.IsDone() || checkpoint.Segments[0].Done || checkpoint.Segments[0].LastEvaluatedKey["N"].N != "1" {
+		t.Fatalf("Expected a failed scan at segment 0 item 2, got %+v (%v)", checkpoint, err)
+	}
+
+	resumed := newParallelScan(fetch, dynamodb.NewScanRequest("Table"), 2)
+	resumed.SetCheckpoint(checkpoint)
+
+	second := 0
+	if checkpoint, err = resumed.Run(count(&second, &mutex)); err != nil || !checkpoint.IsDone() {
+		t.Fatalf("Expected a done scan, got %+v (%v)", checkpoint, err)
+	}
+	if first+second != 12 {
+		t.Fatalf("Expected 12 items without repeats, got %d + %d", first, second)
+	}
+
+	wrong := newParallelScan(fetch, dynamodb.NewScanRequest("Table"), 3)
+	wrong.SetCheckpoint(checkpoint)
+	if _, err = wrong.Run(count(&second, &mutex)); err == nil {
+		t.Fatal("Expected an error for a checkpoint with a different number of segments.")
+	}
+}
+
+func TestParallelScanStream(t *testing.T) {
+
+	scan := newParallelScan(segmentedScan(4, 0), dynamodb.NewScanRequest("Table"), 2)
+	items, errc := scan.Stream(1)
+
+	n := 0
+	for _ = range items {
+		n++
+	}
+	if err := <-errc; err != nil || n != 8 {
+		t.Fatalf("Expected 8 items, got %d (%v)", n, err)
+	}
+
+	scan = newParallelScan(segmentedScan(100, 0), dynamodb.NewScanRequest("Table"), 2)
+	items, errc = scan.Stream(0)
+	<-items
+	scan.Stop()
+	for _ = range items {
+	}
+	if err := <-errc; err != ErrScanStopped {
+		t.Fatalf("Expected ErrScanStopped, got %v", err)
+	}
+}
@@ -0,0 +1,385 @@
+package datamodeling
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Provisioned throughput of generated tables and global secondary indexes.
+const (
+	DEFAULT_READ_CAPACITY_UNITS  int64 = 5
+	DEFAULT_WRITE_CAPACITY_UNITS int64 = 5
+)
+
+// A secondary index derived from the struct tags.
+type tableIndex struct {
+	name             string
+	hashKey          string
+	rangeKey         string
+	projectionType   dynamodb.ProjectionType
+	nonKeyAttributes []string
+}
+
+// The table schema derived from the struct tags.
+type tableSchema struct {
+	tableName  string
+	hashKey    string
+	rangeKey   string
+	attributes []dynamodb.AttributeDefinition
+	gsis       []*tableIndex
+	lsis       []*tableIndex
+}
+
+/*****************************************************************************/
+
+// Generates a CreateTableRequest for the table of v from its struct tags, with the key schema,
+// attribute definitions, global and local secondary indexes, and the default provisioned throughput.
+// (v interface{}) A pointer to a struct of the item type.
+func (m *DynamoDBMapper) GenerateCreateTableRequest(v interface{}) (*dynamodb.CreateTableRequest, error) {
+
+	schema, err := parseSchema(v)
+	if err != nil {
+		return nil, err
+	}
+
+	ctr := dynamodb.NewCreateTableRequest(m.TableName(v), DEFAULT_READ_CAPACITY_UNITS, DEFAULT_WRITE_CAPACITY_UNITS)
+	ctr.AttributeDefinitions = schema.attributes
+
+	ctr.AddKeySchemaElement(schema.hashKey, dynamodb.HASH)
+	if schema.rangeKey != "" {
+		ctr.AddKeySchemaElement(schema.rangeKey, dynamodb.RANGE)
+	}
+
+	for _, idx := range schema.gsis {
+		ctr.GlobalSecondaryIndexes = append(ctr.GlobalSecondaryIndexes, dynamodb.GlobalSecondaryIndex{
+			IndexName:  idx.name,
+			KeySchema:  idx.keySchema(),
+			Projection: idx.projection(),
+			ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+				ReadCapacityUnits:  DEFAULT_READ_CAPACITY_UNITS,
+				WriteCapacityUnits: DEFAULT_WRITE_CAPACITY_UNITS,
+			},
+		})
+	}
+
+	for _, idx := range schema.lsis {
+		idx.hashKey = schema.hashKey
+		ctr.LocalSecondaryIndexes = append(ctr.LocalSecondaryIndexes, dynamodb.LocalSecondaryIndex{
+			IndexName:  idx.name,
+			KeySchema:  idx.keySchema(),
+			Projection: idx.projection(),
+		})
+	}
+
+	return ctr, nil
+}
+
+// Creates the table of v if it does not exist, or else adds the global secondary indexes of v
+// that the table is missing, and returns the names of the created indexes.
+//
+// DynamoDB creates one index per UpdateTable call and rejects updates while the table is not ACTIVE.
+// If an update fails, the indexes created so far are returned with the error; call MigrateTable
+// again once the table is ACTIVE. Local secondary indexes can only be created with the table.
+// (v interface{}) A pointer to a struct of the item type.
+func (m *DynamoDBMapper) MigrateTable(v interface{}) (created []string, err error) {
+
+	ctr, err := m.GenerateCreateTableRequest(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dtr, err := m.DynamoDBService.DescribeTable(dynamodb.NewDescribeTableRequest(ctr.TableName))
+	if err != nil {
+		if !dynamodb.IsErrorType(err, dynamodb.RESOURCE_NOT_FOUND_EXCEPTION) {
+			return nil, err
+		}
+		if _, err = m.DynamoDBService.CreateTable(ctr); err != nil {
+			return nil, err
+		}
+		for _, gsi := range ctr.GlobalSecondaryIndexes {
+			created = append(created, gsi.IndexName)
+		}
+		return created, nil
+	}
+
+	missing, err := missingIndexes(ctr, &dtr.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range missing {
+		utr := &dynamodb.UpdateTableRequest{
+			TableName:                   ctr.TableName,
+			AttributeDefinitions:        keyDefinitions(ctr.AttributeDefinitions, missing[i].KeySchema),
+			GlobalSecondaryIndexUpdates: []dynamodb.GlobalSecondaryIndexUpdate{{Create: &missing[i]}},
+		}
+		if _, err = m.DynamoDBService.UpdateTable(utr); err != nil {
+			return created, err
+		}
+		created = append(created, missing[i].IndexName)
+	}
+
+	return created, nil
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+func (idx *tableIndex) keySchema() []dynamodb.KeySchemaElement {
+	schema := []dynamodb.KeySchemaElement{{AttributeName: idx.hashKey, KeyType: dynamodb.HASH}}
+	if idx.rangeKey != "" {
+		schema = append(schema, dynamodb.KeySchemaElement{AttributeName: idx.rangeKey, KeyType: dynamodb.RANGE})
+	}
+	return schema
+}
+
+// Projected attributes make an INCLUDE projection. Defaults to ALL.
+func (idx *tableIndex) projection() *dynamodb.Projection {
+	if len(idx.nonKeyAttributes) > 0 {
+		return &dynamodb.Projection{NonKeyAttributes: idx.nonKeyAttributes, ProjectionType: dynamodb.INCLUDE}
+	}
+	if idx.projectionType == "" {
+		return &dynamodb.Projection{ProjectionType: dynamodb.ALL}
+	}
+	return &dynamodb.Projection{ProjectionType: idx.projectionType}
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+// Returns the schema of the struct v from its tags.
+func parseSchema(v interface{}) (*tableSchema, error) {
+
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("datamodeling: A table can only be generated from a struct.")
+	}
+
+	info := cachedStructInfo(t)
+	schema := &tableSchema{tableName: info.tableName}
+	gsis := make(map[string]*tableIndex)
+	lsis := make(map[string]*tableIndex)
+	defined := make(map[string]bool)
+
+	index := func(indexes map[string]*tableIndex, list *[]*tableIndex, name string) *tableIndex {
+		if idx, ok := indexes[name]; ok {
+			return idx
+		}
+		idx := &tableIndex{name: name}
+		indexes[name] = idx
+		*list = append(*list, idx)
+		return idx
+	}
+
+	define := func(name string, f reflect.StructField) error {
+		if defined[name] {
+			return nil
+		}
+		attributeType, err := keyAttributeType(f)
+		if err != nil {
+			return err
+		}
+		defined[name] = true
+		schema.attributes = append(schema.attributes, dynamodb.AttributeDefinition{AttributeName: name, AttributeType: attributeType})
+		return nil
+	}
+
+	projected := make(map[string][]string)
+
+	for _, field := range info.fields {
+
+		f := field.field
+		projectionType := dynamodb.ProjectionType(strings.ToUpper(f.Tag.Get("DynamoDBProjectionType")))
+
+		if val := f.Tag.Get("DynamoDBHashKey"); val != "" {
+			schema.hashKey = val
+			if err := define(val, f); err != nil {
+				return nil, err
+			}
+		}
+
+		if val := f.Tag.Get("DynamoDBRangeKey"); val != "" {
+			schema.rangeKey = val
+			if err := define(val, f); err != nil {
+				return nil, err
+			}
+		}
+
+		if val := f.Tag.Get("DynamoDBIndexHashKey"); val != "" {
+			for _, name := range indexNames(f.Tag.Get("DynamoDBGlobalSecondaryIndexNames"), val) {
+				idx := index(gsis, &schema.gsis, name)
+				idx.hashKey = val
+				if projectionType != "" {
+					idx.projectionType = projectionType
+				}
+			}
+			if err := define(val, f); err != nil {
+				return nil, err
+			}
+		}
+
+		if val := f.Tag.Get("DynamoDBIndexRangeKey"); val != "" {
+
+			gsiNames := indexNames(f.Tag.Get("DynamoDBGlobalSecondaryIndexNames"), "")
+			lsiNames := indexNames(f.Tag.Get("DynamoDBLocalSecondaryIndexNames"), "")
+			if len(gsiNames) == 0 && len(lsiNames) == 0 {
+				lsiNames = []string{val + "-index"}
+			}
+
+			for _, name := range gsiNames {
+				idx := index(gsis, &schema.gsis, name)
+				idx.rangeKey = val
+				if projectionType != "" {
+					idx.projectionType = projectionType
+				}
+			}
+			for _, name := range lsiNames {
+				idx := index(lsis, &schema.lsis, name)
+				idx.rangeKey = val
+				if projectionType != "" {
+					idx.projectionType = projectionType
+				}
+			}
+			if err := define(val, f); err != nil {
+				return nil, err
+			}
+		}
+
+		if names := indexNames(f.Tag.Get("DynamoDBProjectedIndexes"), ""); len(names) > 0 {
+			if attr := attributeName(f); attr != "" {
+				for _, name := range names {
+					projected[name] = append(projected[name], attr)
+				}
+			}
+		}
+	}
+
+	if schema.hashKey == "" {
+		return nil, errors.New("datamodeling: Table " + schema.tableName + " has no DynamoDBHashKey.")
+	}
+
+	for _, idx := range schema.gsis {
+		if idx.hashKey == "" {
+			return nil, errors.New("datamodeling: Global secondary index " + idx.name + " has no DynamoDBIndexHashKey.")
+		}
+		idx.nonKeyAttributes = projected[idx.name]
+	}
+	for _, idx := range schema.lsis {
+		if schema.rangeKey == "" {
+			return nil, errors.New("datamodeling: Local secondary index " + idx.name + " requires a table with a DynamoDBRangeKey.")
+		}
+		idx.nonKeyAttributes = projected[idx.name]
+	}
+
+	return schema, nil
+}
+
+// Splits a comma separated list of index names, or returns the default index name "attribute-index".
+func indexNames(tag, attribute string) []string {
+
+	var names []string
+	for _, name := range strings.Split(tag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 && attribute != "" {
+		names = []string{attribute + "-index"}
+	}
+	return names
+}
+
+// Returns the attribute name of a tagged field, or an empty string.
+func attributeName(f reflect.StructField) string {
+	for _, tag := range range_list {
+		if val := f.Tag.Get(tag); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// Returns the scalar attribute type of a key field, as Marshal stores it.
+func keyAttributeType(f reflect.StructField) (dynamodb.AttributeType, error) {
+
+	switch f.Tag.Get("DynamoDBType") {
+	case "S", "STRING", "s", "string":
+		return dynamodb.STRING, nil
+	case "N", "NUMBER", "n", "number":
+		return dynamodb.NUMBER, nil
+	case "B", "BINARY", "b", "binary":
+		return dynamodb.BINARY, nil
+	}
+
+	if f.Type.Kind() == reflect.Ptr {
+		f.Type = f.Type.Elem()
+	}
+
+	if f.Type == reflect.TypeOf(time.Time{}) {
+		return dynamodb.STRING, nil
+	}
+	if f.Type == reflect.TypeOf([]byte(nil)) {
+		return dynamodb.BINARY, nil
+	}
+
+	switch f.Type.Kind() {
+	case reflect.String, reflect.Bool:
+		return dynamodb.STRING, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return dynamodb.NUMBER, nil
+	}
+
+	return "", errors.New("datamodeling: Field " + f.Name + " of type " + f.Type.String() + " cannot be a key attribute.")
+}
+
+// Returns the global secondary indexes of the request that the table is missing.
+func missingIndexes(ctr *dynamodb.CreateTableRequest, table *dynamodb.TableDescription) ([]dynamodb.GlobalSecondaryIndex, error) {
+
+	existing := make(map[string]bool)
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		existing[gsi.IndexName] = true
+	}
+	for _, lsi := range table.LocalSecondaryIndexes {
+		existing[lsi.IndexName] = true
+	}
+
+	for _, lsi := range ctr.LocalSecondaryIndexes {
+		if !existing[lsi.IndexName] {
+			return nil, errors.New("datamodeling: Local secondary index " + lsi.IndexName + " can only be created with the table.")
+		}
+	}
+
+	var missing []dynamodb.GlobalSecondaryIndex
+	for _, gsi := range ctr.GlobalSecondaryIndexes {
+		if !existing[gsi.IndexName] {
+			missing = append(missing, gsi)
+		}
+	}
+	return missing, nil
+}
+
+// Returns the attribute definitions of the key schema.
+func keyDefinitions(definitions []dynamodb.AttributeDefinition, keySchema []dynamodb.KeySchemaElement) []dynamodb.AttributeDefinition {
+
+	var keys []dynamodb.AttributeDefinition
+	for _, def := range definitions {
+		for _, key := range keySchema {
+			if def.AttributeName == key.AttributeName {
+				keys = append(keys, def)
+				break
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,118 @@
+package datamodeling
+
+import (
+	"encoding/json"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaItem struct {
+	Table     string    `DynamoDBTable:"Orders"`
+	Customer  string    `DynamoDBHashKey:"Customer"`
+	Created   time.Time `DynamoDBRangeKey:"Created"`
+	Status    string    `DynamoDBIndexHashKey:"Status" DynamoDBGlobalSecondaryIndexNames:"StatusIndex,StatusTotalIndex"`
+	Total     float64   `DynamoDBIndexRangeKey:"Total" DynamoDBGlobalSecondaryIndexNames:"StatusTotalIndex" DynamoDBLocalSecondaryIndexNames:"TotalIndex" DynamoDBProjectionType:"KEYS_ONLY"`
+	Sku       int       `DynamoDBIndexHashKey:"Sku"`
+	Note      string    `DynamoDBAttribute:"Note" DynamoDBProjectedIndexes:"StatusIndex"`
+	Unindexed string    `DynamoDBAttribute:"Unindexed"`
+}
+
+func TestGenerateCreateTableRequest(t *testing.T) {
+
+	ctr, err := (&DynamoDBMapper{}).GenerateCreateTableRequest(&schemaItem{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ctr.TableName != "Orders" {
+		t.Errorf("Unexpected table name %s", ctr.TableName)
+	}
+	if !reflect.DeepEqual(ctr.KeySchema, []dynamodb.KeySchemaElement{{AttributeName: "Customer", KeyType: dynamodb.HASH}, {AttributeName: "Created", KeyType: dynamodb.RANGE}}) {
+		t.Errorf("Unexpected key schema %v", ctr.KeySchema)
+	}
+
+	defs := []dynamodb.AttributeDefinition{
+		{AttributeName: "Customer", AttributeType: dynamodb.STRING},
+		{AttributeName: "Created", AttributeType: dynamodb.STRING},
+		{AttributeName: "Status", AttributeType: dynamodb.STRING},
+		{AttributeName: "Total", AttributeType: dynamodb.NUMBER},
+		{AttributeName: "Sku", AttributeType: dynamodb.NUMBER},
+	}
+	if !reflect.DeepEqual(ctr.AttributeDefinitions, defs) {
+		t.Errorf("Unexpected attribute definitions %v", ctr.AttributeDefinitions)
+	}
+
+	if len(ctr.GlobalSecondaryIndexes) != 3 {
+		t.Fatalf("Expected 3 global secondary indexes, got %+v", ctr.GlobalSecondaryIndexes)
+	}
+	status, statusTotal, sku := ctr.GlobalSecondaryIndexes[0], ctr.GlobalSecondaryIndexes[1], ctr.GlobalSecondaryIndexes[2]
+
+	if status.IndexName != "StatusIndex" || len(status.KeySchema) != 1 ||
+		!reflect.DeepEqual(*status.Projection, dynamodb.Projection{NonKeyAttributes: []string{"Note"}, ProjectionType: dynamodb.INCLUDE}) {
+		t.Errorf("Unexpected StatusIndex %+v %+v", status, status.Projection)
+	}
+	if statusTotal.IndexName != "StatusTotalIndex" || statusTotal.KeySchema[1].AttributeName != "Total" ||
+		statusTotal.Projection.ProjectionType != dynamodb.KEYS_ONLY {
+		t.Errorf("Unexpected StatusTotalIndex %+v %+v", statusTotal, statusTotal.Projection)
+	}
+	if sku.IndexName != "Sku-index" || sku.Projection.ProjectionType != dynamodb.ALL ||
+		sku.ProvisionedThroughput.ReadCapacityUnits != DEFAULT_READ_CAPACITY_UNITS {
+		t.Errorf("Unexpected Sku-index %+v", sku)
+	}
+
+	if len(ctr.LocalSecondaryIndexes) != 1 || !reflect.DeepEqual(ctr.LocalSecondaryIndexes[0].KeySchema,
+		[]dynamodb.KeySchemaElement{{AttributeName: "Customer", KeyType: dynamodb.HASH}, {AttributeName: "Total", KeyType: dynamodb.RANGE}}) {
+		t.Errorf("Unexpected local secondary indexes %+v", ctr.LocalSecondaryIndexes)
+	}
+
+	b, _ := json.Marshal(ctr.ProvisionedThroughput)
+	if string(b) != `{"ReadCapacityUnits":5,"WriteCapacityUnits":5}` {
+		t.Errorf("Unexpected ProvisionedThroughput JSON %s", b)
+	}
+}
+
+func TestGenerateCreateTableRequestErrors(t *testing.T) {
+
+	type noHashKey struct {
+		Name string `DynamoDBAttribute:"Name"`
+	}
+	type badKey struct {
+		Id []string `DynamoDBHashKey:"Id"`
+	}
+	type noRangeKey struct {
+		Id    string `DynamoDBHashKey:"Id"`
+		Total int    `DynamoDBIndexRangeKey:"Total"`
+	}
+
+	for _, v := range []interface{}{&noHashKey{}, &badKey{}, &noRangeKey{}} {
+		if _, err := (&DynamoDBMapper{}).GenerateCreateTableRequest(v); err == nil {
+			t.Errorf("Expected an error for %T", v)
+		}
+	}
+}
+
+func TestMissingIndexes(t *testing.T) {
+
+	ctr, _ := (&DynamoDBMapper{}).GenerateCreateTableRequest(&schemaItem{})
+
+	table := &dynamodb.TableDescription{
+		GlobalSecondaryIndexes: []dynamodb.GlobalSecondaryIndexDescription{{IndexName: "StatusIndex"}},
+		LocalSecondaryIndexes:  []dynamodb.LocalSecondaryIndexDescription{{IndexName: "TotalIndex"}},
+	}
+
+	missing, err := missingIndexes(ctr, table)
+	if err != nil || len(missing) != 2 || missing[0].IndexName != "StatusTotalIndex" || missing[1].IndexName != "Sku-index" {
+		t.Fatalf("Unexpected missing indexes %+v (%v)", missing, err)
+	}
+	if defs := keyDefinitions(ctr.AttributeDefinitions, missing[0].KeySchema); len(defs) != 2 {
+		t.Errorf("Unexpected key definitions %v", defs)
+	}
+
+	table.LocalSecondaryIndexes = nil
+	if _, err = missingIndexes(ctr, table); err == nil || !strings.Contains(err.Error(), "TotalIndex") {
+		t.Errorf("Expected a local secondary index error, got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package datamodeling
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"github.com/twhello/aws-to-go/services/dynamodb/expression"
+	"sort"
+)
+
+type transactionMode int
+
+const (
+	putTransaction transactionMode = iota
+	updateTransaction
+	deleteTransaction
+	conditionCheckTransaction
+)
+
+type transactionOperation struct {
+	mode      transactionMode
+	item      interface{}
+	condition *expression.ConditionBuilder
+}
+
+// The put, update, delete and condition check operations of a transaction on structs, which can be of
+// different types and tables. Use datamodeling.NewTransactionWriteRequest().
+type TransactionWriteRequest struct {
+	ClientRequestToken string // Makes the transaction idempotent for 10 minutes. Can be empty.
+	operations         []transactionOperation
+}
+
+// Creates a new TransactionWriteRequest.
+func NewTransactionWriteRequest() *TransactionWriteRequest {
+	return &TransactionWriteRequest{}
+}
+
+// Puts the struct whole, like the CLOBBER save behavior.
+// (condition *expression.ConditionBuilder) Can be nil.
+func (r *TransactionWriteRequest) AddPut(v interface{}, condition *expression.ConditionBuilder) {
+	r.operations = append(r.operations, transactionOperation{putTransaction, v, condition})
+}
+
+// Updates the modeled attributes of the struct, like the mapper's UPDATE or UPDATE_SKIP_NULL_ATTRIBUTES save behavior.
+// (condition *expression.ConditionBuilder) Can be nil.
+func (r *TransactionWriteRequest) AddUpdate(v interface{}, condition *expression.ConditionBuilder) {
+	r.operations = append(r.operations, transactionOperation{updateTransaction, v, condition})
+}
+
+// Deletes the struct's item.
+// (condition *expression.ConditionBuilder) Can be nil.
+func (r *TransactionWriteRequest) AddDelete(v interface{}, condition *expression.ConditionBuilder) {
+	r.operations = append(r.operations, transactionOperation{deleteTransaction, v, condition})
+}
+
+// Checks a condition on the struct's item without writing it.
+func (r *TransactionWriteRequest) AddConditionCheck(v interface{}, condition expression.ConditionBuilder) {
+	r.operations = append(r.operations, transactionOperation{conditionCheckTransaction, v, &condition})
+}
+
+/*****************************************************************************/
+
+// Writes the operations of the request in a single, all-or-nothing transaction.
+// Structs with a DynamoDBVersionAttribute are conditioned on their version, as with Save and Delete,
+// and the version fields of put and updated structs are incremented on success.
+// A canceled transaction returns a *dynamodb.TransactionCanceledError, whose CancellationReasons
+// are in the order the operations were added.
+func (m *DynamoDBMapper) TransactionWrite(request *TransactionWriteRequest) error {
+
+	if len(request.operations) == 0 {
+		return errors.New("datamodeling: The transaction has no operations.")
+	}
+
+	twir := dynamodb.NewTransactWriteItemsRequest(request.ClientRequestToken)
+	skipNull := m.DynamoDBMapperConfig.SaveBehavior == UPDATE_SKIP_NULL_ATTRIBUTES
+
+	var versions []*versioning
+
+	for _, op := range request.operations {
+
+		item, ver, err := op.build(m, skipNull)
+		if err != nil {
+			return err
+		}
+		twir.TransactItems = append(twir.TransactItems, item)
+
+		if ver != nil && (op.mode == putTransaction || op.mode == updateTransaction) {
+			versions = append(versions, ver)
+		}
+	}
+
+	if _, err := m.DynamoDBService.TransactWriteItems(twir); err != nil {
+		return err
+	}
+
+	for _, ver := range versions {
+		ver.increment()
+	}
+
+	return nil
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+// Builds the TransactWriteItem of the operation in the mapper's table, and returns the struct's versioning, if any.
+func (op transactionOperation) build(m *DynamoDBMapper, skipNull bool) (item dynamodb.TransactWriteItem, ver *versioning, err error) {
+
+	model := m.marshal(op.item)
+
+	key, err := modelKey(model)
+	if err != nil {
+		return item, nil, err
+	}
+
+	if ver, err = newVersioning(op.item, model); err != nil {
+		return item, nil, err
+	}
+
+	var conditions []expression.ConditionBuilder
+	if op.condition != nil {
+		conditions = append(conditions, *op.condition)
+	}
+	if ver != nil {
+		conditions = append(conditions, ver.condition())
+		if op.mode == putTransaction || op.mode == updateTransaction {
+			model.Item[ver.attribute] = ver.next
+		}
+	}
+
+	builder := expression.NewBuilder()
+	switch len(conditions) {
+	case 1:
+		builder.WithCondition(conditions[0])
+	case 2:
+		builder.WithCondition(expression.And(conditions[0], conditions[1]))
+	}
+
+	if op.mode == updateTransaction {
+		update, ok := updateOf(model, skipNull)
+		if !ok {
+			return item, nil, errors.New("datamodeling: The struct has no attributes to update.")
+		}
+		builder.WithUpdate(update)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return item, nil, err
+	}
+
+	switch op.mode {
+	case putTransaction:
+		attrs := make(map[string]dynamodb.AttributeValue)
+		for k, attr := range model.Item {
+			if !attr.IsEmpty() {
+				attrs[k] = attr
+			}
+		}
+		item.Put = &dynamodb.Put{
+			ConditionExpression:       expr.Condition,
+			ExpressionAttributeNames:  expr.Names,
+			ExpressionAttributeValues: expr.Values,
+			Item:                      attrs,
+			TableName:                 model.TableName,
+		}
+
+	case updateTransaction:
+		item.Update = &dynamodb.Update{
+			ConditionExpression:       expr.Condition,
+			ExpressionAttributeNames:  expr.Names,
+			ExpressionAttributeValues: expr.Values,
+			Key:                       key,
+			TableName:                 model.TableName,
+			UpdateExpression:          expr.Update,
+		}
+
+	case deleteTransaction:
+		item.Delete = &dynamodb.Delete{
+			ConditionExpression:       expr.Condition,
+			ExpressionAttributeNames:  expr.Names,
+			ExpressionAttributeValues: expr.Values,
+			Key:                       key,
+			TableName:                 model.TableName,
+		}
+
+	default:
+		item.ConditionCheck = &dynamodb.ConditionCheck{
+			ConditionExpression:       expr.Condition,
+			ExpressionAttributeNames:  expr.Names,
+			ExpressionAttributeValues: expr.Values,
+			Key:                       key,
+			TableName:                 model.TableName,
+		}
+	}
+
+	return item, ver, nil
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+// Returns the update expression that sets the model's non-key attributes and, unless skipNull,
+// removes its empty ones. Returns false if there is nothing to update.
+func updateOf(model *DataModel, skipNull bool) (expression.UpdateBuilder, bool) {
+
+	names := make([]string, 0, len(model.Item))
+	for k := range model.Item {
+		if k != model.HashKey && k != model.RangeKey {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	var update expression.UpdateBuilder
+	n := 0
+
+	for _, k := range names {
+		switch attr := model.Item[k]; {
+		case !attr.IsEmpty():
+			update = update.Set(expression.AttributeName(k), expression.Value(attr))
+		case !skipNull:
+			update = update.Remove(expression.AttributeName(k))
+		default:
+			continue
+		}
+		n++
+	}
+
+	return update, n > 0
+}
@@ -0,0 +1,114 @@
+package datamodeling
+
+import (
+	"github.com/twhello/aws-to-go/services/dynamodb/expression"
+	"strings"
+	"testing"
+)
+
+type transactionItem struct {
+	Id      string `DynamoDBHashKey:"Id"`
+	Name    string `DynamoDBAttribute:"Name"`
+	Note    string `DynamoDBAttribute:"Note"`
+	Version int64  `DynamoDBVersionAttribute:"Version"`
+}
+
+func TestTransactionPut(t *testing.T) {
+
+	cond := expression.Name("Name").NotEqual(expression.Value("x"))
+	op := transactionOperation{putTransaction, &transactionItem{Id: "a", Name: "n", Version: 2}, &cond}
+
+	item, ver, err := op.build(&DynamoDBMapper{}, false)
+	if err != nil || ver == nil || item.Put == nil {
+		t.Fatalf("Expected a versioned put, got %+v (%v)", item, err)
+	}
+
+	put := item.Put
+	if put.ConditionExpression != "(#n0 <> :v0) AND (#n1 = :v1)" || put.ExpressionAttributeNames["#n1"] != "Version" ||
+		put.ExpressionAttributeValues[":v1"].N != "2" {
+		t.Errorf("Unexpected condition %q %v %v", put.ConditionExpression, put.ExpressionAttributeNames, put.ExpressionAttributeValues)
+	}
+	if put.Item["Version"].N != "3" || put.Item["Name"].S != "n" {
+		t.Errorf("Unexpected item %v", put.Item)
+	}
+	if _, ok := put.Item["Note"]; ok {
+		t.Error("Expected empty attributes to be omitted.")
+	}
+}
+
+func TestTransactionUpdate(t *testing.T) {
+
+	op := transactionOperation{updateTransaction, &transactionItem{Id: "a", Name: "n"}, nil}
+
+	item, _, err := op.build(&DynamoDBMapper{}, false)
+	if err != nil || item.Update == nil {
+		t.Fatalf("Expected an update, got %+v (%v)", item, err)
+	}
+
+	update := item.Update
+	if update.Key["Id"].S != "a" || len(update.Key) != 1 {
+		t.Errorf("Unexpected key %v", update.Key)
+	}
+	if update.ConditionExpression != "attribute_not_exists (#n0)" {
+		t.Errorf("Unexpected condition %q", update.ConditionExpression)
+	}
+	if update.UpdateExpression != "SET #n1 = :v0, #n0 = :v1 REMOVE #n2" {
+		t.Errorf("Unexpected update %q %v", update.UpdateExpression, update.ExpressionAttributeNames)
+	}
+
+	item, _, _ = op.build(&DynamoDBMapper{}, true)
+	if item.Update.UpdateExpression != "SET #n1 = :v0, #n0 = :v1" {
+		t.Errorf("Expected empty attributes to be skipped, got %q", item.Update.UpdateExpression)
+	}
+}
+
+func TestTransactionDottedNames(t *testing.T) {
+
+	type dottedItem struct {
+		Id      string `DynamoDBHashKey:"Id"`
+		Name    string `DynamoDBAttribute:"a.b"`
+		First   string `DynamoDBAttribute:"x[0]"`
+		Version int64  `DynamoDBVersionAttribute:"v.1"`
+	}
+
+	op := transactionOperation{updateTransaction, &dottedItem{Id: "a", Name: "n", Version: 1}, nil}
+
+	item, _, err := op.build(&DynamoDBMapper{}, false)
+	if err != nil || item.Update == nil {
+		t.Fatalf("Expected an update, got %+v (%v)", item, err)
+	}
+
+	update := item.Update
+	names := make(map[string]bool)
+	for _, name := range update.ExpressionAttributeNames {
+		names[name] = true
+	}
+	if len(names) != 3 || !names["a.b"] || !names["x[0]"] || !names["v.1"] {
+		t.Errorf("Expected the attribute names as they are, got %v", update.ExpressionAttributeNames)
+	}
+	if strings.ContainsAny(update.UpdateExpression+update.ConditionExpression, ".[") {
+		t.Errorf("Expected no document paths, got %q and %q", update.UpdateExpression, update.ConditionExpression)
+	}
+}
+
+func TestTransactionDeleteAndConditionCheck(t *testing.T) {
+
+	type plainItem struct {
+		Id string `DynamoDBHashKey:"Id"`
+	}
+
+	item, ver, err := transactionOperation{deleteTransaction, &plainItem{Id: "a"}, nil}.build(&DynamoDBMapper{}, false)
+	if err != nil || ver != nil || item.Delete == nil || item.Delete.ConditionExpression != "" || item.Delete.ExpressionAttributeNames != nil {
+		t.Fatalf("Expected an unconditional delete, got %+v (%v)", item.Delete, err)
+	}
+
+	cond := expression.AttributeExists(expression.Name("Id"))
+	item, _, err = transactionOperation{conditionCheckTransaction, &plainItem{Id: "a"}, &cond}.build(&DynamoDBMapper{}, false)
+	if err != nil || item.ConditionCheck == nil || item.ConditionCheck.ConditionExpression != "attribute_exists (#n0)" {
+		t.Fatalf("Expected a condition check, got %+v (%v)", item.ConditionCheck, err)
+	}
+
+	if _, _, err = (transactionOperation{deleteTransaction, &plainItem{}, nil}).build(&DynamoDBMapper{}, false); err == nil {
+		t.Fatal("Expected an error for a missing key.")
+	}
+}
@@ -0,0 +1,134 @@
+package datamodeling
+
+import (
+	"errors"
+	"fmt"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"github.com/twhello/aws-to-go/services/dynamodb/expression"
+	"reflect"
+)
+
+// Returned by save and delete operations when the item's version attribute does not match
+// the version of the struct, i.e. the item was modified or created by another writer.
+type VersionConflictError struct {
+	TableName        string
+	VersionAttribute string
+	Version          string // The version the struct expected. Empty for a new item.
+	Err              error  // The ConditionalCheckFailedException.
+}
+
+func (e *VersionConflictError) Error() string {
+	if e.Version == "" {
+		return fmt.Sprintf("datamodeling: Version conflict on table %s. Expected no item, but one exists.", e.TableName)
+	}
+	return fmt.Sprintf("datamodeling: Version conflict on table %s. Expected %s %s.", e.TableName, e.VersionAttribute, e.Version)
+}
+
+/*****************************************************************************/
+
+// The optimistic locking state of a struct with a DynamoDBVersionAttribute field.
+// A zero version means the item is new and must not exist yet.
+type versioning struct {
+	field      reflect.Value
+	attribute  string
+	current    dynamodb.AttributeValue
+	next       dynamodb.AttributeValue
+	conditions bool // True if expect() merged user conditions, any of which can fail the check.
+}
+
+// Returns the versioning state of v, or nil if v has no version attribute.
+func newVersioning(v interface{}, model *DataModel) (*versioning, error) {
+
+	if model.VersionAttribute == "" {
+		return nil, nil
+	}
+
+	e := reflect.ValueOf(v).Elem()
+
+	for _, field := range cachedStructInfo(e.Type()).fields {
+
+		if field.tag != "DynamoDBVersionAttribute" || field.name != model.VersionAttribute {
+			continue
+		}
+
+		f, _ := fieldByIndex(e, field.index, true)
+		ver := &versioning{field: f, attribute: model.VersionAttribute}
+
+		switch f.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if f.Int() != 0 {
+				ver.current = dynamodb.NewIntAttributeValue(f.Int())
+			}
+			ver.next = dynamodb.NewIntAttributeValue(f.Int() + 1)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if f.Uint() != 0 {
+				ver.current = dynamodb.NewUintAttributeValue(f.Uint())
+			}
+			ver.next = dynamodb.NewUintAttributeValue(f.Uint() + 1)
+		default:
+			return nil, errors.New("datamodeling: The version attribute " + model.VersionAttribute + " must be an integer type.")
+		}
+
+		return ver, nil
+	}
+
+	return nil, nil
+}
+
+// Returns a copy of expected with the condition on the current version added.
+// A condition on the version attribute already present in expected is kept.
+func (ver *versioning) expect(conditionalOperator dynamodb.ConditionalOperator, expected map[string]dynamodb.ExpectedAttributeValue) (map[string]dynamodb.ExpectedAttributeValue, error) {
+
+	ver.conditions = len(expected) > 0
+
+	if _, ok := expected[ver.attribute]; ok {
+		return expected, nil
+	}
+
+	if conditionalOperator == dynamodb.OR && len(expected) > 0 {
+		return nil, errors.New("datamodeling: The OR conditional operator cannot be combined with the version check.")
+	}
+
+	merged := make(map[string]dynamodb.ExpectedAttributeValue, len(expected)+1)
+	for k, v := range expected {
+		merged[k] = v
+	}
+
+	if ver.current.IsEmpty() {
+		merged[ver.attribute] = dynamodb.ExpectedAttributeValue{ComparisonOperator: dynamodb.NULL}
+	} else {
+		merged[ver.attribute] = dynamodb.ExpectedAttributeValue{
+			ComparisonOperator: dynamodb.EQUAL,
+			AttributeValueList: []dynamodb.AttributeValue{ver.current},
+		}
+	}
+
+	return merged, nil
+}
+
+// Returns the condition expression on the current version, for operations that use expressions.
+func (ver *versioning) condition() expression.ConditionBuilder {
+	if ver.current.IsEmpty() {
+		return expression.AttributeNotExists(expression.AttributeName(ver.attribute))
+	}
+	return expression.AttributeName(ver.attribute).Equal(expression.Value(ver.current))
+}
+
+// Sets the struct's version field to the next version after a successful save.
+func (ver *versioning) increment() {
+	switch ver.field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ver.field.SetInt(ver.next.Int())
+	default:
+		ver.field.SetUint(ver.next.Uint())
+	}
+}
+
+// Wraps a ConditionalCheckFailedException in a VersionConflictError. The exception is returned as is
+// if user conditions were merged, as DynamoDB does not report which condition failed.
+func (ver *versioning) conflict(tableName string, err error) error {
+	if !ver.conditions && dynamodb.IsErrorType(err, dynamodb.CONDITIONAL_CHECK_FAILED_EXCEPTION) {
+		return &VersionConflictError{tableName, ver.attribute, ver.current.N, err}
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package datamodeling
+
+import (
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"testing"
+)
+
+type versionedItem struct {
+	Id      string `DynamoDBHashKey:"Id"`
+	Version int64  `DynamoDBVersionAttribute:"Version"`
+}
+
+func TestVersioningNewItem(t *testing.T) {
+
+	item := &versionedItem{Id: "a"}
+	ver, err := newVersioning(item, Marshal(item))
+	if err != nil || ver == nil {
+		t.Fatalf("Expected versioning, got %v", err)
+	}
+
+	expected, _ := ver.expect("", nil)
+	if expected["Version"].ComparisonOperator != dynamodb.NULL {
+		t.Fatalf("Expected a NULL condition, got %+v", expected["Version"])
+	}
+
+	if ver.next.N != "1" {
+		t.Fatalf("Expected next version 1, got %s", ver.next.N)
+	}
+	ver.increment()
+	if item.Version != 1 {
+		t.Fatalf("Expected version 1, got %d", item.Version)
+	}
+}
+
+func TestVersioningExistingItem(t *testing.T) {
+
+	item := &versionedItem{Id: "a", Version: 7}
+	ver, _ := newVersioning(item, Marshal(item))
+
+	user := map[string]dynamodb.ExpectedAttributeValue{"Id": {ComparisonOperator: dynamodb.NOT_NULL}}
+	expected, err := ver.expect(dynamodb.AND, user)
+	if err != nil || len(expected) != 2 || len(user) != 1 {
+		t.Fatalf("Expected the user condition merged into a copy, got %+v (%v)", expected, err)
+	}
+	if c := expected["Version"]; c.ComparisonOperator != dynamodb.EQUAL || c.AttributeValueList[0].N != "7" {
+		t.Fatalf("Expected Version EQ 7, got %+v", c)
+	}
+
+	if _, err = ver.expect(dynamodb.OR, user); err == nil {
+		t.Fatal("Expected OR with other conditions to fail.")
+	}
+
+	srvErr := &testServiceError{"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException"}
+	ver.expect(dynamodb.AND, user)
+	if ver.conflict("T", srvErr) != srvErr {
+		t.Fatal("Expected the exception with user conditions.")
+	}
+	ver.expect("", nil)
+	if _, ok := ver.conflict("T", srvErr).(*VersionConflictError); !ok {
+		t.Fatal("Expected a VersionConflictError.")
+	}
+}
+
+type testServiceError struct {
+	errType string
+}
+
+func (e *testServiceError) Error() string        { return e.errType }
+func (e *testServiceError) Code() int            { return 400 }
+func (e *testServiceError) Status() string       { return "400 Bad Request" }
+func (e *testServiceError) ErrorType() string    { return e.errType }
+func (e *testServiceError) ErrorMessage() string { return "" }
+func (e *testServiceError) IsRetry() bool        { return false }
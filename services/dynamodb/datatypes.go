@@ -31,6 +31,8 @@ const (
 	LESS_THAN = "LT"
 	GREATER_THAN_EQUAL = "GE"
 	GREATER_THAN = "GT"
+	NOT_EQUAL = "NE"
+	NULL = "NULL"
 	NOT_NULL = "NOT_NULL"
 	CONTAINS = "CONTAINS"
 	NOT_CONTAINS = "NOT_CONTAINS"
@@ -46,6 +48,8 @@ const (
 	LESS_THAN          ComparisonOperator = "LT"
 	GREATER_THAN_EQUAL ComparisonOperator = "GE"
 	GREATER_THAN       ComparisonOperator = "GT"
+	NOT_EQUAL          ComparisonOperator = "NE"
+	NULL               ComparisonOperator = "NULL"
 	NOT_NULL           ComparisonOperator = "NOT_NULL"
 	CONTAINS           ComparisonOperator = "CONTAINS"
 	NOT_CONTAINS       ComparisonOperator = "NOT_CONTAINS"
@@ -145,16 +149,24 @@ const (
 	UPDATED_NEW ReturnValues = "UPDATED_NEW"
 )
 
-/*	The data type for the attribute.
+/*	The data type for the attribute. Only STRING, NUMBER and BINARY are valid key attribute types.
 	STRING = "S"
 	NUMBER = "N"
-	BINARY = "B" */
+	BINARY = "B"
+	BOOLEAN = "BOOL"
+	LIST = "L"
+	MAP = "M"
+	NULL_AT = "NULL" */
 type AttributeType string
 
 const (
-	BINARY AttributeType = "B"
-	NUMBER AttributeType = "N"
-	STRING AttributeType = "S"
+	BINARY  AttributeType = "B"
+	NUMBER  AttributeType = "N"
+	STRING  AttributeType = "S"
+	BOOLEAN AttributeType = "BOOL"
+	LIST    AttributeType = "L"
+	MAP     AttributeType = "M"
+	NULL_AT AttributeType = "NULL"
 )
 
 /*	The current state of the table or global secondary index:
@@ -189,6 +201,15 @@ type AttributeValueUpdate struct {
 	Value  *AttributeValue `json:"Value,omitempty"`
 }
 
+// An item's reason for canceling a transaction, in the order of the transaction's items.
+// The Code is "None" for items that did not cause the cancellation.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_CancellationReason.html]
+type CancellationReason struct {
+	Code    string                    `json:"Code,omitempty"`
+	Item    map[string]AttributeValue `json:"Item,omitempty"`
+	Message string                    `json:"Message,omitempty"`
+}
+
 // Represents the amount of provisioned throughput capacity consumed on a table or an index.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_Capacity.html]
 type CapacityUnits float64
@@ -200,6 +221,17 @@ type Condition struct {
 	ComparisonOperator ComparisonOperator `json:"ComparisonOperator"`
 }
 
+// Represents a condition on an item that must be true for a transaction to succeed.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_ConditionCheck.html]
+type ConditionCheck struct {
+	ConditionExpression                 string                    `json:"ConditionExpression"`
+	ExpressionAttributeNames            map[string]string         `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues           map[string]AttributeValue `json:"ExpressionAttributeValues,omitempty"`
+	Key                                 map[string]AttributeValue `json:"Key"`
+	ReturnValuesOnConditionCheckFailure ReturnValues              `json:"ReturnValuesOnConditionCheckFailure,omitempty"`
+	TableName                           string                    `json:"TableName"`
+}
+
 // Represents the capacity units consumed by an operation. The data returned includes the total provisioned throughput
 // consumed, along with statistics for the table and any indexes involved in the operation. ConsumedCapacity is only
 // returned if it was asked for in the request.
@@ -212,6 +244,23 @@ type ConsumedCapacity struct {
 	TableName              string                   `json:"TableName,omitempty"`
 }
 
+// Represents a request to delete an item within a transaction.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_Delete.html]
+type Delete struct {
+	ConditionExpression                 string                    `json:"ConditionExpression,omitempty"`
+	ExpressionAttributeNames            map[string]string         `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues           map[string]AttributeValue `json:"ExpressionAttributeValues,omitempty"`
+	Key                                 map[string]AttributeValue `json:"Key"`
+	ReturnValuesOnConditionCheckFailure ReturnValues              `json:"ReturnValuesOnConditionCheckFailure,omitempty"`
+	TableName                           string                    `json:"TableName"`
+}
+
+// Represents a global secondary index to be deleted from an existing table.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DeleteGlobalSecondaryIndexAction.html]
+type DeleteGlobalSecondaryIndexAction struct {
+	IndexName string `json:"IndexName"`
+}
+
 // Represents a request to perform a DeleteItem operation on an item.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DeleteRequest.html]
 type DeleteRequest struct {
@@ -227,6 +276,15 @@ type ExpectedAttributeValue struct {
 	Value              *AttributeValue    `json:"Value,omitempty"`
 }
 
+// Represents a request to get an item within a transaction.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_Get.html]
+type Get struct {
+	ExpressionAttributeNames map[string]string         `json:"ExpressionAttributeNames,omitempty"`
+	Key                      map[string]AttributeValue `json:"Key"`
+	ProjectionExpression     string                    `json:"ProjectionExpression,omitempty"`
+	TableName                string                    `json:"TableName"`
+}
+
 // Represents a global secondary index.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_GlobalSecondaryIndex.html]
 type GlobalSecondaryIndex struct {
@@ -248,9 +306,13 @@ type GlobalSecondaryIndexDescription struct {
 	ProvisionedThroughput *ProvisionedThroughputDescription `json:"ProvisionedThroughput,omitempty"`
 }
 
-// Represents the new provisioned throughput settings to apply to a global secondary index.
+// Represents one of the following: a new global secondary index to be added to an existing table,
+// new provisioned throughput parameters for an existing global secondary index, or an existing
+// global secondary index to be removed from an existing table.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_GlobalSecondaryIndexUpdate.html]
 type GlobalSecondaryIndexUpdate struct {
+	Create *GlobalSecondaryIndex             `json:"Create,omitempty"`
+	Delete *DeleteGlobalSecondaryIndexAction `json:"Delete,omitempty"`
 	Update *UpdateGlobalSecondaryIndexAction `json:"Update,omitempty"`
 }
 
@@ -262,6 +324,12 @@ type ItemCollectionMetrics struct {
 	SizeEstimateRangeGB []float64                 `json:"SizeEstimateRangeGB,omitempty"`
 }
 
+// An item returned by a TransactGetItems operation. The Item is empty if it was not found.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_ItemResponse.html]
+type ItemResponse struct {
+	Item map[string]AttributeValue `json:"Item,omitempty"`
+}
+
 // Represents a single element of a key schema. A key schema specifies the attributes that
 // make up the primary key of a table, or the key attributes of an index.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_KeySchemaElement.html]
@@ -273,9 +341,11 @@ type KeySchemaElement struct {
 // Represents a set of primary keys and, for each key, the attributes to retrieve from the table.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_KeysAndAttributes.html]
 type KeysAndAttributes struct {
-	AttributesToGet []string                    `json:"AttributesToGet,omitempty"`
-	ConsistentRead  bool                        `json:"ConsistentRead,omitempty"`
-	Keys            []map[string]AttributeValue `json:"Keys"`
+	AttributesToGet          []string                    `json:"AttributesToGet,omitempty"`
+	ConsistentRead           bool                        `json:"ConsistentRead,omitempty"`
+	ExpressionAttributeNames map[string]string           `json:"ExpressionAttributeNames,omitempty"`
+	Keys                     []map[string]AttributeValue `json:"Keys"`
+	ProjectionExpression     string                      `json:"ProjectionExpression,omitempty"`
 }
 
 // Represents a local secondary index.
@@ -308,8 +378,8 @@ type Projection struct {
 // The settings can be modified using the UpdateTable operation.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_ProvisionedThroughput.html]
 type ProvisionedThroughput struct {
-	ReadCapacityUnits  int64 `json:"AttributeName"`
-	WriteCapacityUnits int64 `json:"KeyType"`
+	ReadCapacityUnits  int64 `json:"ReadCapacityUnits"`
+	WriteCapacityUnits int64 `json:"WriteCapacityUnits"`
 }
 
 // Represents the provisioned throughput settings for the table, consisting of read and write capacity
@@ -319,8 +389,19 @@ type ProvisionedThroughputDescription struct {
 	LastDecreaseDateTime   *datetime.JsonDate `json:"LastDecreaseDateTime,omitempty"`
 	LastIncreaseDateTime   *datetime.JsonDate `json:"LastIncreaseDateTime,omitempty"`
 	NumberOfDecreasesToday int64              `json:"NumberOfDecreasesToday,omitempty"`
-	ReadCapacityUnits      int64              `json:"AttributeName,omitempty"`
-	WriteCapacityUnits     int64              `json:"KeyType,omitempty"`
+	ReadCapacityUnits      int64              `json:"ReadCapacityUnits,omitempty"`
+	WriteCapacityUnits     int64              `json:"WriteCapacityUnits,omitempty"`
+}
+
+// Represents a request to put an item within a transaction.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_Put.html]
+type Put struct {
+	ConditionExpression                 string                    `json:"ConditionExpression,omitempty"`
+	ExpressionAttributeNames            map[string]string         `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues           map[string]AttributeValue `json:"ExpressionAttributeValues,omitempty"`
+	Item                                map[string]AttributeValue `json:"Item"`
+	ReturnValuesOnConditionCheckFailure ReturnValues              `json:"ReturnValuesOnConditionCheckFailure,omitempty"`
+	TableName                           string                    `json:"TableName"`
 }
 
 // Represents a request to perform a PutItem operation on an item.
@@ -334,28 +415,55 @@ type PutRequest struct {
 type TableDescription struct {
 	AttributeDefinitions   []AttributeDefinition             `json:"AttributeDefinitions,omitempty"`
 	CreationDateTime       *datetime.JsonDate                `json:"CreationDateTime,omitempty"`
-	GlobalSecondaryIndexes *GlobalSecondaryIndexDescription  `json:"GlobalSecondaryIndexes,omitempty"`
+	GlobalSecondaryIndexes []GlobalSecondaryIndexDescription `json:"GlobalSecondaryIndexes,omitempty"`
 	ItemCount              int                               `json:"ItemCount,omitempty"`
 	KeySchema              []KeySchemaElement                `json:"KeySchema,omitempty"`
-	LocalSecondaryIndexes  *LocalSecondaryIndexDescription   `json:"LocalSecondaryIndexes,omitempty"`
+	LocalSecondaryIndexes  []LocalSecondaryIndexDescription  `json:"LocalSecondaryIndexes,omitempty"`
 	ProvisionedThroughput  *ProvisionedThroughputDescription `json:"ProvisionedThroughput,omitempty"`
 	TableName              string                            `json:"TableName,omitempty"`
 	TableSizeBytes         int64                             `json:"TableSizeBytes,omitempty"`
 	TableStatus            Status                            `json:"TableStatus,omitempty"`
 }
 
+// Represents an item to get within a TransactGetItems operation.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactGetItem.html]
+type TransactGetItem struct {
+	Get *Get `json:"Get"`
+}
+
+// Represents one of a Put, Update, Delete or ConditionCheck within a TransactWriteItems operation.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactWriteItem.html]
+type TransactWriteItem struct {
+	ConditionCheck *ConditionCheck `json:"ConditionCheck,omitempty"`
+	Delete         *Delete         `json:"Delete,omitempty"`
+	Put            *Put            `json:"Put,omitempty"`
+	Update         *Update         `json:"Update,omitempty"`
+}
+
+// Represents a request to update an item within a transaction.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_Update.html]
+type Update struct {
+	ConditionExpression                 string                    `json:"ConditionExpression,omitempty"`
+	ExpressionAttributeNames            map[string]string         `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues           map[string]AttributeValue `json:"ExpressionAttributeValues,omitempty"`
+	Key                                 map[string]AttributeValue `json:"Key"`
+	ReturnValuesOnConditionCheckFailure ReturnValues              `json:"ReturnValuesOnConditionCheckFailure,omitempty"`
+	TableName                           string                    `json:"TableName"`
+	UpdateExpression                    string                    `json:"UpdateExpression"`
+}
+
 // Represents the new provisioned throughput settings to be applied to a global secondary index.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_UpdateGlobalSecondaryIndexAction.html]
 type UpdateGlobalSecondaryIndexAction struct {
-	IndexName             string                            `json:"IndexName"`
-	ProvisionedThroughput *ProvisionedThroughputDescription `json:"ProvisionedThroughput"`
+	IndexName             string                 `json:"IndexName"`
+	ProvisionedThroughput *ProvisionedThroughput `json:"ProvisionedThroughput"`
 }
 
 // Represents an operation to perform - either DeleteItem or PutItem.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_WriteRequest.html]
 type WriteRequest struct {
-	DeleteRequest *DeleteRequest `json:DeleteRequest,omitempty`
-	PutRequest    *PutRequest    `json:PutRequest,omitempty`
+	DeleteRequest *DeleteRequest `json:"DeleteRequest,omitempty"`
+	PutRequest    *PutRequest    `json:"PutRequest,omitempty"`
 }
 
 /*****************************************************************************
@@ -365,17 +473,17 @@ type WriteRequest struct {
 // Represents the output of a BatchGetItem operation.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_BatchGetItemResult.html]
 type BatchGetItemResult struct {
-	ConsumedCapacity ConsumedCapacity             `json:"ConsumedCapacity,omitempty"`
-	Responses        map[string]AttributeValue    `json:"Responses,omitempty"`
-	UnprocessedKeys  map[string]KeysAndAttributes `json:"UnprocessedKeys,omitempty"`
+	ConsumedCapacity []ConsumedCapacity                     `json:"ConsumedCapacity,omitempty"`
+	Responses        map[string][]map[string]AttributeValue `json:"Responses,omitempty"`
+	UnprocessedKeys  map[string]KeysAndAttributes           `json:"UnprocessedKeys,omitempty"`
 }
 
 // Represents the output of a BatchWriteItem operation.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_BatchWriteItemResult.html]
 type BatchWriteItemResult struct {
-	ConsumedCapacity      ConsumedCapacity                 `json:"ConsumedCapacity,omitempty"`
-	ItemCollectionMetrics map[string]ItemCollectionMetrics `json:"ItemCollectionMetrics,omitempty"`
-	UnprocessedItems      map[string]WriteRequest          `json:"UnprocessedItems,omitempty"`
+	ConsumedCapacity      []ConsumedCapacity                 `json:"ConsumedCapacity,omitempty"`
+	ItemCollectionMetrics map[string][]ItemCollectionMetrics `json:"ItemCollectionMetrics,omitempty"`
+	UnprocessedItems      map[string][]WriteRequest          `json:"UnprocessedItems,omitempty"`
 }
 
 // Represents the output of a CreateTable operation.
@@ -430,20 +538,34 @@ type PutItemResult struct {
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_QueryResult.html]
 type QueryResult struct {
 	ConsumedCapacity ConsumedCapacity            `json:"ConsumedCapacity,omitempty"`
-	Count            int                         `json:"Items,omitempty"`
+	Count            int                         `json:"Count,omitempty"`
 	Items            []map[string]AttributeValue `json:"Items,omitempty"`
 	LastEvaluatedKey map[string]AttributeValue   `json:"LastEvaluatedKey,omitempty"`
-	ScannedCount     int                         `json:"Items,omitempty"`
+	ScannedCount     int                         `json:"ScannedCount,omitempty"`
 }
 
 // Represents the output of a Scan operation.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_ScanResult.html]
 type ScanResult struct {
 	ConsumedCapacity ConsumedCapacity            `json:"ConsumedCapacity,omitempty"`
-	Count            int                         `json:"Items,omitempty"`
+	Count            int                         `json:"Count,omitempty"`
 	Items            []map[string]AttributeValue `json:"Items,omitempty"`
 	LastEvaluatedKey map[string]AttributeValue   `json:"LastEvaluatedKey,omitempty"`
-	ScannedCount     int                         `json:"Items,omitempty"`
+	ScannedCount     int                         `json:"ScannedCount,omitempty"`
+}
+
+// Represents the output of a TransactGetItems operation.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactGetItems.html]
+type TransactGetItemsResult struct {
+	ConsumedCapacity []ConsumedCapacity `json:"ConsumedCapacity,omitempty"`
+	Responses        []ItemResponse     `json:"Responses,omitempty"`
+}
+
+// Represents the output of a TransactWriteItems operation.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactWriteItems.html]
+type TransactWriteItemsResult struct {
+	ConsumedCapacity      []ConsumedCapacity                 `json:"ConsumedCapacity,omitempty"`
+	ItemCollectionMetrics map[string][]ItemCollectionMetrics `json:"ItemCollectionMetrics,omitempty"`
 }
 
 // Represents the output of an UpdateItem operation.
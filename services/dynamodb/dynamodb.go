@@ -12,21 +12,35 @@
 package dynamodb
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/twhello/aws-to-go/auth"
 	"github.com/twhello/aws-to-go/interfaces"
 	"github.com/twhello/aws-to-go/regions"
 	"github.com/twhello/aws-to-go/services"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
 const ServiceName = "dynamodb"
 
+// Error types returned by DynamoDB.
+const (
+	CONDITIONAL_CHECK_FAILED_EXCEPTION        = "ConditionalCheckFailedException"
+	PROVISIONED_THROUGHPUT_EXCEEDED_EXCEPTION = "ProvisionedThroughputExceededException"
+	RESOURCE_NOT_FOUND_EXCEPTION              = "ResourceNotFoundException"
+	TRANSACTION_CANCELED_EXCEPTION            = "TransactionCanceledException"
+)
+
 // DynamoDB Service struct. Use dynamodb.NewService().
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/Welcome.html]
 type DynamoDBService struct {
 	cred     interfaces.IAWSCredentials
 	region   *regions.Region
 	endpoint string
+	governor *ThroughputGovernor
 }
 
 // Returns the name of the service.
@@ -46,29 +60,111 @@ func (db *DynamoDBService) Endpoint() string {
 
 // Low-level request to DynamoDB service.
 func (db *DynamoDBService) SignAndDo(req interfaces.IAWSRequest, dto interface{}) (resp *http.Response, err error) {
+	return db.signAndDo(req, dto, services.NewEvalJsonServiceResponse())
+}
+
+func (db *DynamoDBService) signAndDo(req interfaces.IAWSRequest, dto interface{}, eval *services.EvalServiceResponse) (resp *http.Response, err error) {
 
 	signer := auth.V4Signer{db.cred, db}
 	signer.Sign(req)
 
-	resp, err = services.DoRequest(req, dto, services.NewEvalJsonServiceResponse())
+	resp, err = services.DoRequest(req, dto, eval)
 
 	return
 }
 
+// Returns true if err is a ServiceError of the given type, e.g. CONDITIONAL_CHECK_FAILED_EXCEPTION.
+// DynamoDB prefixes error types with a namespace, e.g. "com.amazonaws.dynamodb.v20120810#".
+func IsErrorType(err error, errorType string) bool {
+	if srvErr, ok := err.(interfaces.IServiceError); ok {
+		t := srvErr.ErrorType()
+		return t == errorType || strings.HasSuffix(t, "#"+errorType)
+	}
+	return false
+}
+
+// Returned by TransactWriteItems and TransactGetItems when the transaction was canceled.
+// It is a TRANSACTION_CANCELED_EXCEPTION ServiceError.
+type TransactionCanceledError struct {
+	interfaces.IServiceError
+	CancellationReasons []CancellationReason // In the order of the transaction's items.
+}
+
+func (e *TransactionCanceledError) Error() string {
+	for i, reason := range e.CancellationReasons {
+		if reason.Code != "" && reason.Code != "None" {
+			return fmt.Sprintf("dynamodb: Transaction canceled by item %d: %s %s", i, reason.Code, reason.Message)
+		}
+	}
+	return "dynamodb: Transaction canceled: " + e.ErrorMessage()
+}
+
+// Returns the index of the first item with the reason code, e.g. "ConditionalCheckFailed", or -1.
+func (e *TransactionCanceledError) IndexOf(code string) int {
+	for i, reason := range e.CancellationReasons {
+		if reason.Code == code {
+			return i
+		}
+	}
+	return -1
+}
+
 func (db *DynamoDBService) wrapperSignAndDo(target string, request, result interface{}) (err error) {
 
+	if db.governor != nil {
+		return db.governor.govern(request, result, func(request interface{}) error {
+			return db.wrapperSignAndDoEval(target, request, result, services.NewEvalJsonServiceResponse())
+		})
+	}
+
+	return db.wrapperSignAndDoEval(target, request, result, services.NewEvalJsonServiceResponse())
+}
+
+func (db *DynamoDBService) wrapperSignAndDoEval(target string, request, result interface{}, eval *services.EvalServiceResponse) (err error) {
+
 	req, err := services.NewServerRequest("POST", db.Endpoint(), request)
 
 	if err == nil {
 		req.Header().Set("Connection", "Keep-Alive")
 		req.Header().Set("Content-Type", "application/x-amz-json-1.0")
 		req.Header().Set("X-Amz-Target", target)
-		_, err = db.SignAndDo(req, result)
+		_, err = db.signAndDo(req, result, eval)
 	}
 
 	return
 }
 
+// Signs and sends a transaction request. The CancellationReasons of a canceled transaction are
+// decoded along with the ServiceError and returned in a TransactionCanceledError.
+func (db *DynamoDBService) transactSignAndDo(target string, request, result interface{}) error {
+
+	var canceled struct {
+		CancellationReasons []CancellationReason
+	}
+
+	eval := services.NewEvalJsonServiceResponse()
+	decode := eval.Decoder
+	eval.Decoder = func(r io.Reader, v interface{}) error {
+		if _, ok := v.(*services.ServiceError); !ok {
+			return decode(r, v)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		// A retried request must not keep the reasons of an earlier error.
+		canceled.CancellationReasons = nil
+		json.Unmarshal(b, &canceled)
+		return json.Unmarshal(b, v)
+	}
+
+	err := db.wrapperSignAndDoEval(target, request, result, eval)
+	if err != nil && IsErrorType(err, TRANSACTION_CANCELED_EXCEPTION) {
+		return &TransactionCanceledError{err.(interfaces.IServiceError), canceled.CancellationReasons}
+	}
+	return err
+}
+
 // The BatchGetItem operation returns the attributes of one or more items from one or more tables.
 // You identify requested items by primary key.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_BatchGetItem.html]
@@ -185,6 +281,26 @@ func (db *DynamoDBService) Scan(sr *ScanRequest) (result *ScanResult, err error)
 	return
 }
 
+// Gets up to 25 items from one or more tables in a single, consistent transaction. The items are returned
+// in the order of the request. A canceled transaction returns a *TransactionCanceledError.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactGetItems.html]
+func (db *DynamoDBService) TransactGetItems(tgir *TransactGetItemsRequest) (result *TransactGetItemsResult, err error) {
+
+	result = new(TransactGetItemsResult)
+	err = db.transactSignAndDo("DynamoDB_20120810.TransactGetItems", tgir, result)
+	return
+}
+
+// Writes up to 25 items in one or more tables in a single, all-or-nothing transaction. A canceled transaction,
+// e.g. because a condition failed, returns a *TransactionCanceledError with the reason of each item.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactWriteItems.html]
+func (db *DynamoDBService) TransactWriteItems(twir *TransactWriteItemsRequest) (result *TransactWriteItemsResult, err error) {
+
+	result = new(TransactWriteItemsResult)
+	err = db.transactSignAndDo("DynamoDB_20120810.TransactWriteItems", twir, result)
+	return
+}
+
 // Edits an existing item's attributes, or inserts a new item if it does not already exist. You can put, delete,
 // or add attribute values. You can also perform a conditional update (insert a new attribute name-value pair if
 // it doesn't exist, or replace an existing name-value pair if it has certain expected attribute values).
@@ -212,5 +328,5 @@ func (db *DynamoDBService) UpdateTable(utr *UpdateTableRequest) (result *UpdateT
 
 // Creates a new DynamoDB Service.
 func NewService(cred interfaces.IAWSCredentials, region *regions.Region) *DynamoDBService {
-	return &DynamoDBService{cred, region, "https://" + ServiceName + "." + region.Name() + ".amazonaws.com"}
+	return &DynamoDBService{cred: cred, region: region, endpoint: "https://" + ServiceName + "." + region.Name() + ".amazonaws.com"}
 }
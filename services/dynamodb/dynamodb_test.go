@@ -0,0 +1,74 @@
+package dynamodb
+
+import (
+	"github.com/twhello/aws-to-go/auth"
+	"github.com/twhello/aws-to-go/regions"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Returns a service sending its requests to a test server that answers with the responses in turn.
+func testService(t *testing.T, statuses []int, responses []string) (*DynamoDBService, *httptest.Server) {
+
+	i := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i >= len(responses) {
+			t.Errorf("Unexpected request %d", i)
+			return
+		}
+		w.WriteHeader(statuses[i])
+		w.Write([]byte(responses[i]))
+		i++
+	}))
+
+	db := NewService(auth.NewCredentials("AKID", "SECRET"), regions.Config(regions.DEFAULT_REGION))
+	db.endpoint = server.URL
+	return db, server
+}
+
+func TestTransactionCanceled(t *testing.T) {
+
+	db, server := testService(t, []int{400}, []string{`{
+		"__type": "com.amazonaws.dynamodb.v20120810#TransactionCanceledException",
+		"message": "Transaction cancelled, please refer cancellation reasons for specific reasons [None, ConditionalCheckFailed]",
+		"CancellationReasons": [{"Code": "None"}, {"Code": "ConditionalCheckFailed", "Message": "The conditional request failed"}]
+	}`})
+	defer server.Close()
+
+	_, err := db.TransactWriteItems(NewTransactWriteItemsRequest(""))
+
+	canceled, ok := err.(*TransactionCanceledError)
+	if !ok {
+		t.Fatalf("Expected a TransactionCanceledError, was %v", err)
+	}
+	if len(canceled.CancellationReasons) != 2 || canceled.IndexOf("ConditionalCheckFailed") != 1 || canceled.IndexOf("ValidationError") != -1 {
+		t.Errorf("Unexpected reasons %v", canceled.CancellationReasons)
+	}
+	if canceled.Error() != "dynamodb: Transaction canceled by item 1: ConditionalCheckFailed The conditional request failed" {
+		t.Errorf("Unexpected message %s", canceled.Error())
+	}
+	if !IsErrorType(err, TRANSACTION_CANCELED_EXCEPTION) {
+		t.Error("Expected a TransactionCanceledException ServiceError.")
+	}
+}
+
+func TestTransactionCanceledAfterRetry(t *testing.T) {
+
+	db, server := testService(t, []int{500, 400}, []string{
+		`{"__type": "com.amazonaws.dynamodb.v20120810#InternalServerError", "CancellationReasons": [{"Code": "ConditionalCheckFailed"}]}`,
+		`{"__type": "com.amazonaws.dynamodb.v20120810#TransactionCanceledException", "message": "Transaction is ongoing"}`,
+	})
+	defer server.Close()
+
+	_, err := db.TransactGetItems(NewTransactGetItemsRequest())
+
+	canceled, ok := err.(*TransactionCanceledError)
+	if !ok {
+		t.Fatalf("Expected a TransactionCanceledError, was %v", err)
+	}
+	if len(canceled.CancellationReasons) != 0 || !strings.HasSuffix(canceled.Error(), "Transaction is ongoing") {
+		t.Errorf("Expected no reasons from the retried error, was %v", canceled.CancellationReasons)
+	}
+}
@@ -0,0 +1,268 @@
+package expression
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"strings"
+)
+
+type conditionMode int
+
+const (
+	unsetCondition conditionMode = iota
+	equalCondition
+	notEqualCondition
+	lessThanCondition
+	lessThanEqualCondition
+	greaterThanCondition
+	greaterThanEqualCondition
+	betweenCondition
+	inCondition
+	andCondition
+	orCondition
+	notCondition
+	attributeExistsCondition
+	attributeNotExistsCondition
+	attributeTypeCondition
+	beginsWithCondition
+	containsCondition
+)
+
+var comparators = map[conditionMode]string{
+	equalCondition:            " = ",
+	notEqualCondition:         " <> ",
+	lessThanCondition:         " < ",
+	lessThanEqualCondition:    " <= ",
+	greaterThanCondition:      " > ",
+	greaterThanEqualCondition: " >= ",
+}
+
+var functions = map[conditionMode]string{
+	attributeExistsCondition:    "attribute_exists",
+	attributeNotExistsCondition: "attribute_not_exists",
+	attributeTypeCondition:      "attribute_type",
+	beginsWithCondition:         "begins_with",
+	containsCondition:           "contains",
+}
+
+// A condition, filter or key condition expression. Create one from the comparison methods
+// of NameBuilder, ValueBuilder and SizeBuilder and combine them with And, Or and Not:
+//
+//	Name("a").Equal(Value(1)).And(Name("b").AttributeNotExists())
+type ConditionBuilder struct {
+	mode       conditionMode
+	operands   []OperandBuilder
+	conditions []ConditionBuilder
+}
+
+/******************************************************************************
+ * Comparisons
+ */
+
+// left = right
+func Equal(left, right OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: equalCondition, operands: []OperandBuilder{left, right}}
+}
+
+// left <> right
+func NotEqual(left, right OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: notEqualCondition, operands: []OperandBuilder{left, right}}
+}
+
+// left < right
+func LessThan(left, right OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: lessThanCondition, operands: []OperandBuilder{left, right}}
+}
+
+// left <= right
+func LessThanEqual(left, right OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: lessThanEqualCondition, operands: []OperandBuilder{left, right}}
+}
+
+// left > right
+func GreaterThan(left, right OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: greaterThanCondition, operands: []OperandBuilder{left, right}}
+}
+
+// left >= right
+func GreaterThanEqual(left, right OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: greaterThanEqualCondition, operands: []OperandBuilder{left, right}}
+}
+
+// operand BETWEEN lower AND upper
+func Between(operand, lower, upper OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: betweenCondition, operands: []OperandBuilder{operand, lower, upper}}
+}
+
+// operand IN (values...)
+func In(operand OperandBuilder, values ...OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: inCondition, operands: append([]OperandBuilder{operand}, values...)}
+}
+
+// attribute_exists (name)
+func AttributeExists(name NameBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: attributeExistsCondition, operands: []OperandBuilder{name}}
+}
+
+// attribute_not_exists (name)
+func AttributeNotExists(name NameBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: attributeNotExistsCondition, operands: []OperandBuilder{name}}
+}
+
+// attribute_type (name, type)
+func AttributeType(name NameBuilder, attributeType dynamodb.AttributeType) ConditionBuilder {
+	return ConditionBuilder{mode: attributeTypeCondition, operands: []OperandBuilder{name, Value(string(attributeType))}}
+}
+
+// begins_with (name, prefix)
+func BeginsWith(name NameBuilder, prefix string) ConditionBuilder {
+	return ConditionBuilder{mode: beginsWithCondition, operands: []OperandBuilder{name, Value(prefix)}}
+}
+
+// contains (name, value)
+func Contains(name NameBuilder, value OperandBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: containsCondition, operands: []OperandBuilder{name, value}}
+}
+
+/******************************************************************************
+ * Logical Operators
+ */
+
+// (left) AND (right) AND (others...)
+func And(left, right ConditionBuilder, others ...ConditionBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: andCondition, conditions: append([]ConditionBuilder{left, right}, others...)}
+}
+
+// (left) OR (right) OR (others...)
+func Or(left, right ConditionBuilder, others ...ConditionBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: orCondition, conditions: append([]ConditionBuilder{left, right}, others...)}
+}
+
+// NOT (condition)
+func Not(condition ConditionBuilder) ConditionBuilder {
+	return ConditionBuilder{mode: notCondition, conditions: []ConditionBuilder{condition}}
+}
+
+// (c) AND (right) AND (others...)
+func (c ConditionBuilder) And(right ConditionBuilder, others ...ConditionBuilder) ConditionBuilder {
+	return And(c, right, others...)
+}
+
+// (c) OR (right) OR (others...)
+func (c ConditionBuilder) Or(right ConditionBuilder, others ...ConditionBuilder) ConditionBuilder {
+	return Or(c, right, others...)
+}
+
+// NOT (c)
+func (c ConditionBuilder) Not() ConditionBuilder {
+	return Not(c)
+}
+
+/******************************************************************************
+ * Operand Methods
+ */
+
+func (n NameBuilder) Equal(right OperandBuilder) ConditionBuilder    { return Equal(n, right) }
+func (n NameBuilder) NotEqual(right OperandBuilder) ConditionBuilder { return NotEqual(n, right) }
+func (n NameBuilder) LessThan(right OperandBuilder) ConditionBuilder { return LessThan(n, right) }
+func (n NameBuilder) LessThanEqual(right OperandBuilder) ConditionBuilder {
+	return LessThanEqual(n, right)
+}
+func (n NameBuilder) GreaterThan(right OperandBuilder) ConditionBuilder { return GreaterThan(n, right) }
+func (n NameBuilder) GreaterThanEqual(right OperandBuilder) ConditionBuilder {
+	return GreaterThanEqual(n, right)
+}
+func (n NameBuilder) Between(lower, upper OperandBuilder) ConditionBuilder {
+	return Between(n, lower, upper)
+}
+func (n NameBuilder) In(values ...OperandBuilder) ConditionBuilder { return In(n, values...) }
+func (n NameBuilder) AttributeExists() ConditionBuilder            { return AttributeExists(n) }
+func (n NameBuilder) AttributeNotExists() ConditionBuilder         { return AttributeNotExists(n) }
+func (n NameBuilder) AttributeType(attributeType dynamodb.AttributeType) ConditionBuilder {
+	return AttributeType(n, attributeType)
+}
+func (n NameBuilder) BeginsWith(prefix string) ConditionBuilder      { return BeginsWith(n, prefix) }
+func (n NameBuilder) Contains(value OperandBuilder) ConditionBuilder { return Contains(n, value) }
+
+func (v ValueBuilder) Equal(right OperandBuilder) ConditionBuilder    { return Equal(v, right) }
+func (v ValueBuilder) NotEqual(right OperandBuilder) ConditionBuilder { return NotEqual(v, right) }
+func (v ValueBuilder) LessThan(right OperandBuilder) ConditionBuilder { return LessThan(v, right) }
+func (v ValueBuilder) LessThanEqual(right OperandBuilder) ConditionBuilder {
+	return LessThanEqual(v, right)
+}
+func (v ValueBuilder) GreaterThan(right OperandBuilder) ConditionBuilder {
+	return GreaterThan(v, right)
+}
+func (v ValueBuilder) GreaterThanEqual(right OperandBuilder) ConditionBuilder {
+	return GreaterThanEqual(v, right)
+}
+func (v ValueBuilder) Between(lower, upper OperandBuilder) ConditionBuilder {
+	return Between(v, lower, upper)
+}
+func (v ValueBuilder) In(values ...OperandBuilder) ConditionBuilder { return In(v, values...) }
+
+func (s SizeBuilder) Equal(right OperandBuilder) ConditionBuilder    { return Equal(s, right) }
+func (s SizeBuilder) NotEqual(right OperandBuilder) ConditionBuilder { return NotEqual(s, right) }
+func (s SizeBuilder) LessThan(right OperandBuilder) ConditionBuilder { return LessThan(s, right) }
+func (s SizeBuilder) LessThanEqual(right OperandBuilder) ConditionBuilder {
+	return LessThanEqual(s, right)
+}
+func (s SizeBuilder) GreaterThan(right OperandBuilder) ConditionBuilder { return GreaterThan(s, right) }
+func (s SizeBuilder) GreaterThanEqual(right OperandBuilder) ConditionBuilder {
+	return GreaterThanEqual(s, right)
+}
+func (s SizeBuilder) Between(lower, upper OperandBuilder) ConditionBuilder {
+	return Between(s, lower, upper)
+}
+func (s SizeBuilder) In(values ...OperandBuilder) ConditionBuilder { return In(s, values...) }
+
+/*****************************************************************************/
+
+func (c ConditionBuilder) build(a *aliases) (string, error) {
+
+	switch c.mode {
+	case unsetCondition:
+		return "", errors.New("expression: Empty condition.")
+
+	case andCondition, orCondition, notCondition:
+		parts := make([]string, len(c.conditions))
+		for i, cond := range c.conditions {
+			part, err := cond.build(a)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = "(" + part + ")"
+		}
+		switch c.mode {
+		case andCondition:
+			return strings.Join(parts, " AND "), nil
+		case orCondition:
+			return strings.Join(parts, " OR "), nil
+		}
+		return "NOT " + parts[0], nil
+	}
+
+	operands := make([]string, len(c.operands))
+	for i, operand := range c.operands {
+		op, err := operand.buildOperand(a)
+		if err != nil {
+			return "", err
+		}
+		operands[i] = op
+	}
+
+	switch c.mode {
+	case betweenCondition:
+		return operands[0] + " BETWEEN " + operands[1] + " AND " + operands[2], nil
+	case inCondition:
+		if len(operands) < 2 {
+			return "", errors.New("expression: IN requires at least one value.")
+		}
+		return operands[0] + " IN (" + strings.Join(operands[1:], ", ") + ")", nil
+	}
+
+	if comparator, ok := comparators[c.mode]; ok {
+		return operands[0] + comparator + operands[1], nil
+	}
+	return functions[c.mode] + " (" + strings.Join(operands, ", ") + ")", nil
+}
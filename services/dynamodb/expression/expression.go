@@ -0,0 +1,194 @@
+//
+// Builds DynamoDB condition, filter, key condition, update and projection expressions.
+//
+// Attribute names and values are replaced by generated placeholders (#n0, :v0, ...),
+// returned in Expression.Names and Expression.Values, so reserved words and any value type can be used.
+//
+//	expr, err := expression.NewBuilder().
+//	    WithKeyCondition(expression.Key("Id").Equal(expression.Value("a"))).
+//	    WithFilter(expression.Name("Count").GreaterThan(expression.Value(1))).
+//	    Build()
+//
+//	qr := dynamodb.NewQueryRequest("Table")
+//	qr.KeyConditionExpression = expr.KeyCondition
+//	qr.FilterExpression = expr.Filter
+//	qr.ExpressionAttributeNames = expr.Names
+//	qr.ExpressionAttributeValues = expr.Values
+//
+// [http://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.html]
+//
+package expression
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"strconv"
+	"strings"
+)
+
+// The built expressions and their placeholders. Unused expressions are empty.
+type Expression struct {
+	Condition    string
+	Filter       string
+	KeyCondition string
+	Projection   string
+	Update       string
+	Names        map[string]string                  // ExpressionAttributeNames. Nil if empty.
+	Values       map[string]dynamodb.AttributeValue // ExpressionAttributeValues. Nil if empty.
+}
+
+/*****************************************************************************/
+
+// Combines the expressions of a request so they share one set of placeholders. Use expression.NewBuilder().
+type Builder struct {
+	condition    *ConditionBuilder
+	filter       *ConditionBuilder
+	keyCondition *ConditionBuilder
+	projection   *ProjectionBuilder
+	update       *UpdateBuilder
+}
+
+// Creates a new Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Sets the ConditionExpression of a PutItem, UpdateItem or DeleteItem request.
+func (b *Builder) WithCondition(condition ConditionBuilder) *Builder {
+	b.condition = &condition
+	return b
+}
+
+// Sets the FilterExpression of a Query or Scan request.
+func (b *Builder) WithFilter(filter ConditionBuilder) *Builder {
+	b.filter = &filter
+	return b
+}
+
+// Sets the KeyConditionExpression of a Query request.
+func (b *Builder) WithKeyCondition(keyCondition ConditionBuilder) *Builder {
+	b.keyCondition = &keyCondition
+	return b
+}
+
+// Sets the ProjectionExpression of a GetItem, Query or Scan request.
+func (b *Builder) WithProjection(projection ProjectionBuilder) *Builder {
+	b.projection = &projection
+	return b
+}
+
+// Sets the UpdateExpression of an UpdateItem request.
+func (b *Builder) WithUpdate(update UpdateBuilder) *Builder {
+	b.update = &update
+	return b
+}
+
+// Builds the expressions.
+func (b *Builder) Build() (*Expression, error) {
+
+	a := &aliases{}
+	expr := &Expression{}
+	var err error
+
+	if b.keyCondition != nil {
+		if expr.KeyCondition, err = b.keyCondition.build(a); err != nil {
+			return nil, err
+		}
+	}
+	if b.condition != nil {
+		if expr.Condition, err = b.condition.build(a); err != nil {
+			return nil, err
+		}
+	}
+	if b.filter != nil {
+		if expr.Filter, err = b.filter.build(a); err != nil {
+			return nil, err
+		}
+	}
+	if b.projection != nil {
+		if expr.Projection, err = b.projection.build(a); err != nil {
+			return nil, err
+		}
+	}
+	if b.update != nil {
+		if expr.Update, err = b.update.build(a); err != nil {
+			return nil, err
+		}
+	}
+
+	expr.Names, expr.Values = a.names, a.values
+	return expr, nil
+}
+
+/*****************************************************************************/
+
+// The attributes to return.
+type ProjectionBuilder struct {
+	names []NameBuilder
+}
+
+// Creates a projection of the attribute names or document paths.
+func NamesList(names ...NameBuilder) ProjectionBuilder {
+	return ProjectionBuilder{names}
+}
+
+// Adds attribute names or document paths to the projection.
+func (p ProjectionBuilder) AddNames(names ...NameBuilder) ProjectionBuilder {
+	all := make([]NameBuilder, 0, len(p.names)+len(names))
+	return ProjectionBuilder{append(append(all, p.names...), names...)}
+}
+
+func (p ProjectionBuilder) build(a *aliases) (string, error) {
+
+	if len(p.names) == 0 {
+		return "", errors.New("expression: Empty projection.")
+	}
+
+	parts := make([]string, len(p.names))
+	for i, name := range p.names {
+		part, err := name.buildOperand(a)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+/*****************************************************************************/
+
+// Generates the placeholders. Each attribute name is aliased once; each value gets its own placeholder.
+type aliases struct {
+	names   map[string]string
+	byName  map[string]string
+	values  map[string]dynamodb.AttributeValue
+	nValues int
+}
+
+func (a *aliases) name(name string) string {
+
+	if alias, ok := a.byName[name]; ok {
+		return alias
+	}
+	if a.names == nil {
+		a.names = make(map[string]string)
+		a.byName = make(map[string]string)
+	}
+
+	alias := "#n" + strconv.Itoa(len(a.names))
+	a.names[alias] = name
+	a.byName[name] = alias
+	return alias
+}
+
+func (a *aliases) value(attr dynamodb.AttributeValue) string {
+
+	if a.values == nil {
+		a.values = make(map[string]dynamodb.AttributeValue)
+	}
+
+	alias := ":v" + strconv.Itoa(a.nValues)
+	a.values[alias] = attr
+	a.nValues++
+	return alias
+}
@@ -0,0 +1,100 @@
+package expression
+
+import (
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+
+	expr, err := NewBuilder().
+		WithKeyCondition(Key("Id").Equal(Value("a")).And(Key("Date").Between(Value(1), Value(2)))).
+		WithFilter(Or(Name("Name").BeginsWith("x"), Not(Name("Tags").Size().GreaterThan(Value(3))))).
+		WithProjection(NamesList(Name("Id"), Name("Name.First[0]"))).
+		Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expr.KeyCondition != "(#n0 = :v0) AND (#n1 BETWEEN :v1 AND :v2)" {
+		t.Errorf("Unexpected key condition %q", expr.KeyCondition)
+	}
+	if expr.Filter != "(begins_with (#n2, :v3)) OR (NOT (size (#n3) > :v4))" {
+		t.Errorf("Unexpected filter %q", expr.Filter)
+	}
+	if expr.Projection != "#n0, #n2.#n4[0]" {
+		t.Errorf("Unexpected projection %q", expr.Projection)
+	}
+
+	names := map[string]string{"#n0": "Id", "#n1": "Date", "#n2": "Name", "#n3": "Tags", "#n4": "First"}
+	if !reflect.DeepEqual(expr.Names, names) {
+		t.Errorf("Unexpected names %v", expr.Names)
+	}
+	if len(expr.Values) != 5 || expr.Values[":v0"].S != "a" || expr.Values[":v2"].N != "2" || expr.Values[":v3"].S != "x" {
+		t.Errorf("Unexpected values %v", expr.Values)
+	}
+}
+
+func TestBuildUpdate(t *testing.T) {
+
+	update := Set(Name("Count"), Name("Count").Plus(Value(1))).
+		Remove(Name("Old")).
+		Add(Name("Tags"), Value([]string{"a"})).
+		Set(Name("List"), ListAppend(Name("List"), Value(dynamodb.NewListAttributeValue(nil))))
+
+	expr, err := NewBuilder().
+		WithCondition(AttributeExists(Name("Count"))).
+		WithUpdate(update).
+		Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expr.Condition != "attribute_exists (#n0)" {
+		t.Errorf("Unexpected condition %q", expr.Condition)
+	}
+	if expr.Update != "SET #n0 = #n0 + :v0, #n3 = list_append(#n3, :v2) REMOVE #n1 ADD #n2 :v1" {
+		t.Errorf("Unexpected update %q", expr.Update)
+	}
+	if expr.Filter != "" || len(expr.Values[":v1"].SS) != 1 {
+		t.Errorf("Unexpected expression %+v", expr)
+	}
+}
+
+func TestBuildLiteralNames(t *testing.T) {
+
+	expr, err := NewBuilder().
+		WithKeyCondition(Key("a.b").Equal(Value("x"))).
+		WithFilter(Name("a.b").Equal(Value(""))).
+		Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expr.KeyCondition != "#n0 = :v0" || expr.Filter != "#n1.#n2 = :v1" {
+		t.Errorf("Unexpected expressions %q and %q", expr.KeyCondition, expr.Filter)
+	}
+	if names := map[string]string{"#n0": "a.b", "#n1": "a", "#n2": "b"}; !reflect.DeepEqual(expr.Names, names) {
+		t.Errorf("Unexpected names %v", expr.Names)
+	}
+	if b, err := expr.Values[":v1"].MarshalJSON(); err != nil || string(b) != `{"S":""}` {
+		t.Errorf("Expected the empty string value, got %s (%v)", b, err)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+
+	if _, err := NewBuilder().WithFilter(ConditionBuilder{}).Build(); err == nil {
+		t.Error("Expected an error for an empty condition.")
+	}
+	if _, err := NewBuilder().WithFilter(Name("a").Equal(Value(struct{}{}))).Build(); err == nil {
+		t.Error("Expected an error for an unsupported value.")
+	}
+	if _, err := NewBuilder().WithFilter(Name("a").In()).Build(); err == nil {
+		t.Error("Expected an error for an empty IN.")
+	}
+	if expr, err := NewBuilder().Build(); err != nil || expr.Names != nil || expr.Values != nil {
+		t.Errorf("Expected an empty expression, got %+v (%v)", expr, err)
+	}
+}
@@ -0,0 +1,229 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"strings"
+)
+
+// An operand of a condition or update expression: a NameBuilder, ValueBuilder, SizeBuilder
+// or SetValueBuilder.
+type OperandBuilder interface {
+	buildOperand(a *aliases) (string, error)
+}
+
+/*****************************************************************************/
+
+// An attribute name or document path, e.g. "a", "a.b" or "a[0].b".
+type NameBuilder struct {
+	path    string
+	literal bool // The path is a single attribute name, even if it contains "." or "[".
+}
+
+// Creates a NameBuilder for the attribute name or document path.
+// Each element of the path is replaced by a placeholder, so reserved words can be used.
+func Name(path string) NameBuilder {
+	return NameBuilder{path, false}
+}
+
+// Creates a NameBuilder for a key attribute of a key condition. The name is replaced by a single
+// placeholder, as key attributes are top-level attributes.
+func Key(name string) NameBuilder {
+	return NameBuilder{name, true}
+}
+
+// Creates a NameBuilder for a top-level attribute name, e.g. the attribute of a struct field.
+// The name is replaced by a single placeholder, even if it contains "." or "[".
+func AttributeName(name string) NameBuilder {
+	return NameBuilder{name, true}
+}
+
+func (n NameBuilder) buildOperand(a *aliases) (string, error) {
+
+	if n.path == "" {
+		return "", errors.New("expression: Empty attribute name.")
+	}
+	if n.literal {
+		return a.name(n.path), nil
+	}
+
+	parts := strings.Split(n.path, ".")
+	for i, part := range parts {
+		name, index := part, ""
+		if j := strings.Index(part, "["); j >= 0 {
+			name, index = part[:j], part[j:]
+		}
+		if name == "" {
+			return "", errors.New("expression: Invalid document path " + n.path)
+		}
+		parts[i] = a.name(name) + index
+	}
+
+	return strings.Join(parts, "."), nil
+}
+
+// Creates a size function of the attribute, e.g. Name("a").Size().GreaterThan(Value(3)).
+func (n NameBuilder) Size() SizeBuilder {
+	return SizeBuilder{n}
+}
+
+// Adds the right operand to the attribute in a SET clause, e.g. Set(Name("a"), Name("a").Plus(Value(1))).
+func (n NameBuilder) Plus(right OperandBuilder) SetValueBuilder {
+	return SetValueBuilder{plusValue, []OperandBuilder{n, right}}
+}
+
+// Subtracts the right operand from the attribute in a SET clause.
+func (n NameBuilder) Minus(right OperandBuilder) SetValueBuilder {
+	return SetValueBuilder{minusValue, []OperandBuilder{n, right}}
+}
+
+// Returns the attribute's value in a SET clause, or the operand if the attribute does not exist.
+func (n NameBuilder) IfNotExists(right OperandBuilder) SetValueBuilder {
+	return SetValueBuilder{ifNotExistsValue, []OperandBuilder{n, right}}
+}
+
+/*****************************************************************************/
+
+// A value, replaced by a placeholder in the expression.
+type ValueBuilder struct {
+	value interface{}
+}
+
+// Creates a ValueBuilder. The value can be a dynamodb.AttributeValue, string, []byte, bool,
+// a number, nil, or a []string, []int, []int64, []float64 or [][]byte, which become sets.
+// Use a dynamodb.AttributeValue for lists and maps.
+func Value(value interface{}) ValueBuilder {
+	return ValueBuilder{value}
+}
+
+func (v ValueBuilder) buildOperand(a *aliases) (string, error) {
+
+	attr, err := attributeValueOf(v.value)
+	if err != nil {
+		return "", err
+	}
+	return a.value(attr), nil
+}
+
+// Returns the Go value as an AttributeValue.
+func attributeValueOf(v interface{}) (dynamodb.AttributeValue, error) {
+
+	switch val := v.(type) {
+	case dynamodb.AttributeValue:
+		return val, nil
+	case *dynamodb.AttributeValue:
+		return *val, nil
+	case nil:
+		return dynamodb.NewNullAttributeValue(), nil
+	case string:
+		if val == "" {
+			return dynamodb.NewEmptyStringAttributeValue(), nil
+		}
+		return dynamodb.NewAttributeValue(val), nil
+	case []byte:
+		return dynamodb.NewBinaryAttributeValue(val), nil
+	case bool:
+		return dynamodb.NewBoolAttributeValue(val), nil
+	case int:
+		return dynamodb.NewIntAttributeValue(int64(val)), nil
+	case int8:
+		return dynamodb.NewIntAttributeValue(int64(val)), nil
+	case int16:
+		return dynamodb.NewIntAttributeValue(int64(val)), nil
+	case int32:
+		return dynamodb.NewIntAttributeValue(int64(val)), nil
+	case int64:
+		return dynamodb.NewIntAttributeValue(val), nil
+	case uint:
+		return dynamodb.NewUintAttributeValue(uint64(val)), nil
+	case uint8:
+		return dynamodb.NewUintAttributeValue(uint64(val)), nil
+	case uint16:
+		return dynamodb.NewUintAttributeValue(uint64(val)), nil
+	case uint32:
+		return dynamodb.NewUintAttributeValue(uint64(val)), nil
+	case uint64:
+		return dynamodb.NewUintAttributeValue(val), nil
+	case float32:
+		return dynamodb.NewFloatAttributeValue(float64(val)), nil
+	case float64:
+		return dynamodb.NewFloatAttributeValue(val), nil
+	case []string:
+		return dynamodb.NewAttributeSet(val), nil
+	case []int:
+		set := make([]int64, len(val))
+		for i, n := range val {
+			set[i] = int64(n)
+		}
+		return dynamodb.NewIntAttributeSet(set), nil
+	case []int64:
+		return dynamodb.NewIntAttributeSet(val), nil
+	case []float64:
+		return dynamodb.NewFloatAttributeSet(val), nil
+	case [][]byte:
+		return dynamodb.NewBinaryAttributeSet(val), nil
+	}
+
+	return dynamodb.AttributeValue{}, fmt.Errorf("expression: Unsupported value type %T.", v)
+}
+
+/*****************************************************************************/
+
+// The size function of an attribute.
+type SizeBuilder struct {
+	name NameBuilder
+}
+
+func (s SizeBuilder) buildOperand(a *aliases) (string, error) {
+
+	name, err := s.name.buildOperand(a)
+	if err != nil {
+		return "", err
+	}
+	return "size (" + name + ")", nil
+}
+
+/*****************************************************************************/
+
+type setValueMode int
+
+const (
+	plusValue setValueMode = iota
+	minusValue
+	listAppendValue
+	ifNotExistsValue
+)
+
+// An arithmetic or function operand of a SET clause.
+type SetValueBuilder struct {
+	mode     setValueMode
+	operands []OperandBuilder
+}
+
+// Concatenates two lists in a SET clause, e.g. Set(Name("l"), ListAppend(Name("l"), Value(list))).
+func ListAppend(left, right OperandBuilder) SetValueBuilder {
+	return SetValueBuilder{listAppendValue, []OperandBuilder{left, right}}
+}
+
+func (s SetValueBuilder) buildOperand(a *aliases) (string, error) {
+
+	left, err := s.operands[0].buildOperand(a)
+	if err != nil {
+		return "", err
+	}
+	right, err := s.operands[1].buildOperand(a)
+	if err != nil {
+		return "", err
+	}
+
+	switch s.mode {
+	case plusValue:
+		return left + " + " + right, nil
+	case minusValue:
+		return left + " - " + right, nil
+	case listAppendValue:
+		return "list_append(" + left + ", " + right + ")", nil
+	}
+	return "if_not_exists(" + left + ", " + right + ")", nil
+}
@@ -0,0 +1,119 @@
+package expression
+
+import (
+	"errors"
+	"strings"
+)
+
+type updateMode int
+
+const (
+	setUpdate updateMode = iota
+	removeUpdate
+	addUpdate
+	deleteUpdate
+)
+
+var updateClauses = []string{"SET", "REMOVE", "ADD", "DELETE"}
+
+type updateAction struct {
+	mode    updateMode
+	name    NameBuilder
+	operand OperandBuilder
+}
+
+// An update expression of SET, REMOVE, ADD and DELETE clauses:
+//
+//	Set(Name("a"), Value(1)).Add(Name("count"), Value(1)).Remove(Name("b"))
+type UpdateBuilder struct {
+	actions []updateAction
+}
+
+// SET name = operand
+func Set(name NameBuilder, operand OperandBuilder) UpdateBuilder {
+	return UpdateBuilder{}.Set(name, operand)
+}
+
+// REMOVE name
+func Remove(name NameBuilder) UpdateBuilder {
+	return UpdateBuilder{}.Remove(name)
+}
+
+// ADD name value. Adds to a number or adds elements to a set.
+func Add(name NameBuilder, value ValueBuilder) UpdateBuilder {
+	return UpdateBuilder{}.Add(name, value)
+}
+
+// DELETE name value. Removes elements from a set.
+func Delete(name NameBuilder, value ValueBuilder) UpdateBuilder {
+	return UpdateBuilder{}.Delete(name, value)
+}
+
+// SET name = operand
+func (u UpdateBuilder) Set(name NameBuilder, operand OperandBuilder) UpdateBuilder {
+	return u.with(updateAction{setUpdate, name, operand})
+}
+
+// REMOVE name
+func (u UpdateBuilder) Remove(name NameBuilder) UpdateBuilder {
+	return u.with(updateAction{removeUpdate, name, nil})
+}
+
+// ADD name value. Adds to a number or adds elements to a set.
+func (u UpdateBuilder) Add(name NameBuilder, value ValueBuilder) UpdateBuilder {
+	return u.with(updateAction{addUpdate, name, value})
+}
+
+// DELETE name value. Removes elements from a set.
+func (u UpdateBuilder) Delete(name NameBuilder, value ValueBuilder) UpdateBuilder {
+	return u.with(updateAction{deleteUpdate, name, value})
+}
+
+// Copies the actions, so builders derived from the same UpdateBuilder do not share them.
+func (u UpdateBuilder) with(action updateAction) UpdateBuilder {
+	actions := make([]updateAction, len(u.actions), len(u.actions)+1)
+	copy(actions, u.actions)
+	return UpdateBuilder{append(actions, action)}
+}
+
+func (u UpdateBuilder) build(a *aliases) (string, error) {
+
+	if len(u.actions) == 0 {
+		return "", errors.New("expression: Empty update.")
+	}
+
+	clauses := make([][]string, len(updateClauses))
+
+	for _, action := range u.actions {
+
+		name, err := action.name.buildOperand(a)
+		if err != nil {
+			return "", err
+		}
+
+		if action.mode == removeUpdate {
+			clauses[action.mode] = append(clauses[action.mode], name)
+			continue
+		}
+
+		operand, err := action.operand.buildOperand(a)
+		if err != nil {
+			return "", err
+		}
+
+		if action.mode == setUpdate {
+			clauses[action.mode] = append(clauses[action.mode], name+" = "+operand)
+		} else {
+			clauses[action.mode] = append(clauses[action.mode], name+" "+operand)
+		}
+	}
+
+	var parts []string
+	for mode, clause := range clauses {
+		if len(clause) > 0 {
+			parts = append(parts, updateClauses[mode]+" "+strings.Join(clause, ", "))
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
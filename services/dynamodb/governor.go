@@ -0,0 +1,355 @@
+package dynamodb
+
+import (
+	"github.com/twhello/aws-to-go/services"
+	"github.com/twhello/aws-to-go/util/ratelimiter"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_TARGET_PERCENT   = 80 // The default percentage of the provisioned capacity a ThroughputGovernor targets.
+	DEFAULT_GOVERNOR_RETRIES = 8  // The default number of retries of a throttled request.
+)
+
+// Error types returned by DynamoDB when requests are throttled.
+var throttlingErrors = []string{PROVISIONED_THROUGHPUT_EXCEEDED_EXCEPTION, "ThrottlingException", "RequestLimitExceeded"}
+
+// Governs the read and write throughput of a DynamoDBService per table, to stay below a percentage of the
+// tables' provisioned capacity. Requests are sent with ReturnConsumedCapacity TOTAL, and the consumed capacity is
+// taken from per-table read and write token buckets owned by the governor, which block the next requests.
+// A throttled request halves the table's rate and is retried with an exponential backoff; the rate then recovers
+// towards the target. Unprocessed batch items also halve the rate.
+//
+// GetItem, BatchGetItem, Query and Scan are governed as reads; PutItem, UpdateItem, DeleteItem and
+// BatchWriteItem as writes. Tables with on-demand capacity are not governed.
+// Use dynamodb.NewThroughputGovernor() and DynamoDBService.SetThroughputGovernor().
+type ThroughputGovernor struct {
+	describe      func(tableName string) (*DescribeTableResult, error)
+	targetPercent float64
+	maxRetries    uint
+	mutex         sync.Mutex
+	tables        map[string]*tableThroughput
+}
+
+type tableThroughput struct {
+	read  *throughputBucket // Nil if the table's reads are not governed.
+	write *throughputBucket // Nil if the table's writes are not governed.
+}
+
+type throughputBucket struct {
+	limiter  *ratelimiter.RateLimiter
+	target   float64
+	rate     float64
+	adjusted time.Time
+	mutex    sync.Mutex
+}
+
+// Creates a new ThroughputGovernor.
+// (service *DynamoDBService) The service that describes the tables' provisioned capacity.
+// (targetPercent uint) The percentage of the provisioned capacity to target, e.g. DEFAULT_TARGET_PERCENT.
+func NewThroughputGovernor(service *DynamoDBService, targetPercent uint) *ThroughputGovernor {
+	return newThroughputGovernor(func(tableName string) (*DescribeTableResult, error) {
+		return service.DescribeTable(NewDescribeTableRequest(tableName))
+	}, targetPercent)
+}
+
+func newThroughputGovernor(describe func(string) (*DescribeTableResult, error), targetPercent uint) *ThroughputGovernor {
+
+	if targetPercent == 0 {
+		targetPercent = DEFAULT_TARGET_PERCENT
+	}
+
+	return &ThroughputGovernor{
+		describe:      describe,
+		targetPercent: float64(targetPercent),
+		maxRetries:    DEFAULT_GOVERNOR_RETRIES,
+		tables:        make(map[string]*tableThroughput),
+	}
+}
+
+// Sets the number of retries of a throttled request. Defaults to DEFAULT_GOVERNOR_RETRIES.
+func (g *ThroughputGovernor) SetMaxRetries(maxRetries uint) {
+	g.maxRetries = maxRetries
+}
+
+// Returns the current read and write rates of the table in capacity units per second,
+// or 0 if they are not governed or the table has not been used yet.
+func (g *ThroughputGovernor) Rates(tableName string) (read, write float64) {
+
+	g.mutex.Lock()
+	table := g.tables[tableName]
+	g.mutex.Unlock()
+
+	if table != nil {
+		read, write = table.read.currentRate(), table.write.currentRate()
+	}
+	return
+}
+
+// Governs the throughput of the DynamoDBService's data requests. A nil governor removes it.
+func (db *DynamoDBService) SetThroughputGovernor(governor *ThroughputGovernor) {
+	db.governor = governor
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+// Sends the request with send, which decodes into result, within the throughput of its tables.
+// A governed request is sent as a copy with ReturnConsumedCapacity TOTAL.
+func (g *ThroughputGovernor) govern(request, result interface{}, send func(request interface{}) error) error {
+
+	governed, tableNames, isWrite := governedRequest(request)
+	if len(tableNames) == 0 {
+		return send(request)
+	}
+
+	buckets := make(map[string]*throughputBucket, len(tableNames))
+	for _, tableName := range tableNames {
+		table, err := g.table(tableName)
+		if err != nil {
+			return err
+		}
+		if bucket := table.bucket(isWrite); bucket != nil {
+			buckets[tableName] = bucket
+			bucket.limiter.Aquire(1)
+		}
+	}
+
+	for retries := uint(0); ; retries++ {
+
+		err := send(governed)
+		if err == nil {
+			break
+		}
+		if !isThrottled(err) || retries >= g.maxRetries {
+			return err
+		}
+
+		for _, bucket := range buckets {
+			bucket.throttled()
+		}
+		time.Sleep(services.ExponentialBackoff(50*time.Millisecond, retries))
+	}
+
+	// One unit of each table was acquired before the request.
+	for tableName, units := range consumedCapacity(tableNames, result) {
+		if bucket, ok := buckets[tableName]; ok {
+			if permits := int(math.Ceil(units)) - 1; permits > 0 {
+				bucket.limiter.Aquire(permits)
+			}
+		}
+	}
+
+	unprocessed := unprocessedTables(result)
+	for tableName, bucket := range buckets {
+		if unprocessed[tableName] {
+			bucket.throttled()
+		} else {
+			bucket.succeeded()
+		}
+	}
+
+	return nil
+}
+
+// Returns the throughput of the table, describing the table on its first use.
+func (g *ThroughputGovernor) table(tableName string) (*tableThroughput, error) {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if table, ok := g.tables[tableName]; ok {
+		return table, nil
+	}
+
+	dtr, err := g.describe(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &tableThroughput{}
+	if pt := dtr.Table.ProvisionedThroughput; pt != nil {
+		table.read = newThroughputBucket(float64(pt.ReadCapacityUnits) * g.targetPercent / 100)
+		table.write = newThroughputBucket(float64(pt.WriteCapacityUnits) * g.targetPercent / 100)
+	}
+
+	g.tables[tableName] = table
+	return table, nil
+}
+
+func (t *tableThroughput) bucket(isWrite bool) *throughputBucket {
+	if isWrite {
+		return t.write
+	}
+	return t.read
+}
+
+// Creates a bucket at the target rate, or returns nil for an on-demand table without provisioned capacity.
+// The limiter is not shared, so that governors of the same table adjust their rates independently.
+func newThroughputBucket(target float64) *throughputBucket {
+
+	if target <= 0 {
+		return nil
+	}
+	target = math.Max(target, 1)
+
+	return &throughputBucket{limiter: ratelimiter.New(uint32(target)), target: target, rate: target}
+}
+
+// Halves the rate, down to one unit per second.
+func (b *throughputBucket) throttled() {
+	b.mutex.Lock()
+	b.setRate(math.Max(1, b.rate/2))
+	b.mutex.Unlock()
+}
+
+// Raises the rate by a tenth of the target, at most once per second, until it reaches the target.
+func (b *throughputBucket) succeeded() {
+	b.mutex.Lock()
+	if b.rate < b.target && time.Since(b.adjusted) >= time.Second {
+		b.setRate(math.Min(b.target, b.rate+math.Max(1, b.target/10)))
+	}
+	b.mutex.Unlock()
+}
+
+func (b *throughputBucket) setRate(rate float64) {
+	b.rate = rate
+	b.adjusted = time.Now()
+	b.limiter.SetRate(uint32(rate))
+}
+
+func (b *throughputBucket) currentRate() float64 {
+	if b == nil {
+		return 0
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.rate
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+// Returns a copy of a governed request with its ReturnConsumedCapacity set to TOTAL, its tables, and whether it writes.
+// The request itself is returned, with no tables, if it is not governed.
+func governedRequest(request interface{}) (governed interface{}, tableNames []string, isWrite bool) {
+
+	total := func(rcc ReturnConsumedCapacity) ReturnConsumedCapacity {
+		if rcc == "" || rcc == NONE_RCC {
+			return TOTAL
+		}
+		return rcc
+	}
+
+	switch r := request.(type) {
+	case *GetItemRequest:
+		c := *r
+		c.ReturnConsumedCapacity = string(total(ReturnConsumedCapacity(r.ReturnConsumedCapacity)))
+		return &c, []string{r.TableName}, false
+	case *QueryRequest:
+		c := *r
+		c.ReturnConsumedCapacity = total(r.ReturnConsumedCapacity)
+		return &c, []string{r.TableName}, false
+	case *ScanRequest:
+		c := *r
+		c.ReturnConsumedCapacity = total(r.ReturnConsumedCapacity)
+		return &c, []string{r.TableName}, false
+	case *BatchGetItemRequest:
+		c := *r
+		c.ReturnConsumedCapacity = total(r.ReturnConsumedCapacity)
+		for tableName := range r.RequestItems {
+			tableNames = append(tableNames, tableName)
+		}
+		return &c, tableNames, false
+	case *PutItemRequest:
+		c := *r
+		c.ReturnConsumedCapacity = total(r.ReturnConsumedCapacity)
+		return &c, []string{r.TableName}, true
+	case *UpdateItemRequest:
+		c := *r
+		c.ReturnConsumedCapacity = total(r.ReturnConsumedCapacity)
+		return &c, []string{r.TableName}, true
+	case *DeleteItemRequest:
+		c := *r
+		c.ReturnConsumedCapacity = total(r.ReturnConsumedCapacity)
+		return &c, []string{r.TableName}, true
+	case *BatchWriteItemRequest:
+		c := *r
+		c.ReturnConsumedCapacity = total(r.ReturnConsumedCapacity)
+		for tableName := range r.RequestItems {
+			tableNames = append(tableNames, tableName)
+		}
+		return &c, tableNames, true
+	}
+
+	return request, nil, false
+}
+
+// Returns the capacity units consumed per table by a governed request of the tables.
+func consumedCapacity(tableNames []string, result interface{}) map[string]float64 {
+
+	var consumed []ConsumedCapacity
+
+	switch r := result.(type) {
+	case *GetItemResult:
+		consumed = []ConsumedCapacity{r.ConsumedCapacity}
+	case *QueryResult:
+		consumed = []ConsumedCapacity{r.ConsumedCapacity}
+	case *ScanResult:
+		consumed = []ConsumedCapacity{r.ConsumedCapacity}
+	case *PutItemResult:
+		consumed = []ConsumedCapacity{r.ConsumedCapacity}
+	case *UpdateItemResult:
+		consumed = []ConsumedCapacity{r.ConsumedCapacity}
+	case *DeleteItemResult:
+		consumed = []ConsumedCapacity{r.ConsumedCapacity}
+	case *BatchGetItemResult:
+		consumed = r.ConsumedCapacity
+	case *BatchWriteItemResult:
+		consumed = r.ConsumedCapacity
+	}
+
+	units := make(map[string]float64, len(consumed))
+
+	for _, cc := range consumed {
+		tableName := cc.TableName
+		if tableName == "" && len(tableNames) == 1 {
+			tableName = tableNames[0]
+		}
+		units[tableName] += float64(cc.CapacityUnits)
+	}
+
+	return units
+}
+
+// Returns the tables with unprocessed items or keys in a batch result.
+func unprocessedTables(result interface{}) map[string]bool {
+
+	tables := make(map[string]bool)
+
+	switch r := result.(type) {
+	case *BatchGetItemResult:
+		for tableName := range r.UnprocessedKeys {
+			tables[tableName] = true
+		}
+	case *BatchWriteItemResult:
+		for tableName := range r.UnprocessedItems {
+			tables[tableName] = true
+		}
+	}
+
+	return tables
+}
+
+func isThrottled(err error) bool {
+	for _, errorType := range throttlingErrors {
+		if IsErrorType(err, errorType) {
+			return true
+		}
+	}
+	return false
+}
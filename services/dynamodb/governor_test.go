@@ -0,0 +1,154 @@
+package dynamodb
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/services"
+	"testing"
+	"time"
+)
+
+var errThrottled = services.NewServiceError(400, "400 Bad Request", "com.amazonaws.dynamodb.v20120810#"+PROVISIONED_THROUGHPUT_EXCEEDED_EXCEPTION, "")
+
+func testGovernor(read, write int64) *ThroughputGovernor {
+	return newThroughputGovernor(func(tableName string) (*DescribeTableResult, error) {
+		if tableName == "missing" {
+			return nil, errors.New("missing")
+		}
+		result := &DescribeTableResult{}
+		if read > 0 || write > 0 {
+			result.Table.ProvisionedThroughput = &ProvisionedThroughputDescription{ReadCapacityUnits: read, WriteCapacityUnits: write}
+		}
+		return result, nil
+	}, 50)
+}
+
+func TestGovernorSetsReturnConsumedCapacity(t *testing.T) {
+
+	g := testGovernor(100, 40)
+
+	pir := NewPutItemRequest("Orders")
+	result := &PutItemResult{}
+	var sent *PutItemRequest
+	err := g.govern(pir, result, func(request interface{}) error {
+		sent = request.(*PutItemRequest)
+		result.ConsumedCapacity = ConsumedCapacity{CapacityUnits: 3, TableName: "Orders"}
+		return nil
+	})
+
+	if err != nil || sent.ReturnConsumedCapacity != TOTAL {
+		t.Errorf("Expected TOTAL, got %q (%v)", sent.ReturnConsumedCapacity, err)
+	}
+	if pir.ReturnConsumedCapacity != "" {
+		t.Errorf("Expected the caller's request to be unchanged, got %q", pir.ReturnConsumedCapacity)
+	}
+	if read, write := g.Rates("Orders"); read != 50 || write != 20 {
+		t.Errorf("Expected 50%% of the provisioned capacity, got %v %v", read, write)
+	}
+
+	gir := NewGetItemRequest("Orders")
+	gir.ReturnConsumedCapacity = string(INDEXES)
+	g.govern(gir, &GetItemResult{}, func(request interface{}) error {
+		if rcc := request.(*GetItemRequest).ReturnConsumedCapacity; rcc != string(INDEXES) {
+			t.Errorf("Expected INDEXES to be kept, got %q", rcc)
+		}
+		return nil
+	})
+}
+
+func TestGovernorBacksOffWhenThrottled(t *testing.T) {
+
+	g := testGovernor(100, 40)
+	g.SetMaxRetries(2)
+
+	calls := 0
+	err := g.govern(NewGetItemRequest("Orders"), &GetItemResult{}, func(interface{}) error {
+		if calls++; calls < 3 {
+			return errThrottled
+		}
+		return nil
+	})
+
+	if err != nil || calls != 3 {
+		t.Errorf("Expected 3 calls, got %d (%v)", calls, err)
+	}
+	if read, write := g.Rates("Orders"); read != 12.5 || write != 20 {
+		t.Errorf("Expected the read rate to be halved twice, got %v %v", read, write)
+	}
+
+	calls = 0
+	err = g.govern(NewGetItemRequest("Orders"), &GetItemResult{}, func(interface{}) error {
+		calls++
+		return errThrottled
+	})
+	if !isThrottled(err) || calls != 3 {
+		t.Errorf("Expected the throttling error after 3 calls, got %d (%v)", calls, err)
+	}
+
+	b := g.tables["Orders"].read
+	b.adjusted = b.adjusted.Add(-2 * time.Second)
+	b.succeeded()
+	if rate := b.currentRate(); rate != 8.125 {
+		t.Errorf("Expected the rate to recover, got %v", rate)
+	}
+}
+
+func TestGovernorsAreIndependent(t *testing.T) {
+
+	g1, g2 := testGovernor(100, 40), testGovernor(100, 40)
+	throttled := false
+	g1.govern(NewGetItemRequest("Orders"), &GetItemResult{}, func(interface{}) error {
+		if !throttled {
+			throttled = true
+			return errThrottled
+		}
+		return nil
+	})
+	g2.govern(NewGetItemRequest("Orders"), &GetItemResult{}, func(interface{}) error { return nil })
+
+	if l1, l2 := g1.tables["Orders"].read.limiter, g2.tables["Orders"].read.limiter; l1.Rate() != 25 || l2.Rate() != 50 {
+		t.Errorf("Expected the limiters of the table to have their own rates, got %d and %d", l1.Rate(), l2.Rate())
+	}
+}
+
+func TestGovernorUnprocessedItems(t *testing.T) {
+
+	g := testGovernor(10, 20)
+
+	bwir := NewBatchWriteItemRequest(map[string][]WriteRequest{"A": nil, "B": nil})
+	result := &BatchWriteItemResult{}
+	var sent *BatchWriteItemRequest
+	err := g.govern(bwir, result, func(request interface{}) error {
+		sent = request.(*BatchWriteItemRequest)
+		result.ConsumedCapacity = []ConsumedCapacity{{CapacityUnits: 1, TableName: "A"}, {CapacityUnits: 1, TableName: "B"}}
+		result.UnprocessedItems = map[string][]WriteRequest{"B": {{}}}
+		return nil
+	})
+
+	if err != nil || sent.ReturnConsumedCapacity != TOTAL || len(sent.RequestItems) != 2 {
+		t.Fatalf("Unexpected request %+v (%v)", sent, err)
+	}
+	if _, write := g.Rates("A"); write != 10 {
+		t.Errorf("Expected A to stay at 10, got %v", write)
+	}
+	if _, write := g.Rates("B"); write != 5 {
+		t.Errorf("Expected B to be halved to 5, got %v", write)
+	}
+}
+
+func TestGovernorPassThrough(t *testing.T) {
+
+	g := testGovernor(0, 0)
+
+	calls := 0
+	send := func(interface{}) error { calls++; return nil }
+
+	g.govern(NewQueryRequest("OnDemand"), &QueryResult{}, send)
+	g.govern(NewDescribeTableRequest("missing"), &DescribeTableResult{}, send)
+	if read, write := g.Rates("OnDemand"); calls != 2 || read != 0 || write != 0 {
+		t.Errorf("Expected ungoverned calls, got %d %v %v", calls, read, write)
+	}
+
+	if err := g.govern(NewScanRequest("missing"), &ScanResult{}, send); err == nil || calls != 2 {
+		t.Errorf("Expected the describe error, got %v", err)
+	}
+}
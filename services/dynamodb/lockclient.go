@@ -0,0 +1,327 @@
+package dynamodb
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/util/uuid"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	LOCK_KEY_ATTRIBUTE     = "LockKey"             // The hash key of a lock table, of type STRING.
+	LOCK_OWNER_ATTRIBUTE   = "Owner"               // The owner of the lock.
+	LOCK_VERSION_ATTRIBUTE = "RecordVersionNumber" // Changed by every heartbeat of the owner.
+	LOCK_LEASE_ATTRIBUTE   = "LeaseDuration"       // The lease duration of the owner in milliseconds.
+
+	DEFAULT_LEASE_DURATION   = 20 * time.Second // The default lease duration of a LockClient.
+	DEFAULT_HEARTBEAT_PERIOD = 5 * time.Second  // The default heartbeat period of a LockClient.
+)
+
+var (
+	ErrLockNotGranted = errors.New("dynamodb: Lock not granted.")
+	ErrLockLost       = errors.New("dynamodb: Lock lost.")
+)
+
+// The item operations used by a LockClient.
+type lockAPI interface {
+	DeleteItem(*DeleteItemRequest) (*DeleteItemResult, error)
+	GetItem(*GetItemRequest) (*GetItemResult, error)
+	PutItem(*PutItemRequest) (*PutItemResult, error)
+	UpdateItem(*UpdateItemRequest) (*UpdateItemResult, error)
+}
+
+// A client of named locks stored in a DynamoDB table, e.g. for leader election or exclusive jobs.
+// A lock is acquired with a conditional PutItem and its lease is held by heartbeats, which change the
+// lock's record version number with a conditional UpdateItem. A lock whose record version number has not
+// changed for its lease duration is stale and can be acquired by another owner. As clocks are not compared,
+// a stale lock is only detected by an AcquireLock waiting at least the lease duration.
+// Use dynamodb.NewLockClient() and dynamodb.NewLockTableRequest().
+type LockClient struct {
+	api             lockAPI
+	tableName       string
+	owner           string
+	leaseDuration   time.Duration
+	heartbeatPeriod time.Duration
+}
+
+// Creates a new LockClient.
+// (service *DynamoDBService) The service of the lock table.
+// (tableName string) The name of a table with the hash key LOCK_KEY_ATTRIBUTE.
+func NewLockClient(service *DynamoDBService, tableName string) *LockClient {
+	return newLockClient(service, tableName)
+}
+
+func newLockClient(api lockAPI, tableName string) *LockClient {
+
+	owner, _ := os.Hostname()
+
+	return &LockClient{
+		api:             api,
+		tableName:       tableName,
+		owner:           owner + "/" + newRecordVersionNumber(),
+		leaseDuration:   DEFAULT_LEASE_DURATION,
+		heartbeatPeriod: DEFAULT_HEARTBEAT_PERIOD,
+	}
+}
+
+// Creates a CreateTableRequest of a lock table.
+// (tableName string) The name of the table to create.
+// (readCapacityUnits int64) The read capacity of the table.
+// (writeCapacityUnits int64) The write capacity of the table.
+func NewLockTableRequest(tableName string, readCapacityUnits int64, writeCapacityUnits int64) *CreateTableRequest {
+	ctr := NewCreateTableRequest(tableName, readCapacityUnits, writeCapacityUnits)
+	ctr.AddAttributeDefinition(LOCK_KEY_ATTRIBUTE, STRING)
+	ctr.AddKeySchemaElement(LOCK_KEY_ATTRIBUTE, HASH)
+	return ctr
+}
+
+// Returns the owner name of the client's locks.
+func (c *LockClient) Owner() string {
+	return c.owner
+}
+
+// Sets the owner name of the client's locks. Defaults to the host name and a random id.
+func (c *LockClient) SetOwner(owner string) {
+	c.owner = owner
+}
+
+// Sets the lease duration of the client's locks and the period of their heartbeats,
+// which must be well below the lease duration. Defaults to DEFAULT_LEASE_DURATION and DEFAULT_HEARTBEAT_PERIOD.
+func (c *LockClient) SetLeaseDuration(leaseDuration, heartbeatPeriod time.Duration) {
+	if leaseDuration > 0 && heartbeatPeriod > 0 {
+		c.leaseDuration, c.heartbeatPeriod = leaseDuration, heartbeatPeriod
+	}
+}
+
+// Acquires the named lock, waiting up to timeout while it is held by another owner, and starts its heartbeats.
+// ErrLockNotGranted is returned if the lock was not acquired in time; a timeout of 0 tries once.
+// The lock must be released with Lock.Close().
+// (key string) The name of the lock.
+// (timeout time.Duration) The time to wait for the lock. It must exceed the lease duration to take over stale locks.
+func (c *LockClient) AcquireLock(key string, timeout time.Duration) (*Lock, error) {
+
+	deadline := time.Now().Add(timeout)
+
+	var observed string
+	var observedAt time.Time
+
+	for {
+
+		rvn := newRecordVersionNumber()
+		err := c.putLock(key, rvn, "")
+		if err == nil {
+			return c.newLock(key, rvn), nil
+		}
+		if !IsErrorType(err, CONDITIONAL_CHECK_FAILED_EXCEPTION) {
+			return nil, err
+		}
+
+		item, err := c.getLock(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if item != nil {
+
+			current := item[LOCK_VERSION_ATTRIBUTE].Value()
+			lease := time.Duration(item[LOCK_LEASE_ATTRIBUTE].Int()) * time.Millisecond
+
+			if current != observed {
+				observed, observedAt = current, time.Now()
+
+			} else if time.Since(observedAt) >= lease {
+				// The owner has not renewed its lease.
+				err = c.putLock(key, rvn, observed)
+				if err == nil {
+					return c.newLock(key, rvn), nil
+				}
+				if !IsErrorType(err, CONDITIONAL_CHECK_FAILED_EXCEPTION) {
+					return nil, err
+				}
+			}
+		}
+
+		wait := deadline.Sub(time.Now())
+		if wait <= 0 {
+			return nil, ErrLockNotGranted
+		}
+		if wait > c.heartbeatPeriod {
+			wait = c.heartbeatPeriod
+		}
+		time.Sleep(wait)
+	}
+}
+
+/*****************************************************************************/
+
+// A lock held by a LockClient. Use LockClient.AcquireLock().
+type Lock struct {
+	client    *LockClient
+	key       string
+	rvn       string
+	lost      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Returns the name of the lock.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Returns a channel that is closed when the lease could not be renewed and the lock may have been taken over.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Stops the heartbeats and releases the lock. ErrLockLost is returned if the lock was lost before.
+func (l *Lock) Close() error {
+
+	l.closeOnce.Do(func() {
+
+		close(l.stop)
+		<-l.done
+
+		select {
+		case <-l.lost:
+			l.closeErr = ErrLockLost
+			return
+		default:
+		}
+
+		dir := NewDeleteItemRequest(l.client.tableName)
+		dir.AddKey(LOCK_KEY_ATTRIBUTE, NewAttributeValue(l.key))
+		dir.ConditionExpression = "#v = :v"
+		dir.ExpressionAttributeNames = map[string]string{"#v": LOCK_VERSION_ATTRIBUTE}
+		dir.ExpressionAttributeValues = map[string]AttributeValue{":v": NewAttributeValue(l.rvn)}
+
+		_, err := l.client.api.DeleteItem(dir)
+		if IsErrorType(err, CONDITIONAL_CHECK_FAILED_EXCEPTION) {
+			err = ErrLockLost
+		}
+		l.closeErr = err
+	})
+
+	return l.closeErr
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+func (c *LockClient) newLock(key, rvn string) *Lock {
+
+	l := &Lock{
+		client: c,
+		key:    key,
+		rvn:    rvn,
+		lost:   make(chan struct{}),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go l.heartbeat()
+	return l
+}
+
+// Puts the lock if it does not exist or, if version is set, if it has the record version number.
+func (c *LockClient) putLock(key, rvn, version string) error {
+
+	pir := NewPutItemRequest(c.tableName)
+	pir.AddItem(LOCK_KEY_ATTRIBUTE, NewAttributeValue(key))
+	pir.AddItem(LOCK_OWNER_ATTRIBUTE, NewAttributeValue(c.owner))
+	pir.AddItem(LOCK_VERSION_ATTRIBUTE, NewAttributeValue(rvn))
+	pir.AddItem(LOCK_LEASE_ATTRIBUTE, NewIntAttributeValue(int64(c.leaseDuration/time.Millisecond)))
+
+	if version == "" {
+		pir.ConditionExpression = "attribute_not_exists(#k)"
+		pir.ExpressionAttributeNames = map[string]string{"#k": LOCK_KEY_ATTRIBUTE}
+	} else {
+		pir.ConditionExpression = "#v = :v"
+		pir.ExpressionAttributeNames = map[string]string{"#v": LOCK_VERSION_ATTRIBUTE}
+		pir.ExpressionAttributeValues = map[string]AttributeValue{":v": NewAttributeValue(version)}
+	}
+
+	_, err := c.api.PutItem(pir)
+	return err
+}
+
+// Returns the lock item, or nil if the lock does not exist.
+func (c *LockClient) getLock(key string) (map[string]AttributeValue, error) {
+
+	gir := NewGetItemRequest(c.tableName)
+	gir.AddKey(LOCK_KEY_ATTRIBUTE, NewAttributeValue(key))
+	gir.ConsistentRead = true
+
+	result, err := c.api.GetItem(gir)
+	if err != nil || len(result.Item) == 0 {
+		return nil, err
+	}
+	return result.Item, nil
+}
+
+// Renews the lease every heartbeat period until the lock is closed or lost. The lock is lost when its
+// record version number was changed by another owner, or when it could not be renewed for the lease duration.
+func (l *Lock) heartbeat() {
+
+	defer close(l.done)
+
+	c := l.client
+	ticker := time.NewTicker(c.heartbeatPeriod)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+
+	for {
+
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		rvn := newRecordVersionNumber()
+
+		uir := NewUpdateItemRequest(c.tableName)
+		uir.AddKey(LOCK_KEY_ATTRIBUTE, NewAttributeValue(l.key))
+		uir.UpdateExpression = "SET #v = :nv, #l = :l"
+		uir.ConditionExpression = "#v = :v AND #o = :o"
+		uir.ExpressionAttributeNames = map[string]string{
+			"#v": LOCK_VERSION_ATTRIBUTE,
+			"#l": LOCK_LEASE_ATTRIBUTE,
+			"#o": LOCK_OWNER_ATTRIBUTE,
+		}
+		uir.ExpressionAttributeValues = map[string]AttributeValue{
+			":nv": NewAttributeValue(rvn),
+			":l":  NewIntAttributeValue(int64(c.leaseDuration / time.Millisecond)),
+			":v":  NewAttributeValue(l.rvn),
+			":o":  NewAttributeValue(c.owner),
+		}
+
+		// The lease is renewed from the time the request is sent, at the latest.
+		sent := time.Now()
+		_, err := c.api.UpdateItem(uir)
+		if err == nil {
+			l.rvn, renewed = rvn, sent
+			continue
+		}
+
+		// Without a renewal by the next heartbeat, which may be late, the lease expires and another client
+		// can take the lock.
+		if IsErrorType(err, CONDITIONAL_CHECK_FAILED_EXCEPTION) || time.Since(renewed)+c.heartbeatPeriod*3/2 >= c.leaseDuration {
+			close(l.lost)
+			return
+		}
+	}
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+func newRecordVersionNumber() string {
+	return uuid.NewUUID().String()[1:37]
+}
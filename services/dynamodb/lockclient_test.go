@@ -0,0 +1,213 @@
+package dynamodb
+
+import (
+	"github.com/twhello/aws-to-go/services"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errConditionalCheck = services.NewServiceError(400, "400 Bad Request", "com.amazonaws.dynamodb.v20120810#"+CONDITIONAL_CHECK_FAILED_EXCEPTION, "")
+
+// An in-memory lock table, which evaluates the conditions of the LockClient.
+type fakeLockTable struct {
+	mutex       sync.Mutex
+	items       map[string]map[string]AttributeValue
+	failUpdates bool
+	renewed     time.Time // The time of the last successful UpdateItem.
+}
+
+func newFakeLockTable() *fakeLockTable {
+	return &fakeLockTable{items: make(map[string]map[string]AttributeValue)}
+}
+
+func (f *fakeLockTable) versionMatches(key string, values map[string]AttributeValue) bool {
+	item, ok := f.items[key]
+	return ok && item[LOCK_VERSION_ATTRIBUTE].Value() == values[":v"].Value()
+}
+
+func (f *fakeLockTable) PutItem(pir *PutItemRequest) (*PutItemResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	key := pir.Item[LOCK_KEY_ATTRIBUTE].Value()
+	if strings.HasPrefix(pir.ConditionExpression, "attribute_not_exists") {
+		if _, ok := f.items[key]; ok {
+			return nil, errConditionalCheck
+		}
+	} else if !f.versionMatches(key, pir.ExpressionAttributeValues) {
+		return nil, errConditionalCheck
+	}
+	f.items[key] = pir.Item
+	return &PutItemResult{}, nil
+}
+
+func (f *fakeLockTable) GetItem(gir *GetItemRequest) (*GetItemResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return &GetItemResult{Item: f.items[gir.Key[LOCK_KEY_ATTRIBUTE].Value()]}, nil
+}
+
+func (f *fakeLockTable) UpdateItem(uir *UpdateItemRequest) (*UpdateItemResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.failUpdates {
+		return nil, services.NewServiceError(500, "500 Internal Server Error", "InternalServerError", "")
+	}
+	key := uir.Key[LOCK_KEY_ATTRIBUTE].Value()
+	if !f.versionMatches(key, uir.ExpressionAttributeValues) ||
+		f.items[key][LOCK_OWNER_ATTRIBUTE].Value() != uir.ExpressionAttributeValues[":o"].Value() {
+		return nil, errConditionalCheck
+	}
+	item := make(map[string]AttributeValue)
+	for name, value := range f.items[key] {
+		item[name] = value
+	}
+	item[LOCK_VERSION_ATTRIBUTE] = uir.ExpressionAttributeValues[":nv"]
+	f.items[key] = item
+	f.renewed = time.Now()
+	return &UpdateItemResult{}, nil
+}
+
+func (f *fakeLockTable) DeleteItem(dir *DeleteItemRequest) (*DeleteItemResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	key := dir.Key[LOCK_KEY_ATTRIBUTE].Value()
+	if !f.versionMatches(key, dir.ExpressionAttributeValues) {
+		return nil, errConditionalCheck
+	}
+	delete(f.items, key)
+	return &DeleteItemResult{}, nil
+}
+
+func (f *fakeLockTable) attribute(key, name string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.items[key][name].Value()
+}
+
+func testLockClient(table *fakeLockTable, owner string) *LockClient {
+	c := newLockClient(table, "Locks")
+	c.SetOwner(owner)
+	c.SetLeaseDuration(100*time.Millisecond, 20*time.Millisecond)
+	return c
+}
+
+func TestLockExclusiveAndHeartbeat(t *testing.T) {
+
+	table := newFakeLockTable()
+	a, b := testLockClient(table, "a"), testLockClient(table, "b")
+
+	lock, err := a.AcquireLock("job", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version := table.attribute("job", LOCK_VERSION_ATTRIBUTE)
+	if _, err := b.AcquireLock("job", 200*time.Millisecond); err != ErrLockNotGranted {
+		t.Errorf("Expected ErrLockNotGranted while heartbeating, got %v", err)
+	}
+	if table.attribute("job", LOCK_VERSION_ATTRIBUTE) == version {
+		t.Error("Expected the heartbeats to change the record version number.")
+	}
+
+	if err := lock.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Close(); err != nil {
+		t.Errorf("Expected Close to be idempotent, got %v", err)
+	}
+
+	lock, err = b.AcquireLock("job", 0)
+	if err != nil {
+		t.Fatalf("Expected the released lock, got %v", err)
+	}
+	lock.Close()
+}
+
+func TestLockStaleAndLost(t *testing.T) {
+
+	table := newFakeLockTable()
+	a, b := testLockClient(table, "a"), testLockClient(table, "b")
+
+	held, err := a.AcquireLock("leader", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The heartbeats of a fail, so its lease expires and b takes over the stale lock.
+	table.mutex.Lock()
+	table.failUpdates = true
+	table.mutex.Unlock()
+
+	select {
+	case <-held.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the lock to be lost.")
+	}
+
+	table.mutex.Lock()
+	table.failUpdates = false
+	table.mutex.Unlock()
+
+	taken, err := b.AcquireLock("leader", time.Second)
+	if err != nil {
+		t.Fatalf("Expected the stale lock to be taken over, got %v", err)
+	}
+	if owner := table.attribute("leader", LOCK_OWNER_ATTRIBUTE); owner != "b" {
+		t.Errorf("Expected owner b, got %s", owner)
+	}
+
+	if err := held.Close(); err != ErrLockLost {
+		t.Errorf("Expected ErrLockLost, got %v", err)
+	}
+	if err := taken.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLockLostBeforeLeaseExpires(t *testing.T) {
+
+	table := newFakeLockTable()
+	c := newLockClient(table, "Locks")
+	c.SetLeaseDuration(200*time.Millisecond, 50*time.Millisecond)
+
+	held, err := c.AcquireLock("leader", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Close()
+
+	time.Sleep(120 * time.Millisecond)
+	table.mutex.Lock()
+	table.failUpdates = true
+	table.mutex.Unlock()
+
+	select {
+	case <-held.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the lock to be lost.")
+	}
+
+	table.mutex.Lock()
+	renewed := table.renewed
+	table.mutex.Unlock()
+
+	if renewed.IsZero() {
+		t.Fatal("Expected a heartbeat to succeed first.")
+	}
+	if elapsed := time.Since(renewed); elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected the lock to be lost before the lease expired, was %v after the last renewal", elapsed)
+	}
+}
+
+func TestNewLockTableRequest(t *testing.T) {
+
+	ctr := NewLockTableRequest("Locks", 1, 1)
+	if len(ctr.KeySchema) != 1 || ctr.KeySchema[0].AttributeName != LOCK_KEY_ATTRIBUTE || ctr.KeySchema[0].KeyType != HASH {
+		t.Errorf("Unexpected key schema %+v", ctr.KeySchema)
+	}
+}
@@ -40,6 +40,7 @@ func NewBatchWriteItemRequest(requestItems map[string][]WriteRequest) *BatchWrit
 type CreateTableRequest struct {
 	AttributeDefinitions   []AttributeDefinition  `json:"AttributeDefinitions"`
 	GlobalSecondaryIndexes []GlobalSecondaryIndex `json:"GlobalSecondaryIndexes,omitempty"`
+	KeySchema              []KeySchemaElement     `json:"KeySchema"`
 	LocalSecondaryIndexes  []LocalSecondaryIndex  `json:"LocalSecondaryIndexes,omitempty"`
 	ProvisionedThroughput  *ProvisionedThroughput `json:"ProvisionedThroughput"`
 	TableName              string                 `json:"TableName"`
@@ -66,13 +67,23 @@ func (r *CreateTableRequest) AddAttributeDefinition(attributeName string, attrib
 	r.AttributeDefinitions = append(r.AttributeDefinitions, AttributeDefinition{attributeName, attributeType})
 }
 
+// Adds a KeySchemaElement to the KeySchema array. The HASH key must be added first.
+// (attributeName string) The name of a key attribute.
+// (keyType KeyType) The role that the key attribute will assume. Valid Values: HASH | RANGE
+func (r *CreateTableRequest) AddKeySchemaElement(attributeName string, keyType KeyType) {
+	r.KeySchema = append(r.KeySchema, KeySchemaElement{attributeName, keyType})
+}
+
 /*****************************************************************************/
 
 // Deletes a single item in a table by primary key.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DeleteItem.html]
 type DeleteItemRequest struct {
+	ConditionExpression         string                            `json:"ConditionExpression,omitempty"`
 	ConditionalOperator         ConditionalOperator               `json:"ConditionalOperator,omitempty"`
 	Expected                    map[string]ExpectedAttributeValue `json:"Expected,omitempty"`
+	ExpressionAttributeNames    map[string]string                 `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues   map[string]AttributeValue         `json:"ExpressionAttributeValues,omitempty"`
 	Key                         map[string]AttributeValue         `json:"Key"`
 	ReturnConsumedCapacity      ReturnConsumedCapacity            `json:"ReturnConsumedCapacity,omitempty"`
 	ReturnItemCollectionMetrics ReturnItemCollectionMetrics       `json:"ReturnItemCollectionMetrics,omitempty"`
@@ -126,11 +137,13 @@ func NewDescribeTableRequest(tableName string) *DescribeTableRequest {
 // The GetItem operation returns a set of attributes for the item with the given primary key.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_GetItem.html]
 type GetItemRequest struct {
-	AttributesToGet        []string                  `json:"AttributesToGet,omitempty"`
-	ConsistentRead         bool                      `json:"ConsistentRead,omitempty"`
-	Key                    map[string]AttributeValue `json:"Key"`
-	ReturnConsumedCapacity string                    `json:"ReturnConsumedCapacity,omitempty"`
-	TableName              string                    `json:"TableName"`
+	AttributesToGet          []string                  `json:"AttributesToGet,omitempty"`
+	ConsistentRead           bool                      `json:"ConsistentRead,omitempty"`
+	ExpressionAttributeNames map[string]string         `json:"ExpressionAttributeNames,omitempty"`
+	Key                      map[string]AttributeValue `json:"Key"`
+	ProjectionExpression     string                    `json:"ProjectionExpression,omitempty"`
+	ReturnConsumedCapacity   string                    `json:"ReturnConsumedCapacity,omitempty"`
+	TableName                string                    `json:"TableName"`
 }
 
 // Creates a new GetItemRequest with the primary key hash attribute.
@@ -166,8 +179,11 @@ func NewListTablesRequest(limit int) *ListTablesRequest {
 // Creates a new item, or replaces an old item with a new item.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_PutItem.html]
 type PutItemRequest struct {
+	ConditionExpression         string                            `json:"ConditionExpression,omitempty"`
 	ConditionalOperator         ConditionalOperator               `json:"ConditionalOperator,omitempty"`
 	Expected                    map[string]ExpectedAttributeValue `json:"Expected,omitempty"`
+	ExpressionAttributeNames    map[string]string                 `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues   map[string]AttributeValue         `json:"ExpressionAttributeValues,omitempty"`
 	Item                        map[string]AttributeValue         `json:"Item"`
 	ReturnConsumedCapacity      ReturnConsumedCapacity            `json:"ReturnConsumedCapacity,omitempty"`
 	ReturnItemCollectionMetrics ReturnItemCollectionMetrics       `json:"ReturnItemCollectionMetrics,omitempty"`
@@ -193,18 +209,23 @@ func (r *PutItemRequest) AddItem(name string, attribute AttributeValue) {
 // QueryRequest struct.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_Query.html]
 type QueryRequest struct {
-	AttributesToGet        []string                  `json:"AttributesToGet,omitempty"`
-	ConditionalOperator    ConditionalOperator       `json:"ConditionalOperator,omitempty"`
-	ConsistentRead         bool                      `json:"ConsistentRead,omitempty"`
-	ExclusiveStartKey      map[string]AttributeValue `json:"ExclusiveStartKey,omitempty"`
-	IndexName              string                    `json:"IndexName,omitempty"`
-	KeyConditions          map[string]Condition      `json:"KeyConditions"`
-	Limit                  int                       `json:"Limit,omitempty"`
-	QueryFilter            map[string]Condition      `json:"QueryFilter,omitempty"`
-	ReturnConsumedCapacity ReturnConsumedCapacity    `json:"ReturnConsumedCapacity,omitempty"`
-	ScanIndexForward       bool                      `json:"ScanIndexForward,omitempty"`
-	Select                 SelectAttributes          `json:"Select,omitempty"`
-	TableName              string                    `json:"TableName"`
+	AttributesToGet           []string                  `json:"AttributesToGet,omitempty"`
+	ConditionalOperator       ConditionalOperator       `json:"ConditionalOperator,omitempty"`
+	ConsistentRead            bool                      `json:"ConsistentRead,omitempty"`
+	ExclusiveStartKey         map[string]AttributeValue `json:"ExclusiveStartKey,omitempty"`
+	ExpressionAttributeNames  map[string]string         `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues map[string]AttributeValue `json:"ExpressionAttributeValues,omitempty"`
+	FilterExpression          string                    `json:"FilterExpression,omitempty"`
+	IndexName                 string                    `json:"IndexName,omitempty"`
+	KeyConditionExpression    string                    `json:"KeyConditionExpression,omitempty"`
+	KeyConditions             map[string]Condition      `json:"KeyConditions,omitempty"`
+	Limit                     int                       `json:"Limit,omitempty"`
+	ProjectionExpression      string                    `json:"ProjectionExpression,omitempty"`
+	QueryFilter               map[string]Condition      `json:"QueryFilter,omitempty"`
+	ReturnConsumedCapacity    ReturnConsumedCapacity    `json:"ReturnConsumedCapacity,omitempty"`
+	ScanIndexForward          *bool                     `json:"ScanIndexForward,omitempty"`
+	Select                    SelectAttributes          `json:"Select,omitempty"`
+	TableName                 string                    `json:"TableName"`
 }
 
 // Create a new QueryRequest object.
@@ -235,24 +256,34 @@ func (r *QueryRequest) AddQueryFilter(name string, attribute AttributeValue, op
 	r.QueryFilter[name] = Condition{[]AttributeValue{attribute}, op}
 }
 
+// Sets the order of the index traversal. If true (default), the traversal is performed in ascending order;
+// if false, the traversal is performed in descending order.
+func (r *QueryRequest) SetScanIndexForward(scanIndexForward bool) {
+	r.ScanIndexForward = &scanIndexForward
+}
+
 /*****************************************************************************/
 
 // The Scan operation returns one or more items and item attributes by accessing every item in the table.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_Scan.html]
 type ScanRequest struct {
-	AttributesToGet        []string                  `json:"AttributesToGet,omitempty"`
-	ConditionalOperator    ConditionalOperator       `json:"ConditionalOperator,omitempty"`
-	ExclusiveStartKey      map[string]AttributeValue `json:"ExclusiveStartKey,omitempty"`
-	Limit                  int                       `json:"Limit,omitempty"`
-	ReturnConsumedCapacity ReturnConsumedCapacity    `json:"ReturnConsumedCapacity,omitempty"`
-	ScanFilter             map[string]Condition      `json:"ScanFilter,omitempty"`
-	Segment                int64                     `json:"Segment,omitempty"`
-	Select                 SelectAttributes          `json:"Select,omitempty"`
-	TableName              string                    `json:"TableName"`
-	TotalSegments          int                       `json:"TotalSegments,omitempty"`
-}
-
-// Create a new QueryRequest object.
+	AttributesToGet           []string                  `json:"AttributesToGet,omitempty"`
+	ConditionalOperator       ConditionalOperator       `json:"ConditionalOperator,omitempty"`
+	ExclusiveStartKey         map[string]AttributeValue `json:"ExclusiveStartKey,omitempty"`
+	ExpressionAttributeNames  map[string]string         `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues map[string]AttributeValue `json:"ExpressionAttributeValues,omitempty"`
+	FilterExpression          string                    `json:"FilterExpression,omitempty"`
+	Limit                     int                       `json:"Limit,omitempty"`
+	ProjectionExpression      string                    `json:"ProjectionExpression,omitempty"`
+	ReturnConsumedCapacity    ReturnConsumedCapacity    `json:"ReturnConsumedCapacity,omitempty"`
+	ScanFilter                map[string]Condition      `json:"ScanFilter,omitempty"`
+	Segment                   *int64                    `json:"Segment,omitempty"`
+	Select                    SelectAttributes          `json:"Select,omitempty"`
+	TableName                 string                    `json:"TableName"`
+	TotalSegments             int                       `json:"TotalSegments,omitempty"`
+}
+
+// Create a new ScanRequest object.
 // (tableName string) The name of the table containing the requested items.
 func NewScanRequest(tableName string) *ScanRequest {
 	return &ScanRequest{TableName: tableName}
@@ -269,18 +300,109 @@ func (r *ScanRequest) AddScanFilter(name string, attribute AttributeValue, op Co
 	r.ScanFilter[name] = Condition{[]AttributeValue{attribute}, op}
 }
 
+// Sets the segment to be scanned by this worker of a parallel scan.
+// (segment int64) The segment, from 0 to totalSegments-1.
+// (totalSegments int) The total number of segments the table is divided into.
+func (r *ScanRequest) SetSegment(segment int64, totalSegments int) {
+	r.Segment = &segment
+	r.TotalSegments = totalSegments
+}
+
+/*****************************************************************************/
+
+// Gets up to 25 items from one or more tables in a single, consistent transaction.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactGetItems.html]
+type TransactGetItemsRequest struct {
+	ReturnConsumedCapacity ReturnConsumedCapacity `json:"ReturnConsumedCapacity,omitempty"`
+	TransactItems          []TransactGetItem      `json:"TransactItems"`
+}
+
+// Creates a new TransactGetItemsRequest.
+func NewTransactGetItemsRequest() *TransactGetItemsRequest {
+	return &TransactGetItemsRequest{}
+}
+
+// Adds an item to get. The items of the result are in the same order.
+// (tableName string) The name of the table containing the item.
+// (key map[string]AttributeValue) The primary key of the item.
+func (r *TransactGetItemsRequest) AddGet(tableName string, key map[string]AttributeValue) *Get {
+	get := &Get{Key: key, TableName: tableName}
+	r.TransactItems = append(r.TransactItems, TransactGetItem{get})
+	return get
+}
+
+/*****************************************************************************/
+
+// Writes up to 25 items in one or more tables in a single, all-or-nothing transaction.
+// [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactWriteItems.html]
+type TransactWriteItemsRequest struct {
+	ClientRequestToken          string                      `json:"ClientRequestToken,omitempty"`
+	ReturnConsumedCapacity      ReturnConsumedCapacity      `json:"ReturnConsumedCapacity,omitempty"`
+	ReturnItemCollectionMetrics ReturnItemCollectionMetrics `json:"ReturnItemCollectionMetrics,omitempty"`
+	TransactItems               []TransactWriteItem         `json:"TransactItems"`
+}
+
+// Creates a new TransactWriteItemsRequest.
+// (clientRequestToken string) Makes the request idempotent for 10 minutes, so retries do not apply it twice. Can be empty.
+func NewTransactWriteItemsRequest(clientRequestToken string) *TransactWriteItemsRequest {
+	return &TransactWriteItemsRequest{ClientRequestToken: clientRequestToken}
+}
+
+// Adds a condition that must be true for the transaction to succeed.
+// (tableName string) The name of the table containing the item.
+// (key map[string]AttributeValue) The primary key of the item.
+// (conditionExpression string) The condition on the item.
+func (r *TransactWriteItemsRequest) AddConditionCheck(tableName string, key map[string]AttributeValue, conditionExpression string) *ConditionCheck {
+	check := &ConditionCheck{ConditionExpression: conditionExpression, Key: key, TableName: tableName}
+	r.TransactItems = append(r.TransactItems, TransactWriteItem{ConditionCheck: check})
+	return check
+}
+
+// Adds an item to delete.
+// (tableName string) The name of the table containing the item.
+// (key map[string]AttributeValue) The primary key of the item.
+func (r *TransactWriteItemsRequest) AddDelete(tableName string, key map[string]AttributeValue) *Delete {
+	del := &Delete{Key: key, TableName: tableName}
+	r.TransactItems = append(r.TransactItems, TransactWriteItem{Delete: del})
+	return del
+}
+
+// Adds an item to put.
+// (tableName string) The name of the table to put the item in.
+// (item map[string]AttributeValue) The item, including its primary key.
+func (r *TransactWriteItemsRequest) AddPut(tableName string, item map[string]AttributeValue) *Put {
+	put := &Put{Item: item, TableName: tableName}
+	r.TransactItems = append(r.TransactItems, TransactWriteItem{Put: put})
+	return put
+}
+
+// Adds an item to update.
+// (tableName string) The name of the table containing the item.
+// (key map[string]AttributeValue) The primary key of the item.
+// (updateExpression string) The attributes to update.
+func (r *TransactWriteItemsRequest) AddUpdate(tableName string, key map[string]AttributeValue, updateExpression string) *Update {
+	update := &Update{Key: key, TableName: tableName, UpdateExpression: updateExpression}
+	r.TransactItems = append(r.TransactItems, TransactWriteItem{Update: update})
+	return update
+}
+
 /*****************************************************************************/
 
 // Edits an existing item's attributes, or inserts a new item if it does not already exist.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_UpdateItem.html]
 type UpdateItemRequest struct {
+	AttributeUpdates            map[string]AttributeValueUpdate   `json:"AttributeUpdates,omitempty"`
+	ConditionExpression         string                            `json:"ConditionExpression,omitempty"`
 	ConditionalOperator         ConditionalOperator               `json:"ConditionalOperator,omitempty"`
 	Expected                    map[string]ExpectedAttributeValue `json:"Expected,omitempty"`
+	ExpressionAttributeNames    map[string]string                 `json:"ExpressionAttributeNames,omitempty"`
+	ExpressionAttributeValues   map[string]AttributeValue         `json:"ExpressionAttributeValues,omitempty"`
 	Key                         map[string]AttributeValue         `json:"Key"`
 	ReturnConsumedCapacity      ReturnConsumedCapacity            `json:"ReturnConsumedCapacity,omitempty"`
 	ReturnItemCollectionMetrics ReturnItemCollectionMetrics       `json:"ReturnItemCollectionMetrics,omitempty"`
 	ReturnValues                ReturnValues                      `json:"ReturnValues,omitempty"`
 	TableName                   string                            `json:"TableName"`
+	UpdateExpression            string                            `json:"UpdateExpression,omitempty"`
 }
 
 // Creates a new UpdateItemRequest.
@@ -296,14 +418,26 @@ func (r *UpdateItemRequest) AddKey(name string, attribute AttributeValue) {
 	r.Key[name] = attribute
 }
 
+// Adds an attribute to be modified.
+// (name string) The attribute name.
+// (action Action) PUT, DELETE or ADD.
+// (attribute *AttributeValue) The new value. Can be nil for DELETE.
+func (r *UpdateItemRequest) AddAttributeUpdate(name string, action Action, attribute *AttributeValue) {
+	if len(r.AttributeUpdates) == 0 {
+		r.AttributeUpdates = make(map[string]AttributeValueUpdate)
+	}
+	r.AttributeUpdates[name] = AttributeValueUpdate{string(action), attribute}
+}
+
 /*****************************************************************************/
 
 // Updates the provisioned throughput for the given table.
 // [http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_UpdateTable.html]
 type UpdateTableRequest struct {
-	GlobalSecondaryIndexUpdates *GlobalSecondaryIndexUpdate `json:"GlobalSecondaryIndexUpdates,omitempty"`
-	ProvisionedThroughput       *ProvisionedThroughput      `json:"ProvisionedThroughput,omitempty"`
-	TableName                   string                      `json:"TableName"`
+	AttributeDefinitions        []AttributeDefinition        `json:"AttributeDefinitions,omitempty"`
+	GlobalSecondaryIndexUpdates []GlobalSecondaryIndexUpdate `json:"GlobalSecondaryIndexUpdates,omitempty"`
+	ProvisionedThroughput       *ProvisionedThroughput       `json:"ProvisionedThroughput,omitempty"`
+	TableName                   string                       `json:"TableName"`
 }
 
 // Creates a new UpdateTableRequest.
@@ -0,0 +1,154 @@
+package dynamodbstreams
+
+import (
+	"github.com/twhello/aws-to-go/services/dynamodb"
+)
+
+/******************************************************************************
+ * Constants
+ */
+
+// The type of data modification that was performed on the DynamoDB table.
+// INSERT - A new item was added to the table.
+// MODIFY - One or more of an existing item's attributes were modified.
+// REMOVE - The item was deleted from the table.
+type OperationType string
+
+const (
+	INSERT OperationType = "INSERT"
+	MODIFY OperationType = "MODIFY"
+	REMOVE OperationType = "REMOVE"
+)
+
+// Determines how the shard iterator is used to start reading stream records from the shard.
+// AT_SEQUENCE_NUMBER - Start reading exactly from the position denoted by a specific sequence number.
+// AFTER_SEQUENCE_NUMBER - Start reading right after the position denoted by a specific sequence number.
+// TRIM_HORIZON - Start reading at the last (untrimmed) stream record, which is the oldest record in the shard.
+// LATEST - Start reading just after the most recent stream record in the shard.
+type ShardIteratorType string
+
+const (
+	AFTER_SEQUENCE_NUMBER ShardIteratorType = "AFTER_SEQUENCE_NUMBER"
+	AT_SEQUENCE_NUMBER    ShardIteratorType = "AT_SEQUENCE_NUMBER"
+	LATEST                ShardIteratorType = "LATEST"
+	TRIM_HORIZON          ShardIteratorType = "TRIM_HORIZON"
+)
+
+// The current status of the stream.
+// ENABLING - Streams is currently being enabled on the DynamoDB table.
+// ENABLED - The stream is enabled.
+// DISABLING - Streams is currently being disabled on the DynamoDB table.
+// DISABLED - The stream is disabled.
+type StreamStatus string
+
+const (
+	DISABLED  StreamStatus = "DISABLED"
+	DISABLING StreamStatus = "DISABLING"
+	ENABLED   StreamStatus = "ENABLED"
+	ENABLING  StreamStatus = "ENABLING"
+)
+
+// Determines the information written to the stream when an item is modified.
+// KEYS_ONLY - Only the key attributes of the modified item.
+// NEW_IMAGE - The entire item, as it appears after it was modified.
+// OLD_IMAGE - The entire item, as it appeared before it was modified.
+// NEW_AND_OLD_IMAGES - Both the new and the old images of the item.
+type StreamViewType string
+
+const (
+	KEYS_ONLY          StreamViewType = "KEYS_ONLY"
+	NEW_AND_OLD_IMAGES StreamViewType = "NEW_AND_OLD_IMAGES"
+	NEW_IMAGE          StreamViewType = "NEW_IMAGE"
+	OLD_IMAGE          StreamViewType = "OLD_IMAGE"
+)
+
+/******************************************************************************
+ * Data Types
+ */
+
+// Represents the output of a DescribeStream operation.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_DescribeStream.html]
+type DescribeStreamResult struct {
+	StreamDescription StreamDescription `json:"StreamDescription"`
+}
+
+// Represents the output of a GetRecords operation.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_GetRecords.html]
+type GetRecordsResult struct {
+	NextShardIterator string   `json:"NextShardIterator,omitempty"`
+	Records           []Record `json:"Records"`
+}
+
+// Represents the output of a GetShardIterator operation.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_GetShardIterator.html]
+type GetShardIteratorResult struct {
+	ShardIterator string `json:"ShardIterator,omitempty"`
+}
+
+// Represents the output of a ListStreams operation.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_ListStreams.html]
+type ListStreamsResult struct {
+	LastEvaluatedStreamArn string   `json:"LastEvaluatedStreamArn,omitempty"`
+	Streams                []Stream `json:"Streams"`
+}
+
+// A description of a unique event within a stream.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_Record.html]
+type Record struct {
+	AwsRegion    string        `json:"awsRegion"`
+	Dynamodb     StreamRecord  `json:"dynamodb"`
+	EventID      string        `json:"eventID"`
+	EventName    OperationType `json:"eventName"`
+	EventSource  string        `json:"eventSource"`
+	EventVersion string        `json:"eventVersion"`
+}
+
+// The beginning and ending sequence numbers for the stream records contained within a shard.
+// An open shard has no EndingSequenceNumber.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_SequenceNumberRange.html]
+type SequenceNumberRange struct {
+	EndingSequenceNumber   string `json:"EndingSequenceNumber,omitempty"`
+	StartingSequenceNumber string `json:"StartingSequenceNumber,omitempty"`
+}
+
+// A uniquely identified group of stream records within a stream.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_Shard.html]
+type Shard struct {
+	ParentShardId       string              `json:"ParentShardId,omitempty"`
+	SequenceNumberRange SequenceNumberRange `json:"SequenceNumberRange"`
+	ShardId             string              `json:"ShardId"`
+}
+
+// Represents all of the data describing a particular stream.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_Stream.html]
+type Stream struct {
+	StreamArn   string `json:"StreamArn"`
+	StreamLabel string `json:"StreamLabel"`
+	TableName   string `json:"TableName"`
+}
+
+// Represents all of the data describing a particular stream.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_StreamDescription.html]
+type StreamDescription struct {
+	CreationRequestDateTime float64                     `json:"CreationRequestDateTime,omitempty"`
+	KeySchema               []dynamodb.KeySchemaElement `json:"KeySchema"`
+	LastEvaluatedShardId    string                      `json:"LastEvaluatedShardId,omitempty"`
+	Shards                  []Shard                     `json:"Shards"`
+	StreamArn               string                      `json:"StreamArn"`
+	StreamLabel             string                      `json:"StreamLabel"`
+	StreamStatus            StreamStatus                `json:"StreamStatus"`
+	StreamViewType          StreamViewType              `json:"StreamViewType"`
+	TableName               string                      `json:"TableName"`
+}
+
+// A description of a single data modification that was performed on an item in a DynamoDB table.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_StreamRecord.html]
+type StreamRecord struct {
+	ApproximateCreationDateTime float64                            `json:"ApproximateCreationDateTime,omitempty"`
+	Keys                        map[string]dynamodb.AttributeValue `json:"Keys,omitempty"`
+	NewImage                    map[string]dynamodb.AttributeValue `json:"NewImage,omitempty"`
+	OldImage                    map[string]dynamodb.AttributeValue `json:"OldImage,omitempty"`
+	SequenceNumber              string                             `json:"SequenceNumber"`
+	SizeBytes                   int64                              `json:"SizeBytes"`
+	StreamViewType              StreamViewType                     `json:"StreamViewType"`
+}
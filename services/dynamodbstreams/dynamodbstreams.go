@@ -0,0 +1,129 @@
+//
+// Amazon DynamoDB Streams captures a time-ordered sequence of item-level modifications
+// in a DynamoDB table and stores this information in a log for up to 24 hours.
+// Applications can access this log and view the data items as they appeared before
+// and after they were modified, in near real time.
+//
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/Welcome.html]
+//
+package dynamodbstreams
+
+import (
+	"github.com/twhello/aws-to-go/auth"
+	"github.com/twhello/aws-to-go/interfaces"
+	"github.com/twhello/aws-to-go/regions"
+	"github.com/twhello/aws-to-go/services"
+	"net/http"
+)
+
+// The service name requests are signed with. The endpoint is prefixed with "streams.".
+const ServiceName = "dynamodb"
+
+// Error types returned by DynamoDB Streams. Check with dynamodb.IsErrorType().
+const (
+	EXPIRED_ITERATOR_EXCEPTION    = "ExpiredIteratorException"
+	INTERNAL_SERVER_ERROR         = "InternalServerError"
+	LIMIT_EXCEEDED_EXCEPTION      = "LimitExceededException"
+	RESOURCE_NOT_FOUND_EXCEPTION  = "ResourceNotFoundException"
+	TRIMMED_DATA_ACCESS_EXCEPTION = "TrimmedDataAccessException"
+)
+
+// DynamoDB Streams Service struct. Use dynamodbstreams.NewService().
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/Welcome.html]
+type DynamoDBStreamsService struct {
+	cred     interfaces.IAWSCredentials
+	region   *regions.Region
+	endpoint string
+}
+
+// Returns the name of the service.
+func (s *DynamoDBStreamsService) ServiceName() string {
+	return ServiceName
+}
+
+// Returns the region name the service will call.
+func (s *DynamoDBStreamsService) RegionName() string {
+	return s.region.Name()
+}
+
+// Returns the endpoint to the service.
+func (s *DynamoDBStreamsService) Endpoint() string {
+	return s.endpoint
+}
+
+// Low-level request to DynamoDB Streams service.
+func (s *DynamoDBStreamsService) SignAndDo(req interfaces.IAWSRequest, dto interface{}) (resp *http.Response, err error) {
+
+	signer := auth.V4Signer{Credentials: s.cred, AWSService: s}
+	signer.Sign(req)
+
+	resp, err = services.DoRequest(req, dto, services.NewEvalJsonServiceResponse())
+
+	return
+}
+
+// Creates the IAWSRequest and sets required headers.
+// (target string) Sets the X-Amz-Target header.
+// (request interface{}) The interface to marshal into the request body.
+// (result interface{}) The interface for the unmarshalled API result, or nil.
+func (s *DynamoDBStreamsService) wrapperSignAndDo(target string, request, result interface{}) (err error) {
+
+	req, err := services.NewServerRequest("POST", s.Endpoint(), request)
+
+	if err == nil {
+		h := req.Header()
+		h.Set("Connection", "Keep-Alive")
+		h.Set("Content-Type", "application/x-amz-json-1.0")
+		h.Set("X-Amz-Target", target)
+		_, err = s.SignAndDo(req, result)
+	}
+
+	return
+}
+
+/******************************************************************************
+ * DynamoDB Streams Service Methods.
+ */
+
+// Returns information about a stream, including its current status, its ARN, the composition of its
+// shards, and its corresponding DynamoDB table.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_DescribeStream.html]
+func (s *DynamoDBStreamsService) DescribeStream(req *DescribeStreamRequest) (result *DescribeStreamResult, err error) {
+
+	result = new(DescribeStreamResult)
+	err = s.wrapperSignAndDo("DynamoDBStreams_20120810.DescribeStream", req, result)
+	return
+}
+
+// Retrieves the stream records from a given shard.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_GetRecords.html]
+func (s *DynamoDBStreamsService) GetRecords(req *GetRecordsRequest) (result *GetRecordsResult, err error) {
+
+	result = new(GetRecordsResult)
+	err = s.wrapperSignAndDo("DynamoDBStreams_20120810.GetRecords", req, result)
+	return
+}
+
+// Returns a shard iterator, which describes the location within a shard to start reading stream records from.
+// A shard iterator expires 15 minutes after it is returned to the requester.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_GetShardIterator.html]
+func (s *DynamoDBStreamsService) GetShardIterator(req *GetShardIteratorRequest) (result *GetShardIteratorResult, err error) {
+
+	result = new(GetShardIteratorResult)
+	err = s.wrapperSignAndDo("DynamoDBStreams_20120810.GetShardIterator", req, result)
+	return
+}
+
+// Returns an array of stream ARNs associated with the current account and endpoint.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_ListStreams.html]
+func (s *DynamoDBStreamsService) ListStreams(req *ListStreamsRequest) (result *ListStreamsResult, err error) {
+
+	result = new(ListStreamsResult)
+	err = s.wrapperSignAndDo("DynamoDBStreams_20120810.ListStreams", req, result)
+	return
+}
+
+// Creates a new DynamoDB Streams Service.
+func NewService(cred interfaces.IAWSCredentials, region *regions.Region) *DynamoDBStreamsService {
+	return &DynamoDBStreamsService{cred, region, "https://streams." + ServiceName + "." + region.Name() + ".amazonaws.com"}
+}
@@ -0,0 +1,350 @@
+package dynamodbstreams
+
+import (
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"github.com/twhello/aws-to-go/services/dynamodb/datamodeling"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_POLL_INTERVAL    = time.Second      // Wait after an empty page of an open shard.
+	DEFAULT_REFRESH_INTERVAL = 10 * time.Second // Wait between descriptions of the stream's shards.
+)
+
+// The progress of a stream processor, which can be saved as JSON and resumed with SetCheckpoint.
+type StreamCheckpoint struct {
+	Shards map[string]ShardCheckpoint
+}
+
+// The progress of one shard. A shard without a SequenceNumber that is not done starts at TRIM_HORIZON.
+type ShardCheckpoint struct {
+	Done           bool
+	SequenceNumber string `json:",omitempty"`
+}
+
+// The operations of DynamoDBStreamsService used by the StreamProcessor.
+type streamsAPI interface {
+	DescribeStream(*DescribeStreamRequest) (*DescribeStreamResult, error)
+	GetRecords(*GetRecordsRequest) (*GetRecordsResult, error)
+	GetShardIterator(*GetShardIteratorRequest) (*GetShardIteratorResult, error)
+}
+
+/*****************************************************************************/
+
+// Reads the records of a stream with one worker per shard. A child shard is read after its parent
+// is done, so the records of an item are processed in order. Use dynamodbstreams.NewStreamProcessor().
+type StreamProcessor struct {
+	api             streamsAPI
+	streamArn       string
+	pollInterval    time.Duration
+	refreshInterval time.Duration
+	checkpoint      *StreamCheckpoint
+	onCheckpoint    func(StreamCheckpoint)
+	stop            chan struct{}
+	stopOnce        sync.Once
+}
+
+// Creates a new StreamProcessor.
+// (service *DynamoDBStreamsService) The service to read with.
+// (streamArn string) The ARN of the stream, e.g. the LatestStreamArn of the table's description.
+func NewStreamProcessor(service *DynamoDBStreamsService, streamArn string) *StreamProcessor {
+	return newStreamProcessor(service, streamArn)
+}
+
+func newStreamProcessor(api streamsAPI, streamArn string) *StreamProcessor {
+	return &StreamProcessor{
+		api:             api,
+		streamArn:       streamArn,
+		pollInterval:    DEFAULT_POLL_INTERVAL,
+		refreshInterval: DEFAULT_REFRESH_INTERVAL,
+		stop:            make(chan struct{}),
+	}
+}
+
+// Sets the wait after an empty page of an open shard. Defaults to DEFAULT_POLL_INTERVAL.
+func (p *StreamProcessor) SetPollInterval(d time.Duration) {
+	if d > 0 {
+		p.pollInterval = d
+	}
+}
+
+// Sets the wait between descriptions of the stream, which find the shards created while it is read.
+// The stream is also described whenever a shard is done. Defaults to DEFAULT_REFRESH_INTERVAL.
+func (p *StreamProcessor) SetRefreshInterval(d time.Duration) {
+	if d > 0 {
+		p.refreshInterval = d
+	}
+}
+
+// Resumes the processor from a checkpoint of a previous run on the same stream.
+func (p *StreamProcessor) SetCheckpoint(checkpoint *StreamCheckpoint) {
+	p.checkpoint = checkpoint
+}
+
+// Sets a function that is called with a copy of the checkpoint after every processed page.
+// The calls are serialized.
+func (p *StreamProcessor) SetCheckpointHandler(fn func(checkpoint StreamCheckpoint)) {
+	p.onCheckpoint = fn
+}
+
+// Stops the processor after the pages in progress. A stopped processor cannot be run again;
+// resume it with a new StreamProcessor and the checkpoint.
+func (p *StreamProcessor) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// Reads the shards of the stream and calls fn for each record. The records of a shard are passed
+// in order, and the records of different shards concurrently. A shard's checkpoint advances after fn
+// has returned for all records of a page, so a resumed processor may repeat the records of the pages
+// in progress. If fn returns an error, the processor stops and returns it.
+// Run returns when Stop is called, or when all shards of a DISABLED stream are done.
+// Returns the checkpoint of the processor.
+func (p *StreamProcessor) Run(fn func(record Record) error) (*StreamCheckpoint, error) {
+
+	checkpoint := &StreamCheckpoint{make(map[string]ShardCheckpoint)}
+	if p.checkpoint != nil {
+		for id, shard := range p.checkpoint.Shards {
+			checkpoint.Shards[id] = shard
+		}
+	}
+
+	var (
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	fail := func(err error) {
+		mutex.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mutex.Unlock()
+		p.Stop()
+	}
+
+	update := func(shardId string, shard ShardCheckpoint) {
+		mutex.Lock()
+		checkpoint.Shards[shardId] = shard
+		if p.onCheckpoint != nil {
+			p.onCheckpoint(checkpoint.copy())
+		}
+		mutex.Unlock()
+	}
+
+	done := make(map[string]chan struct{})
+	finished := make(chan struct{})
+	running := 0
+
+	refresh := time.NewTicker(p.refreshInterval)
+	defer refresh.Stop()
+
+	for {
+		description, err := p.describe()
+		if err != nil {
+			fail(err)
+			break
+		}
+
+		var started []Shard
+
+		mutex.Lock()
+		for _, shard := range description.Shards {
+			if _, ok := done[shard.ShardId]; ok {
+				continue
+			}
+			done[shard.ShardId] = make(chan struct{})
+			if checkpoint.Shards[shard.ShardId].Done {
+				close(done[shard.ShardId])
+			} else {
+				started = append(started, shard)
+			}
+		}
+		mutex.Unlock()
+
+		// The done channels of all listed shards exist before any worker looks up its parent's.
+		// A parent that is not listed has been trimmed from the stream.
+		for _, shard := range started {
+			running++
+			wg.Add(1)
+			go func(shard Shard, shardDone, parentDone chan struct{}) {
+				defer wg.Done()
+
+				if parentDone != nil {
+					select {
+					case <-parentDone:
+					case <-p.stop:
+						return
+					}
+				}
+
+				mutex.Lock()
+				sequenceNumber := checkpoint.Shards[shard.ShardId].SequenceNumber
+				mutex.Unlock()
+
+				if err := p.processShard(shard, sequenceNumber, fn, update); err != nil {
+					fail(err)
+					return
+				}
+
+				mutex.Lock()
+				isDone := checkpoint.Shards[shard.ShardId].Done
+				mutex.Unlock()
+
+				if isDone {
+					close(shardDone)
+					select {
+					case finished <- struct{}{}:
+					case <-p.stop:
+					}
+				}
+			}(shard, done[shard.ShardId], done[shard.ParentShardId])
+		}
+
+		if running == 0 && description.StreamStatus == DISABLED {
+			break
+		}
+
+		select {
+		case <-p.stop:
+		case <-refresh.C:
+			continue
+		case <-finished:
+			running--
+			continue
+		}
+		break
+	}
+
+	wg.Wait()
+
+	return checkpoint, firstErr
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+// Describes the stream with all of its shards.
+func (p *StreamProcessor) describe() (*StreamDescription, error) {
+
+	dsr := NewDescribeStreamRequest(p.streamArn)
+	var description *StreamDescription
+
+	for {
+		result, err := p.api.DescribeStream(dsr)
+		if err != nil {
+			return nil, err
+		}
+
+		if description == nil {
+			description = &result.StreamDescription
+		} else {
+			description.Shards = append(description.Shards, result.StreamDescription.Shards...)
+		}
+
+		if result.StreamDescription.LastEvaluatedShardId == "" {
+			return description, nil
+		}
+		dsr.ExclusiveStartShardId = result.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// Returns a shard iterator after the sequence number, or at TRIM_HORIZON if it is empty.
+func (p *StreamProcessor) iterator(shardId, sequenceNumber string) (string, error) {
+
+	gsir := NewGetShardIteratorRequest(p.streamArn, shardId, TRIM_HORIZON, "")
+	if sequenceNumber != "" {
+		gsir.ShardIteratorType = AFTER_SEQUENCE_NUMBER
+		gsir.SequenceNumber = sequenceNumber
+	}
+
+	result, err := p.api.GetShardIterator(gsir)
+	if err != nil {
+		return "", err
+	}
+	return result.ShardIterator, nil
+}
+
+// Reads the shard until it is done or the processor is stopped.
+func (p *StreamProcessor) processShard(shard Shard, sequenceNumber string, fn func(Record) error, update func(string, ShardCheckpoint)) error {
+
+	iterator, err := p.iterator(shard.ShardId, sequenceNumber)
+	if err != nil {
+		return err
+	}
+
+	isOpen := shard.SequenceNumberRange.EndingSequenceNumber == ""
+
+	for iterator != "" {
+		select {
+		case <-p.stop:
+			return nil
+		default:
+		}
+
+		result, err := p.api.GetRecords(NewGetRecordsRequest(iterator))
+		if dynamodb.IsErrorType(err, EXPIRED_ITERATOR_EXCEPTION) {
+			if iterator, err = p.iterator(shard.ShardId, sequenceNumber); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, record := range result.Records {
+			if err = fn(record); err != nil {
+				return err
+			}
+		}
+
+		if len(result.Records) > 0 {
+			sequenceNumber = result.Records[len(result.Records)-1].Dynamodb.SequenceNumber
+		}
+		iterator = result.NextShardIterator
+		update(shard.ShardId, ShardCheckpoint{iterator == "", sequenceNumber})
+
+		if len(result.Records) == 0 && isOpen && iterator != "" {
+			select {
+			case <-p.stop:
+				return nil
+			case <-time.After(p.pollInterval):
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *StreamCheckpoint) copy() StreamCheckpoint {
+	shards := make(map[string]ShardCheckpoint, len(c.Shards))
+	for id, shard := range c.Shards {
+		shards[id] = shard
+	}
+	return StreamCheckpoint{shards}
+}
+
+/*****************************************************************************/
+
+// Unmarshals the item as it appeared after it was modified into v, a pointer to a struct.
+// Returns false if the record has no new image, e.g. for a REMOVE or a KEYS_ONLY or OLD_IMAGE stream.
+func (r *StreamRecord) UnmarshalNewImage(v interface{}) bool {
+	if len(r.NewImage) == 0 {
+		return false
+	}
+	datamodeling.Unmarshal(r.NewImage, v)
+	return true
+}
+
+// Unmarshals the item as it appeared before it was modified into v, a pointer to a struct.
+// Returns false if the record has no old image, e.g. for an INSERT or a KEYS_ONLY or NEW_IMAGE stream.
+func (r *StreamRecord) UnmarshalOldImage(v interface{}) bool {
+	if len(r.OldImage) == 0 {
+		return false
+	}
+	datamodeling.Unmarshal(r.OldImage, v)
+	return true
+}
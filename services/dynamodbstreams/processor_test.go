@@ -0,0 +1,184 @@
+package dynamodbstreams
+
+import (
+	"errors"
+	"fmt"
+	"github.com/twhello/aws-to-go/services"
+	"github.com/twhello/aws-to-go/services/dynamodb"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A disabled stream of closed shards, listed one per DescribeStream page.
+// Records are numbered per shard and served two per page.
+type fakeStream struct {
+	mutex   sync.Mutex
+	shards  []Shard
+	records map[string]int
+	expired bool
+}
+
+func (f *fakeStream) DescribeStream(req *DescribeStreamRequest) (*DescribeStreamResult, error) {
+
+	i := 0
+	for req.ExclusiveStartShardId != "" && f.shards[i].ShardId != req.ExclusiveStartShardId {
+		i++
+	}
+	if req.ExclusiveStartShardId != "" {
+		i++
+	}
+
+	description := StreamDescription{Shards: f.shards[i : i+1], StreamArn: req.StreamArn, StreamStatus: DISABLED}
+	if i+1 < len(f.shards) {
+		description.LastEvaluatedShardId = f.shards[i].ShardId
+	}
+	return &DescribeStreamResult{description}, nil
+}
+
+func (f *fakeStream) GetShardIterator(req *GetShardIteratorRequest) (*GetShardIteratorResult, error) {
+
+	position := 0
+	if req.ShardIteratorType == AFTER_SEQUENCE_NUMBER {
+		n, _ := strconv.Atoi(req.SequenceNumber[strings.LastIndex(req.SequenceNumber, "-")+1:])
+		position = n + 1
+	}
+	return &GetShardIteratorResult{fmt.Sprintf("%s/%d", req.ShardId, position)}, nil
+}
+
+func (f *fakeStream) GetRecords(req *GetRecordsRequest) (*GetRecordsResult, error) {
+
+	f.mutex.Lock()
+	if !f.expired {
+		f.expired = true
+		f.mutex.Unlock()
+		return nil, services.NewServiceError(400, "400 Bad Request", "com.amazonaws.dynamodb.v20120810#"+EXPIRED_ITERATOR_EXCEPTION, "")
+	}
+	f.mutex.Unlock()
+
+	parts := strings.Split(req.ShardIterator, "/")
+	shardId := parts[0]
+	position, _ := strconv.Atoi(parts[1])
+
+	result := &GetRecordsResult{}
+	for i := position; i < position+2 && i < f.records[shardId]; i++ {
+		record := Record{EventName: INSERT}
+		record.Dynamodb.SequenceNumber = fmt.Sprintf("%s-%d", shardId, i)
+		result.Records = append(result.Records, record)
+	}
+	if position+2 < f.records[shardId] {
+		result.NextShardIterator = fmt.Sprintf("%s/%d", shardId, position+2)
+	}
+	return result, nil
+}
+
+func newFakeStream() *fakeStream {
+
+	closed := SequenceNumberRange{EndingSequenceNumber: "end"}
+
+	return &fakeStream{
+		shards: []Shard{
+			{ShardId: "root", SequenceNumberRange: closed},
+			{ShardId: "a", ParentShardId: "root", SequenceNumberRange: closed},
+			{ShardId: "b", ParentShardId: "root", SequenceNumberRange: closed},
+			{ShardId: "c", ParentShardId: "a", SequenceNumberRange: closed},
+			{ShardId: "d", ParentShardId: "trimmed", SequenceNumberRange: closed},
+		},
+		records: map[string]int{"root": 3, "a": 4, "b": 1, "c": 2, "d": 2},
+	}
+}
+
+func TestStreamProcessor(t *testing.T) {
+
+	p := newStreamProcessor(newFakeStream(), "arn")
+	p.SetRefreshInterval(time.Millisecond)
+
+	var mutex sync.Mutex
+	var order []string
+
+	checkpoint, err := p.Run(func(record Record) error {
+		mutex.Lock()
+		order = append(order, record.Dynamodb.SequenceNumber)
+		mutex.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 12 {
+		t.Fatalf("Expected 12 records, got %v", order)
+	}
+
+	index := make(map[string]int)
+	for i, sn := range order {
+		index[sn] = i
+	}
+	for _, pair := range [][2]string{{"root-2", "a-0"}, {"root-2", "b-0"}, {"a-3", "c-0"}, {"root-0", "root-1"}, {"a-1", "a-2"}} {
+		if index[pair[0]] > index[pair[1]] {
+			t.Errorf("Expected %s before %s in %v", pair[0], pair[1], order)
+		}
+	}
+
+	for id, shard := range checkpoint.Shards {
+		if !shard.Done {
+			t.Errorf("Expected shard %s to be done, got %+v", id, shard)
+		}
+	}
+	if checkpoint.Shards["c"].SequenceNumber != "c-1" {
+		t.Errorf("Unexpected checkpoint %+v", checkpoint.Shards["c"])
+	}
+}
+
+func TestStreamProcessorResume(t *testing.T) {
+
+	stream := newFakeStream()
+	stream.expired = true
+
+	p := newStreamProcessor(stream, "arn")
+	p.SetCheckpoint(&StreamCheckpoint{map[string]ShardCheckpoint{
+		"root": {Done: true, SequenceNumber: "root-2"},
+		"a":    {SequenceNumber: "a-1"},
+	}})
+
+	var mutex sync.Mutex
+	seen := make(map[string]bool)
+
+	_, err := p.Run(func(record Record) error {
+		mutex.Lock()
+		seen[record.Dynamodb.SequenceNumber] = true
+		mutex.Unlock()
+		return nil
+	})
+	if err != nil || len(seen) != 7 || seen["root-0"] || seen["a-1"] || !seen["a-2"] {
+		t.Fatalf("Expected the 7 records after the checkpoint, got %v (%v)", seen, err)
+	}
+
+	failed := errors.New("failed")
+	if _, err = newStreamProcessor(newFakeStream(), "arn").Run(func(Record) error { return failed }); err != failed {
+		t.Fatalf("Expected the handler's error, got %v", err)
+	}
+}
+
+func TestStreamRecordImages(t *testing.T) {
+
+	type item struct {
+		Id   string `DynamoDBHashKey:"Id"`
+		Name string `DynamoDBAttribute:"Name"`
+	}
+
+	record := StreamRecord{NewImage: map[string]dynamodb.AttributeValue{
+		"Id":   dynamodb.NewAttributeValue("a"),
+		"Name": dynamodb.NewAttributeValue("n"),
+	}}
+
+	var newItem, oldItem item
+	if !record.UnmarshalNewImage(&newItem) || newItem.Id != "a" || newItem.Name != "n" {
+		t.Errorf("Unexpected new image %+v", newItem)
+	}
+	if record.UnmarshalOldImage(&oldItem) {
+		t.Error("Expected no old image.")
+	}
+}
@@ -0,0 +1,69 @@
+package dynamodbstreams
+
+import ()
+
+/*****************************************************************************/
+
+// Returns information about a stream, including its current status, its ARN, the composition of its
+// shards, and its corresponding DynamoDB table.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_DescribeStream.html]
+type DescribeStreamRequest struct {
+	ExclusiveStartShardId string `json:"ExclusiveStartShardId,omitempty"`
+	Limit                 int    `json:"Limit,omitempty"`
+	StreamArn             string `json:"StreamArn"`
+}
+
+// Creates a new DescribeStreamRequest.
+func NewDescribeStreamRequest(streamArn string) *DescribeStreamRequest {
+	return &DescribeStreamRequest{StreamArn: streamArn}
+}
+
+/*****************************************************************************/
+
+// Retrieves the stream records from a given shard. GetRecords can retrieve a maximum of 1 MB of data
+// or 1000 stream records, whichever comes first.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_GetRecords.html]
+type GetRecordsRequest struct {
+	Limit         int    `json:"Limit,omitempty"`
+	ShardIterator string `json:"ShardIterator"`
+}
+
+// Creates a new GetRecordsRequest.
+func NewGetRecordsRequest(shardIterator string) *GetRecordsRequest {
+	return &GetRecordsRequest{ShardIterator: shardIterator}
+}
+
+/*****************************************************************************/
+
+// Returns a shard iterator, which describes the location within a shard to start reading stream records from.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_GetShardIterator.html]
+type GetShardIteratorRequest struct {
+	SequenceNumber    string            `json:"SequenceNumber,omitempty"`
+	ShardId           string            `json:"ShardId"`
+	ShardIteratorType ShardIteratorType `json:"ShardIteratorType"`
+	StreamArn         string            `json:"StreamArn"`
+}
+
+// Creates a new GetShardIteratorRequest.
+// (sequenceNumber string) Required for AT_SEQUENCE_NUMBER and AFTER_SEQUENCE_NUMBER; otherwise empty.
+func NewGetShardIteratorRequest(streamArn, shardId string, shardIteratorType ShardIteratorType, sequenceNumber string) *GetShardIteratorRequest {
+	return &GetShardIteratorRequest{
+		SequenceNumber: sequenceNumber, ShardId: shardId, ShardIteratorType: shardIteratorType, StreamArn: streamArn,
+	}
+}
+
+/*****************************************************************************/
+
+// Returns an array of stream ARNs associated with the current account and endpoint.
+// [http://docs.aws.amazon.com/dynamodbstreams/latest/APIReference/API_ListStreams.html]
+type ListStreamsRequest struct {
+	ExclusiveStartStreamArn string `json:"ExclusiveStartStreamArn,omitempty"`
+	Limit                   int    `json:"Limit,omitempty"`
+	TableName               string `json:"TableName,omitempty"`
+}
+
+// Creates a new ListStreamsRequest.
+// (tableName string) Lists only the streams of the table, or all streams if empty.
+func NewListStreamsRequest(tableName string) *ListStreamsRequest {
+	return &ListStreamsRequest{TableName: tableName}
+}
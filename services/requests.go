@@ -67,6 +67,7 @@ func NewClientRequest(method, rawurl string, queryString interface{}) (*AWSReque
 // method expects POST or PUT
 // contentType expects a constant, e.g. CONTENT_TYPE_TEXT_PLAIN.
 // body expects string, io.Reader, []byte or a struct. Will panic otherwise on AWSRequest.BuildRequest().
+// Values added to FormPostValues() replace the body with the encoded form.
 func NewServerRequest(method, rawurl string, body interface{}) (*AWSRequest, error) {
 
 	u, err := url.ParseRequestURI(rawurl)
@@ -78,12 +79,23 @@ func NewServerRequest(method, rawurl string, body interface{}) (*AWSRequest, err
 		strings.ToUpper(method),
 		u,
 		nil,
-		nil,
+		&url.Values{},
 		&http.Header{},
 		body,
 	}, nil
 }
 
+// Creates a new POST AWSRequest with a form-encoded body, for Query APIs.
+// The arg 'form' can be a struct or nil. Add more values with FormPostValues().
+func NewFormPostRequest(rawurl string, form interface{}) (*AWSRequest, error) {
+
+	req, err := NewServerRequest("POST", rawurl, nil)
+	if err == nil && form != nil {
+		netutil.MergeValues(req.postVals, netutil.MarshalValues(form))
+	}
+	return req, err
+}
+
 func (r *AWSRequest) Method() string {
 	return r.method
 }
@@ -110,6 +122,10 @@ func (r *AWSRequest) BuildRequest() *http.Request {
 		r.u.RawQuery = r.queryVals.Encode()
 	}
 
+	if r.hasFormPostValues() {
+		r.header.Set("Content-Type", CONTENT_TYPE_APPLICATION_FORM_URLENCODED)
+	}
+
 	body, length := r.buildBody()
 
 	return &http.Request{
@@ -126,8 +142,17 @@ func (r *AWSRequest) BuildRequest() *http.Request {
 	}
 }
 
+func (r *AWSRequest) hasFormPostValues() bool {
+	return r.postVals != nil && len(*r.postVals) > 0
+}
+
 func (r *AWSRequest) buildBody() (io.ReadCloser, int64) {
 
+	if r.hasFormPostValues() {
+		form := r.postVals.Encode()
+		return ioutil.NopCloser(strings.NewReader(form)), int64(len(form))
+	}
+
 	if r.body == nil {
 		return ioutil.NopCloser(strings.NewReader(``)), 0
 	}
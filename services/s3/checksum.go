@@ -0,0 +1,180 @@
+package s3
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*	Checksum algorithms for end-to-end integrity checking.
+	CHECKSUM_MD5 = "MD5" Sent as Content-MD5 and verified against the ETag.
+	CHECKSUM_CRC32C = "CRC32C" Sent as X-Amz-Checksum-Crc32c.
+	CHECKSUM_SHA256 = "SHA256" Sent as X-Amz-Checksum-Sha256.
+	[http://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html] */
+type ChecksumAlgorithm string
+
+const (
+	CHECKSUM_MD5    ChecksumAlgorithm = "MD5"
+	CHECKSUM_CRC32C ChecksumAlgorithm = "CRC32C"
+	CHECKSUM_SHA256 ChecksumAlgorithm = "SHA256"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Returned when the checksum of the transferred content does not match the expected value.
+type ChecksumError struct {
+	Algorithm ChecksumAlgorithm
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumError) Error() string {
+	return "s3: " + string(e.Algorithm) + " checksum mismatch. Expected: " + e.Expected + ", Actual: " + e.Actual
+}
+
+// Computes the checksum of the content. MD5 is hex encoded to match the ETag;
+// CRC32C and SHA256 are base64 encoded to match the X-Amz-Checksum-* headers.
+func ComputeChecksum(algorithm ChecksumAlgorithm, content []byte) string {
+	d := newDigest(algorithm, 0)
+	d.Write(content)
+	return d.Sum()
+}
+
+// Computes the ETag S3 assigns to an object uploaded in parts of partSize bytes:
+// the hex MD5 of the concatenated part MD5s, followed by "-" and the number of parts.
+func ComputeMultipartETag(r io.Reader, partSize int64) (string, error) {
+	d := newDigest(CHECKSUM_MD5, partSize)
+	if _, err := io.Copy(d, r); err != nil {
+		return "", err
+	}
+	return d.Sum(), nil
+}
+
+// Wraps the content of a download. Reads return a *ChecksumError at EOF if the
+// checksum of the content does not match the expected value.
+// (partSize int64) The part size of a multipart ETag; only used with CHECKSUM_MD5.
+func NewChecksumReader(rc io.ReadCloser, algorithm ChecksumAlgorithm, expected string, partSize int64) io.ReadCloser {
+	return &checksumReader{rc, newDigest(algorithm, partSize), algorithm, expected, false}
+}
+
+/*****************************************************************************/
+
+type checksumReader struct {
+	rc        io.ReadCloser
+	digest    digest
+	algorithm ChecksumAlgorithm
+	expected  string
+	verified  bool
+}
+
+func (r *checksumReader) Read(p []byte) (n int, err error) {
+
+	n, err = r.rc.Read(p)
+	r.digest.Write(p[:n])
+
+	if err == io.EOF && !r.verified {
+		r.verified = true
+		if actual := r.digest.Sum(); actual != r.expected {
+			return n, &ChecksumError{r.algorithm, r.expected, actual}
+		}
+	}
+
+	return
+}
+
+func (r *checksumReader) Close() error {
+	return r.rc.Close()
+}
+
+// A running checksum encoded like the header it is compared with.
+type digest interface {
+	io.Writer
+	Sum() string
+}
+
+func newDigest(algorithm ChecksumAlgorithm, partSize int64) digest {
+
+	switch algorithm {
+	case CHECKSUM_CRC32C:
+		return &encodedDigest{crc32.New(crc32cTable), base64.StdEncoding.EncodeToString}
+	case CHECKSUM_SHA256:
+		return &encodedDigest{sha256.New(), base64.StdEncoding.EncodeToString}
+	}
+
+	if partSize > 0 {
+		return &multipartDigest{partSize: partSize, part: md5.New()}
+	}
+	return &encodedDigest{md5.New(), hex.EncodeToString}
+}
+
+type encodedDigest struct {
+	h      hash.Hash
+	encode func([]byte) string
+}
+
+func (d *encodedDigest) Write(p []byte) (int, error) {
+	return d.h.Write(p)
+}
+
+func (d *encodedDigest) Sum() string {
+	return d.encode(d.h.Sum(nil))
+}
+
+// Computes a multipart ETag.
+type multipartDigest struct {
+	partSize int64
+	written  int64
+	part     hash.Hash
+	sums     []byte
+	parts    int
+}
+
+func (d *multipartDigest) Write(p []byte) (int, error) {
+
+	n := len(p)
+	for len(p) > 0 {
+		chunk := d.partSize - d.written
+		if int64(len(p)) < chunk {
+			chunk = int64(len(p))
+		}
+		d.part.Write(p[:chunk])
+		d.written += chunk
+		p = p[chunk:]
+
+		if d.written == d.partSize {
+			d.endPart()
+		}
+	}
+	return n, nil
+}
+
+func (d *multipartDigest) Sum() string {
+
+	if d.written > 0 || d.parts == 0 {
+		d.endPart()
+	}
+	sum := md5.Sum(d.sums)
+	return hex.EncodeToString(sum[:]) + "-" + strconv.Itoa(d.parts)
+}
+
+func (d *multipartDigest) endPart() {
+	d.sums = d.part.Sum(d.sums)
+	d.parts++
+	d.written = 0
+	d.part.Reset()
+}
+
+// Returns the number of parts of a multipart ETag, or 0 for a single part ETag.
+func etagParts(etag string) int {
+	if i := strings.LastIndex(etag, "-"); i >= 0 {
+		parts, _ := strconv.Atoi(etag[i+1:])
+		return parts
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+)
+
+func TestComputeChecksum(t *testing.T) {
+
+	content := []byte("123456789")
+
+	if sum := ComputeChecksum(CHECKSUM_CRC32C, content); sum != "4waSgw==" {
+		t.Errorf("CRC32C: %s", sum)
+	}
+	if sum := ComputeChecksum(CHECKSUM_MD5, content); sum != "25f9e794323b453885f5181f1b624d0b" {
+		t.Errorf("MD5: %s", sum)
+	}
+	if sum := ComputeChecksum(CHECKSUM_SHA256, content); sum != "FeKw08M4keuw8e9gnsQZQgwg4yDOlMZfvIwzEkSOsiU=" {
+		t.Errorf("SHA256: %s", sum)
+	}
+}
+
+func TestComputeMultipartETag(t *testing.T) {
+
+	content := bytes.Repeat([]byte("abcdefghij"), 25)
+	partOne, partTwo, partThree := md5.Sum(content[:100]), md5.Sum(content[100:200]), md5.Sum(content[200:])
+	expected := md5.Sum(append(append(partOne[:], partTwo[:]...), partThree[:]...))
+
+	etag, err := ComputeMultipartETag(bytes.NewReader(content), 100)
+	if err != nil || etag != hex.EncodeToString(expected[:])+"-3" {
+		t.Errorf("ComputeMultipartETag returned %s, %v", etag, err)
+	}
+}
+
+func TestChecksumReader(t *testing.T) {
+
+	content := []byte("123456789")
+
+	r := NewChecksumReader(ioutil.NopCloser(bytes.NewReader(content)), CHECKSUM_CRC32C, "4waSgw==", 0)
+	if _, err := ioutil.ReadAll(r); err != nil {
+		t.Errorf("Expected a matching checksum, got %v", err)
+	}
+
+	r = NewChecksumReader(ioutil.NopCloser(bytes.NewReader(content)), CHECKSUM_MD5, "00000000000000000000000000000000", 0)
+	if _, err := ioutil.ReadAll(r); err == nil {
+		t.Error("Expected a *ChecksumError.")
+	} else if _, ok := err.(*ChecksumError); !ok {
+		t.Errorf("Expected a *ChecksumError, got %v", err)
+	}
+}
+
+func TestVerifyContentComposite(t *testing.T) {
+
+	content := []byte("123456789")
+	sum := md5.Sum(content)
+
+	// A composite checksum is skipped and the single part ETag verified instead.
+	hdrs := &GetObjectHeaderResponse{ChecksumSHA256: "FeKw08M4keuw8e9gnsQZQgwg4yDOlMZfvIwzEkSOsiU=-2", ETag: `"` + hex.EncodeToString(sum[:]) + `"`}
+	if _, err := ioutil.ReadAll(verifyContent(ioutil.NopCloser(bytes.NewReader(content)), hdrs, 0)); err != nil {
+		t.Errorf("Expected the ETag to match, got %v", err)
+	}
+
+	hdrs.ETag = `"00000000000000000000000000000000"`
+	if _, err := ioutil.ReadAll(verifyContent(ioutil.NopCloser(bytes.NewReader(content)), hdrs, 0)); err == nil {
+		t.Error("Expected the ETag to be verified.")
+	}
+
+	// A composite checksum of a multipart object without a part size is not verified.
+	hdrs = &GetObjectHeaderResponse{ChecksumCRC32C: "AAAAAA==-3", ETag: `"00000000000000000000000000000000-3"`}
+	if _, err := ioutil.ReadAll(verifyContent(ioutil.NopCloser(bytes.NewReader(content)), hdrs, 0)); err != nil {
+		t.Errorf("Expected no verification, got %v", err)
+	}
+}
@@ -45,13 +45,16 @@ type Contents struct {
 	Owner        Owner     `xml:"Owner"`
 }
 
+// Type: XML
+// [http://docs.aws.amazon.com/AmazonS3/latest/API/multiobjectdeleteapi.html]
 type Delete struct {
-	Object []Object `xml:"Object"`
-	Quiet  bool     `xml:"Quiet"`
+	XMLName xml.Name `xml:"Delete"`
+	Quiet   bool     `xml:"Quiet"`
+	Object  []Object `xml:"Object"`
 }
 
 type Object struct {
-	Key       string `xml:"Name"`
+	Key       string `xml:"Key"`
 	VersionId string `xml:"VersionId,omitempty"`
 }
 
@@ -65,7 +68,7 @@ type CreateBucketConfiguration struct {
 // Type: Header Values
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/multiobjectdeleteapi.html]
 type DeleteMultipleObjectsHeaders struct {
-	MFA string `name:"X-Amz-Mfa"`
+	MFA string `name:"X-Amz-Mfa,omitempty"`
 }
 
 // Type: Header Values
@@ -90,11 +93,11 @@ type ObjectMetadata struct {
 //
 // []
 type ListObjects struct {
-	Delimiter    string `name:"delimiter"`
-	EncodingType string `name:"encoding-type"`
-	Marker       string `name:"marker"`
+	Delimiter    string `name:"delimiter,omitempty"`
+	EncodingType string `name:"encoding-type,omitempty"`
+	Marker       string `name:"marker,omitempty"`
 	MaxKeys      string `name:"max-keys" default:"1000"`
-	Prefix       string `name:"prefix"`
+	Prefix       string `name:"prefix,omitempty"`
 }
 
 // There are times when you want to override certain response header values in a GET response.
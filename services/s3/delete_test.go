@@ -0,0 +1,151 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// A bucket server listing the keys in pages of up to 1000 and deleting them with Multi-Object Delete.
+// Keys in denied are reported as errors. The number of keys of each delete request is kept in batches.
+type deleteServer struct {
+	t       *testing.T
+	mutex   sync.Mutex
+	keys    []string
+	denied  map[string]bool
+	batches []int
+}
+
+func (s *deleteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method == "GET" {
+		s.list(w, r)
+		return
+	}
+
+	if _, ok := r.URL.Query()["delete"]; r.Method != "POST" || r.URL.Path != "/bucket" || !ok {
+		s.t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	sum := md5.Sum(body)
+	if header := r.Header.Get("Content-Md5"); header != base64.StdEncoding.EncodeToString(sum[:]) {
+		s.t.Errorf("Expected the Content-MD5 of the body, was %s", header)
+	}
+
+	var d Delete
+	if err := xml.Unmarshal(body, &d); err != nil {
+		s.t.Error(err)
+		return
+	}
+
+	s.mutex.Lock()
+	s.batches = append(s.batches, len(d.Object))
+	s.mutex.Unlock()
+
+	w.Write([]byte("<DeleteResult>"))
+	for _, o := range d.Object {
+		if s.denied[o.Key] {
+			fmt.Fprintf(w, "<Error><Key>%s</Key><Code>AccessDenied</Code><Message>Access Denied</Message></Error>", o.Key)
+		} else {
+			fmt.Fprintf(w, "<Deleted><Key>%s</Key></Deleted>", o.Key)
+		}
+	}
+	w.Write([]byte("</DeleteResult>"))
+}
+
+func (s *deleteServer) list(w http.ResponseWriter, r *http.Request) {
+
+	marker := r.URL.Query().Get("marker")
+	maxKeys, _ := strconv.Atoi(r.URL.Query().Get("max-keys"))
+	if r.URL.Query().Get("prefix") != "p/" || maxKeys != 1000 {
+		s.t.Errorf("Unexpected list request %s", r.URL)
+	}
+
+	w.Write([]byte("<ListBucketResult>"))
+	n := 0
+	for _, key := range s.keys {
+		if key <= marker {
+			continue
+		}
+		if n == maxKeys {
+			w.Write([]byte("<IsTruncated>true</IsTruncated>"))
+			break
+		}
+		fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", key)
+		n++
+	}
+	w.Write([]byte("</ListBucketResult>"))
+}
+
+func newDeleteServer(t *testing.T, count int) (*deleteServer, *httptest.Server, *S3Service) {
+
+	s := &deleteServer{t: t, denied: map[string]bool{}}
+	for i := 0; i < count; i++ {
+		s.keys = append(s.keys, "p/"+strconv.Itoa(10000 + i)[1:])
+	}
+
+	server := httptest.NewServer(s)
+	service := exampleService()
+	service.endpoint = server.URL
+	return s, server, service
+}
+
+func TestDeleteMultipleObjects(t *testing.T) {
+
+	s, server, service := newDeleteServer(t, 0)
+	defer server.Close()
+	s.denied["p/b"] = true
+
+	result, err := service.DeleteMultipleObjects(NewDeleteMultipleObjectsRequest("bucket", "p/a", "p/b", "p/c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Deleted) != 2 || result.Deleted[0].Key != "p/a" || result.Deleted[1].Key != "p/c" {
+		t.Errorf("Unexpected deleted objects %v", result.Deleted)
+	}
+	if len(result.Error) != 1 || result.Error[0].Key != "p/b" || result.Error[0].Code != "AccessDenied" {
+		t.Errorf("Unexpected errors %v", result.Error)
+	}
+	if len(s.batches) != 1 || s.batches[0] != 3 {
+		t.Errorf("Expected a single request of 3 keys, was %v", s.batches)
+	}
+}
+
+func TestDeleteObjectsByPrefix(t *testing.T) {
+
+	s, server, service := newDeleteServer(t, 2500)
+	defer server.Close()
+	s.denied["p/1000"] = true
+	s.denied["p/2499"] = true
+
+	result, err := service.DeleteObjectsByPrefix("bucket", "p/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Ints(s.batches)
+	if fmt.Sprint(s.batches) != "[500 1000 1000]" {
+		t.Errorf("Expected requests of 1000, 1000 and 500 keys, was %v", s.batches)
+	}
+	if len(result.Deleted) != 2498 || len(result.Errors) != 2 {
+		t.Errorf("Expected 2498 deleted and 2 errors, was %d and %d", len(result.Deleted), len(result.Errors))
+	}
+
+	keys := map[string]bool{}
+	for _, e := range result.Errors {
+		keys[e.Key] = true
+	}
+	if !keys["p/1000"] || !keys["p/2499"] {
+		t.Errorf("Unexpected errors %v", result.Errors)
+	}
+}
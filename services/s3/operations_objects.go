@@ -51,11 +51,13 @@ type GetObjectRequest struct {
 	ObjectName              string
 	Constraints             *Constraints             // Can be nil
 	ResponseHeaderOverrides *ResponseHeaderOverrides // Can be nil
+	VerifyChecksum          bool                     // Verifies the content against the stored checksum or ETag while reading.
+	PartSize                int64                    // The part size used to upload a multipart object. Required to verify a multipart ETag.
 }
 
 // Creates a new GetObjectRequest.
 func NewGetObjectRequest(bucketName, objectName string) *GetObjectRequest {
-	return &GetObjectRequest{bucketName, objectName, nil, nil}
+	return &GetObjectRequest{BucketName: bucketName, ObjectName: objectName}
 }
 
 /*****************************************************************************/
@@ -64,13 +66,14 @@ func NewGetObjectRequest(bucketName, objectName string) *GetObjectRequest {
 // You must have WRITE permissions on a bucket to add an object to it.
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPUT.html]
 type PutObjectRequest struct {
-	BucketName     string
-	ObjectName     string
-	Content        io.Reader
-	ObjectMetadata *ObjectMetadata
+	BucketName        string
+	ObjectName        string
+	Content           io.Reader
+	ObjectMetadata    *ObjectMetadata
+	ChecksumAlgorithm ChecksumAlgorithm // Sends and verifies a checksum of the content. Can be empty.
 }
 
 // Creates a new PutObjectRequest.
 func NewPutObjectRequest(bucketName, objectName string, content io.Reader, metadata *ObjectMetadata) *PutObjectRequest {
-	return &PutObjectRequest{bucketName, objectName, content, metadata}
+	return &PutObjectRequest{BucketName: bucketName, ObjectName: objectName, Content: content, ObjectMetadata: metadata}
 }
@@ -0,0 +1,96 @@
+package s3
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout of the POST policy expiration.
+const POST_POLICY_EXPIRATION_FORMAT = "2006-01-02T15:04:05.000Z"
+
+/*****************************************************************************/
+
+// A POST policy document for browser-based uploads using HTML forms.
+// The policy restricts what the browser may upload. Use s3.NewPostPolicy() and S3Service.PresignPostPolicy().
+// [http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html]
+type PostPolicy struct {
+	BucketName string
+	Expiration time.Time
+	conditions []interface{}
+	formFields map[string]string
+}
+
+// Creates a new PostPolicy.
+// (bucketName string) The bucket to upload to.
+// (expiration time.Time) The time after which the policy is no longer accepted.
+func NewPostPolicy(bucketName string, expiration time.Time) *PostPolicy {
+	return &PostPolicy{BucketName: bucketName, Expiration: expiration, formFields: map[string]string{}}
+}
+
+// Restricts the upload to the exact object key.
+func (p *PostPolicy) SetKey(key string) {
+	p.addEquals("key", key)
+}
+
+// Restricts the upload to object keys beginning with the prefix.
+// The form field "key" defaults to prefix + "${filename}", which S3 replaces with the uploaded file name.
+func (p *PostPolicy) SetKeyStartsWith(prefix string) {
+	p.addStartsWith("key", prefix)
+	p.formFields["key"] = prefix + "${filename}"
+}
+
+// Restricts the size of the uploaded content in bytes.
+func (p *PostPolicy) SetContentLengthRange(min, max int64) {
+	p.conditions = append(p.conditions, []interface{}{"content-length-range", min, max})
+}
+
+// Restricts the Content-Type to the exact value.
+func (p *PostPolicy) SetContentType(contentType string) {
+	p.addEquals("Content-Type", contentType)
+}
+
+// Restricts the Content-Type to values beginning with the prefix, e.g. "image/".
+func (p *PostPolicy) SetContentTypeStartsWith(prefix string) {
+	p.addStartsWith("Content-Type", prefix)
+}
+
+// The URL to which the browser is redirected after a successful upload.
+func (p *PostPolicy) SetSuccessActionRedirect(redirect string) {
+	p.addEquals("success_action_redirect", redirect)
+}
+
+// The status code returned to the browser after a successful upload when no redirect is set.
+// Valid Values: 200 | 201 | 204
+func (p *PostPolicy) SetSuccessActionStatus(status int) {
+	p.addEquals("success_action_status", strconv.Itoa(status))
+}
+
+// Sets the canned ACL of the uploaded object.
+func (p *PostPolicy) SetCannedACL(acl CannedACL) {
+	p.addEquals("acl", string(acl))
+}
+
+// Adds user metadata to the uploaded object. The name is prefixed with "x-amz-meta-".
+func (p *PostPolicy) AddMetadata(name, value string) {
+	p.addEquals("x-amz-meta-"+strings.ToLower(name), value)
+}
+
+func (p *PostPolicy) addEquals(field, value string) {
+	p.conditions = append(p.conditions, map[string]string{field: value})
+	p.formFields[field] = value
+}
+
+func (p *PostPolicy) addStartsWith(field, prefix string) {
+	p.conditions = append(p.conditions, []interface{}{"starts-with", "$" + field, prefix})
+}
+
+/*****************************************************************************/
+
+// The signed POST policy and the form fields to include in the HTML form.
+// The file field must be the last field in the form.
+type PostPolicyForm struct {
+	Url    string            // The form action.
+	Policy string            // The base64 encoded policy document.
+	Fields map[string]string // All form fields, including "policy" and "x-amz-signature".
+}
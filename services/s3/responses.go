@@ -26,7 +26,12 @@ type ListObjectsResult struct {
 
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectGET.html]
 type GetObjectHeaderResponse struct {
+	ChecksumCRC32C                       string            `name:"X-Amz-Checksum-Crc32c"`
+	ChecksumSHA256                       string            `name:"X-Amz-Checksum-Sha256"`
+	ContentLength                        int64             `name:"Content-Length"`
+	ContentRange                         string            `name:"Content-Range"`
 	DeleteMarker                         string            `name:"X-Amz-Delete-Marker"`
+	ETag                                 string            `name:"Etag"`
 	Expiration                           *time.Time        `name:"X-Amz-Expiration" format:"Mon, 02 Jan 2006 15:04:05 MST"`
 	Metadata                             map[string]string `name:"X-Amz-Meta-*"`
 	MissingMetadata                      int               `name:"X-Amz-Missing-Meta"`
@@ -46,6 +51,9 @@ type DeleteObjectHeaderResponse struct {
 
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPUT.html]
 type PutObjectHeaderResponse struct {
+	ChecksumCRC32C                       string    `name:"X-Amz-Checksum-Crc32c"`
+	ChecksumSHA256                       string    `name:"X-Amz-Checksum-Sha256"`
+	ETag                                 string    `name:"Etag"`
 	Expiration                           time.Time `name:"X-Amz-Expiration" format:"Mon, 02 Jan 2006 15:04:05 MST"`
 	ServerSideEncryption                 string    `name:"X-Amz-Server-Side-Encryption"`
 	ServerSideEncryptionCustomerAlgorith string    `name:"X-Amz-Server-Side-Encryption-Customer-Algorithm"`
@@ -55,16 +63,32 @@ type PutObjectHeaderResponse struct {
 
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/multiobjectdeleteapi.html]
 type DeleteResult struct {
-	Deleted []struct {
-		Key                   string `xml:"Key"`
-		VersionId             string `xml:"VersionId"`
-		DeleteMarker          bool   `xml:"DeleteMarker"`
-		DeleteMarkerVersionId string `xml:"DeleteMarkerVersionId"`
-	} `xml:"Deleted"`
-	Error []struct {
-		Key       string `xml:"Key"`
-		VersionId string `xml:"VersionId"`
-		Code      string `xml:"Code"`
-		Message   string `xml:"Message"`
-	} `xml:"Error"`
+	Deleted []DeletedObject `xml:"Deleted"`
+	Error   []DeleteError   `xml:"Error"`
+}
+
+// An object removed by a Multi-Object Delete.
+type DeletedObject struct {
+	Key                   string `xml:"Key"`
+	VersionId             string `xml:"VersionId"`
+	DeleteMarker          bool   `xml:"DeleteMarker"`
+	DeleteMarkerVersionId string `xml:"DeleteMarkerVersionId"`
+}
+
+// An object a Multi-Object Delete failed to remove.
+type DeleteError struct {
+	Key       string `xml:"Key"`
+	VersionId string `xml:"VersionId"`
+	Code      string `xml:"Code"`
+	Message   string `xml:"Message"`
+}
+
+func (e DeleteError) Error() string {
+	return "Key: " + e.Key + ", Code: " + e.Code + ", Message: " + e.Message
+}
+
+// The aggregated result of S3Service.DeleteObjectsByPrefix().
+type DeleteObjectsByPrefixResult struct {
+	Deleted []DeletedObject
+	Errors  []DeleteError
 }
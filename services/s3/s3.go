@@ -14,10 +14,18 @@ import (
 	"github.com/twhello/aws-to-go/regions"
 	"github.com/twhello/aws-to-go/services"
 	"github.com/twhello/aws-to-go/util/netutil"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const ServiceName = "s3"
@@ -136,7 +144,7 @@ func (s3 *S3Service) ListBuckets() (buckets *ListBucketsResult, err error) {
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/RESTBucketGET.html]
 func (s3 *S3Service) ListObjects(lor *ListObjectsRequest) (objs *ListObjectsResult, err error) {
 
-	req, err := services.NewClientRequest("GET", s3.Endpoint()+"/"+lor.BucketName, lor)
+	req, err := services.NewClientRequest("GET", s3.Endpoint()+"/"+lor.BucketName, lor.ListObjects)
 	if err == nil {
 		objs = new(ListObjectsResult)
 		_, err = s3.SignAndDo(req, objs)
@@ -168,22 +176,98 @@ func (s3 *S3Service) DeleteObject(dor *DeleteObjectRequest) (hdrs *DeleteObjectH
 
 // The Multi-Object Delete operation enables you to delete multiple objects from a bucket using a single HTTP request.
 // If you know the object keys that you want to delete, then this operation provides a suitable alternative to sending
-// individual delete requests (see DELETE Object), reducing per-request overhead.
+// individual delete requests (see DELETE Object), reducing per-request overhead. A request can contain up to 1000 keys.
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/multiobjectdeleteapi.html]
 func (s3 *S3Service) DeleteMultipleObjects(dmor *DeleteMultipleObjectsRequest) (result *DeleteResult, err error) {
 
-	req, err := services.NewServerRequest("POST", s3.Endpoint()+"/"+dmor.BucketName, dmor.Headers)
+	body, err := xml.Marshal(dmor.Delete)
+	if err != nil {
+		return
+	}
+	sum := md5.Sum(body)
+
+	req, err := services.NewServerRequest("POST", s3.Endpoint()+"/"+dmor.BucketName+"?delete", body)
 	if err == nil {
 
 		req.Header().Set("Content-Type", services.CONTENT_TYPE_APPLICATION_XML)
-		req.QueryStringValues().Add("delete", "1")
+		req.Header().Set("Content-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+		netutil.MergeHeaders(req.Header(), netutil.MarshalHeader(dmor.Headers))
+
 		result = new(DeleteResult)
 		_, err = s3.SignAndDo(req, result)
 	}
 	return
 }
 
+// Deletes every object whose key begins with the prefix. Keys are listed with ListObjects and deleted
+// concurrently in DeleteMultipleObjects requests of up to 1000 keys. Keys S3 could not delete are
+// returned in the result's Errors; err is only set when a list or delete request fails.
+func (s3 *S3Service) DeleteObjectsByPrefix(bucketName, prefix string) (result *DeleteObjectsByPrefixResult, err error) {
+
+	const maxKeys = 1000
+	const concurrency = 4
+
+	result = new(DeleteObjectsByPrefixResult)
+	m := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	batches := make(chan []string)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for keys := range batches {
+				dr, e := s3.DeleteMultipleObjects(NewDeleteMultipleObjectsRequest(bucketName, keys...))
+				m.Lock()
+				if e != nil {
+					if err == nil {
+						err = e
+					}
+				} else {
+					result.Deleted = append(result.Deleted, dr.Deleted...)
+					result.Errors = append(result.Errors, dr.Error...)
+				}
+				m.Unlock()
+			}
+		}()
+	}
+
+	lor := NewListObjectsRequest(bucketName)
+	lor.ListObjects = &ListObjects{Prefix: prefix, MaxKeys: strconv.Itoa(maxKeys)}
+
+	for {
+		objs, e := s3.ListObjects(lor)
+		if e != nil {
+			m.Lock()
+			err = e
+			m.Unlock()
+			break
+		}
+
+		if len(objs.Contents) > 0 {
+			keys := make([]string, len(objs.Contents))
+			for i, c := range objs.Contents {
+				keys[i] = c.Key
+			}
+			batches <- keys
+		}
+
+		if !objs.IsTruncated || len(objs.Contents) == 0 {
+			break
+		}
+		lor.ListObjects.Marker = objs.Contents[len(objs.Contents)-1].Key
+	}
+
+	close(batches)
+	wg.Wait()
+
+	return
+}
+
 // This implementation of the GET operation retrieves objects from Amazon S3. To use GET, you must have READ access to the object.
+// If GetObjectRequest.VerifyChecksum is set, reading the content returns a *ChecksumError at EOF when the content does not
+// match the stored SHA256 or CRC32C checksum, or the MD5 ETag. Ranged reads and objects whose ETag is not an MD5 are not verified.
+// Composite checksums of multipart uploads are not verified; the multipart ETag is used if GetObjectRequest.PartSize is set.
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectGET.html]
 func (s3 *S3Service) GetObject(gor *GetObjectRequest) (content io.ReadCloser, hdrs *GetObjectHeaderResponse, err error) {
 
@@ -191,19 +275,53 @@ func (s3 *S3Service) GetObject(gor *GetObjectRequest) (content io.ReadCloser, hd
 	if err == nil {
 		
 		netutil.MergeHeaders(req.Header(), netutil.MarshalHeader(gor.Constraints))
-		
+		if gor.VerifyChecksum {
+			req.Header().Set("X-Amz-Checksum-Mode", "ENABLED")
+		}
+
 		var resp *http.Response
 		resp, err = s3.SignAndDo(req, nil)
 		if err == nil {
 			content = resp.Body
 			hdrs = new(GetObjectHeaderResponse)
 			netutil.UnmarshalHeader(resp.Header, hdrs)
+
+			if gor.VerifyChecksum && hdrs.ContentRange == "" {
+				content = verifyContent(content, hdrs, gor.PartSize)
+			}
 		}
 	}
 
 	return
 }
 
+// Wraps the content with a checksum reader for the strongest checksum available.
+// Composite "<checksum>-<parts>" checksums of multipart uploads fall back to the ETag.
+func verifyContent(content io.ReadCloser, hdrs *GetObjectHeaderResponse, partSize int64) io.ReadCloser {
+
+	switch {
+	case hdrs.ChecksumSHA256 != "" && etagParts(hdrs.ChecksumSHA256) == 0:
+		return NewChecksumReader(content, CHECKSUM_SHA256, hdrs.ChecksumSHA256, 0)
+
+	case hdrs.ChecksumCRC32C != "" && etagParts(hdrs.ChecksumCRC32C) == 0:
+		return NewChecksumReader(content, CHECKSUM_CRC32C, hdrs.ChecksumCRC32C, 0)
+	}
+
+	// The ETag of SSE-KMS and SSE-C encrypted objects is not an MD5 of the content.
+	if hdrs.ServerSideEncryption == "aws:kms" || hdrs.ServerSideEncryptionCustomerAlgorith != "" {
+		return content
+	}
+
+	etag := strings.Trim(hdrs.ETag, `"`)
+	if parts := etagParts(etag); parts == 0 {
+		return NewChecksumReader(content, CHECKSUM_MD5, etag, 0)
+	} else if partSize > 0 {
+		return NewChecksumReader(content, CHECKSUM_MD5, etag, partSize)
+	}
+
+	return content
+}
+
 // Gets the metadata for the specified Amazon S3 object without actually fetching the object itself.
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectHEAD.html]
 func (s3 *S3Service) GetObjectMetadata(gor *GetObjectRequest) (hdrs *GetObjectHeaderResponse, err error) {
@@ -227,24 +345,127 @@ func (s3 *S3Service) GetObjectMetadata(gor *GetObjectRequest) (hdrs *GetObjectHe
 // Uploads the specified input stream and object metadata to Amazon S3 under the specified bucket and key name.
 // This implementation of the PUT operation adds an object to a bucket. You must have WRITE permissions on a bucket to add an object to it.
 // [http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPUT.html]
+//
+// If PutObjectRequest.ChecksumAlgorithm is set, the checksum of the content is sent with the request so S3 rejects
+// corrupted uploads, and the checksum returned by S3 (or the ETag for MD5) is verified. A mismatch returns a *ChecksumError.
 func (s3 *S3Service) PutObject(por *PutObjectRequest) (hdrs *PutObjectHeaderResponse, err error) {
 
-	req, err := services.NewServerRequest("PUT", s3.Endpoint()+"/"+por.BucketName+"/"+por.ObjectName, por.Content)
+	var body interface{} = por.Content
+	checksum := ""
+
+	if por.ChecksumAlgorithm != "" {
+		content := []byte{}
+		if por.Content != nil {
+			if content, err = ioutil.ReadAll(por.Content); err != nil {
+				return
+			}
+		}
+		body = content
+		checksum = ComputeChecksum(por.ChecksumAlgorithm, content)
+	}
+
+	req, err := services.NewServerRequest("PUT", s3.Endpoint()+"/"+por.BucketName+"/"+por.ObjectName, body)
 	if err == nil {
 		
 		netutil.MergeHeaders(req.Header(), netutil.MarshalHeader(por.ObjectMetadata))
 
+		switch por.ChecksumAlgorithm {
+		case CHECKSUM_MD5:
+			b, _ := hex.DecodeString(checksum)
+			req.Header().Set("Content-Md5", base64.StdEncoding.EncodeToString(b))
+		case CHECKSUM_CRC32C:
+			req.Header().Set("X-Amz-Checksum-Crc32c", checksum)
+		case CHECKSUM_SHA256:
+			req.Header().Set("X-Amz-Checksum-Sha256", checksum)
+		}
+
 		var resp *http.Response
 		resp, err = s3.SignAndDo(req, nil)
 		if err == nil {
 			hdrs = new(PutObjectHeaderResponse)
 			netutil.UnmarshalHeader(resp.Header, hdrs)
+			err = verifyPut(por, hdrs, checksum)
 		}
 	}
 
 	return
 }
 
+// Compares the checksum returned by S3 with the checksum that was sent.
+func verifyPut(por *PutObjectRequest, hdrs *PutObjectHeaderResponse, checksum string) error {
+
+	actual := ""
+
+	switch por.ChecksumAlgorithm {
+	case CHECKSUM_MD5:
+		// The ETag of SSE-KMS and SSE-C encrypted objects is not an MD5 of the content.
+		if hdrs.ServerSideEncryption == "aws:kms" || hdrs.ServerSideEncryptionCustomerAlgorith != "" {
+			return nil
+		}
+		actual = strings.Trim(hdrs.ETag, `"`)
+	case CHECKSUM_CRC32C:
+		actual = hdrs.ChecksumCRC32C
+	case CHECKSUM_SHA256:
+		actual = hdrs.ChecksumSHA256
+	}
+
+	if actual != "" && actual != checksum {
+		return &ChecksumError{por.ChecksumAlgorithm, checksum, actual}
+	}
+	return nil
+}
+
+/******************************************************************************
+ * S3 Browser-Based Uploads
+ */
+
+// Signs the POST policy with Signature Version 4 and returns the form fields for a browser-based upload.
+// [http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-UsingHTTPPOST.html]
+func (s3 *S3Service) PresignPostPolicy(policy *PostPolicy) (form *PostPolicyForm, err error) {
+	return s3.presignPostPolicy(policy, time.Now().UTC())
+}
+
+func (s3 *S3Service) presignPostPolicy(policy *PostPolicy, now time.Time) (form *PostPolicyForm, err error) {
+
+	signer := auth.V4Signer{Credentials: s3.cred, AWSService: s3}
+	credential := s3.cred.AccessKeyId() + "/" + signer.CredentialScope(now)
+	date := now.Format(auth.TIME_FORMAT)
+
+	conditions := []interface{}{
+		map[string]string{"bucket": policy.BucketName},
+		map[string]string{"x-amz-algorithm": auth.ALGORITHM},
+		map[string]string{"x-amz-credential": credential},
+		map[string]string{"x-amz-date": date},
+	}
+
+	document, err := json.Marshal(map[string]interface{}{
+		"expiration": policy.Expiration.UTC().Format(POST_POLICY_EXPIRATION_FORMAT),
+		"conditions": append(conditions, policy.conditions...),
+	})
+	if err != nil {
+		return
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(document)
+
+	fields := make(map[string]string, len(policy.formFields)+5)
+	for k, v := range policy.formFields {
+		fields[k] = v
+	}
+	fields["policy"] = encoded
+	fields["x-amz-algorithm"] = auth.ALGORITHM
+	fields["x-amz-credential"] = credential
+	fields["x-amz-date"] = date
+	fields["x-amz-signature"] = signer.SignString(now, encoded)
+
+	endpoint := s3.Endpoint()[0:8] + policy.BucketName + "." + s3.Endpoint()[8:] + "/"
+	if strings.ContainsRune(policy.BucketName, '.') {
+		endpoint = s3.Endpoint() + "/" + policy.BucketName + "/"
+	}
+
+	return &PostPolicyForm{endpoint, encoded, fields}, nil
+}
+
 /******************************************************************************/
 
 // Creates a new S3Service.
@@ -0,0 +1,85 @@
+package s3crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// Wrapping algorithm used by the SymmetricKeyProvider.
+const WRAP_ALGORITHM_AES_GCM = "AES/GCM"
+
+// A master key provider encrypts (wraps) and decrypts (unwraps) the per-object data keys.
+// The material description is stored with the object and handed back on UnwrapKey so
+// providers can select the correct master key.
+type IMasterKeyProvider interface {
+	MaterialDescription() map[string]string
+	UnwrapKey(wrappedKey []byte, materialDescription map[string]string) ([]byte, error)
+	WrapAlgorithm() string
+	WrapKey(dataKey []byte) ([]byte, error)
+}
+
+/*****************************************************************************/
+
+// Wraps data keys with a local AES master key using AES-GCM.
+// The wrapped key is encoded as nonce || ciphertext || tag.
+type SymmetricKeyProvider struct {
+	aead                cipher.AEAD
+	materialDescription map[string]string
+}
+
+// Creates a new SymmetricKeyProvider.
+// (masterKey []byte) An AES key of 16, 24 or 32 bytes.
+// (materialDescription map[string]string) Describes the master key. Can be nil.
+func NewSymmetricKeyProvider(masterKey []byte, materialDescription map[string]string) (*SymmetricKeyProvider, error) {
+
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if materialDescription == nil {
+		materialDescription = map[string]string{}
+	}
+
+	return &SymmetricKeyProvider{aead, materialDescription}, nil
+}
+
+// Returns the description of the master key.
+func (p *SymmetricKeyProvider) MaterialDescription() map[string]string {
+	return p.materialDescription
+}
+
+// Returns WRAP_ALGORITHM_AES_GCM.
+func (p *SymmetricKeyProvider) WrapAlgorithm() string {
+	return WRAP_ALGORITHM_AES_GCM
+}
+
+// Encrypts the data key with the master key.
+func (p *SymmetricKeyProvider) WrapKey(dataKey []byte) ([]byte, error) {
+
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return p.aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+// Decrypts the data key with the master key.
+func (p *SymmetricKeyProvider) UnwrapKey(wrappedKey []byte, materialDescription map[string]string) ([]byte, error) {
+
+	nonceSize := p.aead.NonceSize()
+	if len(wrappedKey) < nonceSize {
+		return nil, errors.New("s3crypto: The wrapped key is too short.")
+	}
+
+	return p.aead.Open(nil, wrappedKey[:nonceSize], wrappedKey[nonceSize:], nil)
+}
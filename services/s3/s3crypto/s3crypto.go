@@ -0,0 +1,322 @@
+//
+// Client-side envelope encryption for Amazon S3 objects.
+//
+// Each object is encrypted with its own AES-256 data key using AES-GCM. The data key
+// is wrapped by a master key provider and stored, with the IV and the material
+// description, in the object's user metadata (X-Amz-Meta-*). Reads unwrap the data
+// key and decrypt the content transparently, including ranged reads.
+//
+// [http://docs.aws.amazon.com/AmazonS3/latest/dev/UsingClientSideEncryption.html]
+//
+package s3crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/twhello/aws-to-go/services/s3"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content encryption algorithm.
+const CEK_ALGORITHM_AES_GCM = "AES/GCM/NoPadding"
+
+// Envelope keys stored in ObjectMetadata.Metadata.
+const (
+	META_KEY                        = "X-Amz-Key-V2"
+	META_IV                         = "X-Amz-Iv"
+	META_CEK_ALG                    = "X-Amz-Cek-Alg"
+	META_WRAP_ALG                   = "X-Amz-Wrap-Alg"
+	META_MATDESC                    = "X-Amz-Matdesc"
+	META_TAG_LEN                    = "X-Amz-Tag-Len"
+	META_UNENCRYPTED_CONTENT_LENGTH = "X-Amz-Unencrypted-Content-Length"
+)
+
+const (
+	dataKeySize = 32
+	nonceSize   = 12
+	tagSize     = 16
+)
+
+/*****************************************************************************/
+
+// Wraps an S3Service to encrypt objects before upload and decrypt them after download.
+// Use s3crypto.NewClient().
+type S3CryptoClient struct {
+	S3Service   *s3.S3Service
+	KeyProvider IMasterKeyProvider
+}
+
+// Creates a new S3CryptoClient.
+func NewClient(s3Service *s3.S3Service, keyProvider IMasterKeyProvider) *S3CryptoClient {
+	return &S3CryptoClient{s3Service, keyProvider}
+}
+
+// Encrypts the content with a new data key and uploads it with the envelope in the object metadata.
+// The request is not modified.
+func (c *S3CryptoClient) PutObject(por *s3.PutObjectRequest) (*s3.PutObjectHeaderResponse, error) {
+
+	var plaintext []byte
+	if por.Content != nil {
+		b, err := ioutil.ReadAll(por.Content)
+		if err != nil {
+			return nil, err
+		}
+		plaintext = b
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+
+	wrappedKey, err := c.KeyProvider.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	matdesc, err := json.Marshal(c.KeyProvider.MaterialDescription())
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := new(s3.ObjectMetadata)
+	if por.ObjectMetadata != nil {
+		*metadata = *por.ObjectMetadata
+	}
+
+	userMeta := make(map[string]string, len(metadata.Metadata)+7)
+	for k, v := range metadata.Metadata {
+		userMeta[k] = v
+	}
+	userMeta[META_KEY] = base64.StdEncoding.EncodeToString(wrappedKey)
+	userMeta[META_IV] = base64.StdEncoding.EncodeToString(nonce)
+	userMeta[META_CEK_ALG] = CEK_ALGORITHM_AES_GCM
+	userMeta[META_WRAP_ALG] = c.KeyProvider.WrapAlgorithm()
+	userMeta[META_MATDESC] = string(matdesc)
+	userMeta[META_TAG_LEN] = strconv.Itoa(tagSize * 8)
+	userMeta[META_UNENCRYPTED_CONTENT_LENGTH] = strconv.Itoa(len(plaintext))
+	metadata.Metadata = userMeta
+
+	req := s3.NewPutObjectRequest(por.BucketName, por.ObjectName, bytes.NewReader(ciphertext), metadata)
+	req.ChecksumAlgorithm = por.ChecksumAlgorithm
+	return c.S3Service.PutObject(req)
+}
+
+// Downloads and decrypts the object. A Constraints.Range of the form "bytes=first-last" or
+// "bytes=first-" returns the decrypted plaintext of that range. Ranged reads are not
+// authenticated; only full reads verify the GCM tag. The request is not modified.
+func (c *S3CryptoClient) GetObject(gor *s3.GetObjectRequest) (io.ReadCloser, *s3.GetObjectHeaderResponse, error) {
+
+	first, last, ranged, err := parseRange(gor.Constraints)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := *gor
+	alignedFirst := first - first%aes.BlockSize
+
+	if ranged {
+		constraints := *gor.Constraints
+		constraints.Range = "bytes=" + strconv.FormatInt(alignedFirst, 10) + "-"
+		if last >= 0 {
+			constraints.Range += strconv.FormatInt(last-last%aes.BlockSize+aes.BlockSize-1, 10)
+		}
+		req.Constraints = &constraints
+	}
+
+	content, hdrs, err := c.S3Service.GetObject(&req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer content.Close()
+
+	dataKey, nonce, err := c.openEnvelope(hdrs.Metadata)
+	if err != nil {
+		return nil, hdrs, err
+	}
+
+	ciphertext, err := ioutil.ReadAll(content)
+	if err != nil {
+		return nil, hdrs, err
+	}
+
+	var plaintext []byte
+
+	if ranged {
+		length, e := strconv.ParseInt(metadataValue(hdrs.Metadata, META_UNENCRYPTED_CONTENT_LENGTH), 10, 64)
+		if e != nil {
+			return nil, hdrs, errors.New("s3crypto: Ranged reads require the " + META_UNENCRYPTED_CONTENT_LENGTH + " metadata.")
+		}
+		plaintext, err = decryptRange(dataKey, nonce, ciphertext, alignedFirst, first, last, length)
+	} else {
+		var aead cipher.AEAD
+		if aead, err = newGCM(dataKey); err == nil {
+			plaintext, err = aead.Open(nil, nonce, ciphertext, nil)
+		}
+	}
+
+	if err != nil {
+		return nil, hdrs, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(plaintext)), hdrs, nil
+}
+
+// Reads the envelope from the object metadata and unwraps the data key.
+func (c *S3CryptoClient) openEnvelope(metadata map[string]string) (dataKey, nonce []byte, err error) {
+
+	wrappedKey := metadataValue(metadata, META_KEY)
+	if wrappedKey == "" {
+		return nil, nil, errors.New("s3crypto: The object is missing the " + META_KEY + " metadata.")
+	}
+
+	if alg := metadataValue(metadata, META_CEK_ALG); alg != CEK_ALGORITHM_AES_GCM {
+		return nil, nil, errors.New("s3crypto: Unsupported content encryption algorithm: " + alg)
+	}
+
+	if alg := metadataValue(metadata, META_WRAP_ALG); alg != c.KeyProvider.WrapAlgorithm() {
+		return nil, nil, errors.New("s3crypto: Unsupported key wrap algorithm: " + alg)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(wrappedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce, err = base64.StdEncoding.DecodeString(metadataValue(metadata, META_IV))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nonce) != nonceSize {
+		return nil, nil, errors.New("s3crypto: Invalid " + META_IV + " metadata.")
+	}
+
+	matdesc := map[string]string{}
+	if desc := metadataValue(metadata, META_MATDESC); desc != "" {
+		if err = json.Unmarshal([]byte(desc), &matdesc); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	dataKey, err = c.KeyProvider.UnwrapKey(key, matdesc)
+	return
+}
+
+/******************************************************************************
+ * Helper Functions
+ */
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Looks up a metadata value regardless of the key's case.
+func metadataValue(metadata map[string]string, key string) string {
+
+	if val, ok := metadata[key]; ok {
+		return val
+	}
+	for k, val := range metadata {
+		if http.CanonicalHeaderKey(k) == key {
+			return val
+		}
+	}
+	return ""
+}
+
+// Parses "bytes=first-last" or "bytes=first-". last is -1 when open-ended.
+func parseRange(constraints *s3.Constraints) (first, last int64, ranged bool, err error) {
+
+	if constraints == nil || constraints.Range == "" {
+		return 0, -1, false, nil
+	}
+
+	spec := strings.TrimSpace(constraints.Range)
+	if !strings.HasPrefix(spec, "bytes=") || strings.Contains(spec, ",") {
+		return 0, -1, false, errors.New("s3crypto: Unsupported range: " + spec)
+	}
+
+	parts := strings.SplitN(spec[len("bytes="):], "-", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return 0, -1, false, errors.New("s3crypto: Unsupported range: " + spec)
+	}
+
+	if first, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return
+	}
+
+	last = -1
+	if parts[1] != "" {
+		if last, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return
+		}
+		if last < first {
+			return 0, -1, false, errors.New("s3crypto: Invalid range: " + spec)
+		}
+	}
+
+	return first, last, true, nil
+}
+
+// Decrypts a block-aligned slice of GCM ciphertext starting at byte offset alignedFirst.
+// GCM encrypts with AES-CTR where the first block of content uses counter 2, so any
+// block can be decrypted independently.
+func decryptRange(dataKey, nonce, ciphertext []byte, alignedFirst, first, last, length int64) ([]byte, error) {
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := make([]byte, aes.BlockSize)
+	copy(counter, nonce)
+	binary.BigEndian.PutUint32(counter[nonceSize:], uint32(2+alignedFirst/aes.BlockSize))
+
+	// Drop the trailing tag.
+	if limit := length - alignedFirst; limit < int64(len(ciphertext)) {
+		if limit < 0 {
+			limit = 0
+		}
+		ciphertext = ciphertext[:limit]
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCTR(block, counter).XORKeyStream(plaintext, ciphertext)
+
+	start := first - alignedFirst
+	if start > int64(len(plaintext)) {
+		start = int64(len(plaintext))
+	}
+	end := int64(len(plaintext))
+	if last >= 0 && last-alignedFirst+1 < end {
+		end = last - alignedFirst + 1
+	}
+
+	return plaintext[start:end], nil
+}
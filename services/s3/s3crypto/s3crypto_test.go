@@ -0,0 +1,71 @@
+package s3crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"github.com/twhello/aws-to-go/services/s3"
+	"testing"
+)
+
+func TestDecryptRange(t *testing.T) {
+
+	dataKey := bytes.Repeat([]byte{7}, dataKeySize)
+	nonce := bytes.Repeat([]byte{3}, nonceSize)
+
+	plaintext := make([]byte, 1000)
+	for i := range plaintext {
+		plaintext[i] = byte(i)
+	}
+
+	aead, _ := newGCM(dataKey)
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+	length := int64(len(plaintext))
+
+	ranges := []string{"bytes=0-0", "bytes=0-15", "bytes=5-40", "bytes=16-31", "bytes=990-999", "bytes=990-5000", "bytes=123-"}
+
+	for _, rng := range ranges {
+
+		first, last, ranged, err := parseRange(&s3.Constraints{Range: rng})
+		if err != nil || !ranged {
+			t.Fatalf("%s: parseRange failed: %v", rng, err)
+		}
+
+		alignedFirst := first - first%aes.BlockSize
+		alignedLast := int64(len(ciphertext)) - 1
+		if last >= 0 && last-last%aes.BlockSize+aes.BlockSize-1 < alignedLast {
+			alignedLast = last - last%aes.BlockSize + aes.BlockSize - 1
+		}
+
+		got, err := decryptRange(dataKey, nonce, ciphertext[alignedFirst:alignedLast+1], alignedFirst, first, last, length)
+		if err != nil {
+			t.Fatalf("%s: %v", rng, err)
+		}
+
+		end := length
+		if last >= 0 && last+1 < end {
+			end = last + 1
+		}
+		if !bytes.Equal(got, plaintext[first:end]) {
+			t.Errorf("%s: decrypted range does not match the plaintext.", rng)
+		}
+	}
+}
+
+func TestSymmetricKeyProvider(t *testing.T) {
+
+	provider, err := NewSymmetricKeyProvider(bytes.Repeat([]byte{1}, 32), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataKey := bytes.Repeat([]byte{9}, dataKeySize)
+	wrapped, err := provider.WrapKey(dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unwrapped, err := provider.UnwrapKey(wrapped, provider.MaterialDescription())
+	if err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Errorf("UnwrapKey returned %x, %v", unwrapped, err)
+	}
+}
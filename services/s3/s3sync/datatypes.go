@@ -0,0 +1,93 @@
+package s3sync
+
+import (
+	"github.com/twhello/aws-to-go/services/s3"
+	"time"
+)
+
+/*	The direction of a sync.
+	UPLOAD Makes the bucket prefix match the local directory.
+	DOWNLOAD Makes the local directory match the bucket prefix. */
+type Direction uint
+
+const (
+	UPLOAD Direction = iota
+	DOWNLOAD
+)
+
+/*	The operation planned for a single file or object.
+	PUT_OBJECT Uploads the local file.
+	GET_OBJECT Downloads the object.
+	DELETE_OBJECT Deletes the object from the bucket.
+	DELETE_FILE Deletes the local file. */
+type ActionType string
+
+const (
+	PUT_OBJECT    ActionType = "PutObject"
+	GET_OBJECT    ActionType = "GetObject"
+	DELETE_OBJECT ActionType = "DeleteObject"
+	DELETE_FILE   ActionType = "DeleteFile"
+)
+
+// Options for a sync. The zero value uploads without deleting, comparing size and modification time.
+type Options struct {
+	Direction      Direction          // Defaults to UPLOAD.
+	Delete         bool               // Removes destination files or objects missing from the source.
+	DryRun         bool               // Sync() only computes the plan.
+	Include        []string           // Glob patterns of relative paths to sync. Empty includes everything.
+	Exclude        []string           // Glob patterns of relative paths to skip. Applied after Include.
+	Concurrency    int                // Number of concurrent transfers. Defaults to 4.
+	CompareMD5     bool               // Compares the MD5 of equal sized files with the ETag instead of the modification time.
+	ObjectMetadata *s3.ObjectMetadata // Metadata for uploaded objects. Can be nil.
+}
+
+// A single planned operation.
+type Action struct {
+	Type    ActionType
+	Key     string // The object key.
+	Path    string // The local file path.
+	Size    int64
+	ModTime time.Time // The source's modification time.
+	Reason  string
+}
+
+// The operations required to bring the destination in line with the source.
+type Plan struct {
+	Actions []Action
+}
+
+// Returns the actions of the given type.
+func (p *Plan) Filter(actionType ActionType) []Action {
+	var actions []Action
+	for _, a := range p.Actions {
+		if a.Type == actionType {
+			actions = append(actions, a)
+		}
+	}
+	return actions
+}
+
+// An action that could not be completed.
+type ActionError struct {
+	Action Action
+	Err    error
+}
+
+func (e ActionError) Error() string {
+	return string(e.Action.Type) + " " + e.Action.Key + ": " + e.Err.Error()
+}
+
+// The outcome of executing a Plan.
+type Result struct {
+	Completed []Action
+	Failed    []ActionError
+}
+
+// A file or object as seen by the comparison.
+type entry struct {
+	key     string
+	path    string
+	size    int64
+	modTime time.Time
+	etag    string
+}
@@ -0,0 +1,462 @@
+//
+// Synchronizes a local directory tree with an Amazon S3 bucket prefix.
+//
+// A sync compares both sides by size and modification time (or MD5 against the ETag),
+// computes a Plan of uploads, downloads and deletes, and executes it concurrently
+// using S3Service.PutObject, GetObject and DeleteMultipleObjects.
+//
+package s3sync
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/twhello/aws-to-go/services/s3"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The maximum number of keys in a DeleteMultipleObjects request.
+const MAX_DELETE_KEYS = 1000
+
+/*****************************************************************************/
+
+// The S3Service operations used by a Syncer.
+type s3API interface {
+	DeleteMultipleObjects(*s3.DeleteMultipleObjectsRequest) (*s3.DeleteResult, error)
+	GetObject(*s3.GetObjectRequest) (io.ReadCloser, *s3.GetObjectHeaderResponse, error)
+	ListObjects(*s3.ListObjectsRequest) (*s3.ListObjectsResult, error)
+	PutObject(*s3.PutObjectRequest) (*s3.PutObjectHeaderResponse, error)
+}
+
+// Syncs LocalDir with the objects under Prefix in Bucket. Use s3sync.New().
+type Syncer struct {
+	service  s3API
+	LocalDir string
+	Bucket   string
+	Prefix   string
+	Options  Options
+}
+
+// Creates a new Syncer.
+// (localDir string) The local root directory.
+// (bucket string) The bucket name.
+// (prefix string) The key prefix the local root maps to. A trailing "/" is added if missing.
+// (options *Options) Can be nil.
+func New(s3Service *s3.S3Service, localDir, bucket, prefix string, options *Options) *Syncer {
+	return newSyncer(s3Service, localDir, bucket, prefix, options)
+}
+
+func newSyncer(service s3API, localDir, bucket, prefix string, options *Options) *Syncer {
+
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	s := &Syncer{service: service, LocalDir: localDir, Bucket: bucket, Prefix: prefix}
+	if options != nil {
+		s.Options = *options
+	}
+	if s.Options.Concurrency <= 0 {
+		s.Options.Concurrency = 4
+	}
+	return s
+}
+
+// Computes the plan and, unless Options.DryRun is set, executes it.
+// The result is nil on a dry run.
+func (s *Syncer) Sync() (*Plan, *Result, error) {
+
+	plan, err := s.Plan()
+	if err != nil || s.Options.DryRun {
+		return plan, nil, err
+	}
+
+	result, err := s.Execute(plan)
+	return plan, result, err
+}
+
+// Compares the local tree with the bucket prefix and returns the required actions.
+func (s *Syncer) Plan() (*Plan, error) {
+
+	local, err := s.listLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := s.listRemote()
+	if err != nil {
+		return nil, err
+	}
+
+	src, dest := local, remote
+	if s.Options.Direction == DOWNLOAD {
+		src, dest = remote, local
+	}
+
+	plan := &Plan{}
+
+	for _, rel := range sortedKeys(src) {
+
+		se := src[rel]
+		de, exists := dest[rel]
+
+		reason := ""
+		switch {
+		case !exists:
+			reason = "missing"
+		case se.size != de.size:
+			reason = "size differs"
+		default:
+			if changed, e := s.changed(local[rel], remote[rel]); e != nil {
+				return nil, e
+			} else if changed != "" {
+				reason = changed
+			}
+		}
+
+		if reason == "" {
+			continue
+		}
+
+		e := local[rel]
+		if e == nil {
+			e = &entry{path: filepath.Join(s.LocalDir, filepath.FromSlash(rel))}
+		}
+
+		action := Action{Type: PUT_OBJECT, Key: s.Prefix + rel, Path: e.path, Size: se.size, ModTime: se.modTime, Reason: reason}
+		if s.Options.Direction == DOWNLOAD {
+			action.Type = GET_OBJECT
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+
+	if s.Options.Delete {
+		for _, rel := range sortedKeys(dest) {
+			if _, ok := src[rel]; !ok {
+				de := dest[rel]
+				action := Action{Type: DELETE_OBJECT, Key: s.Prefix + rel, Size: de.size, ModTime: de.modTime, Reason: "not in source"}
+				if s.Options.Direction == DOWNLOAD {
+					action.Type = DELETE_FILE
+					action.Path = de.path
+				}
+				plan.Actions = append(plan.Actions, action)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// Executes the plan. Transfers and local deletes run concurrently; object deletes are
+// batched into DeleteMultipleObjects requests. Returns an error if any action failed.
+func (s *Syncer) Execute(plan *Plan) (*Result, error) {
+
+	result := &Result{}
+	m := &sync.Mutex{}
+
+	report := func(a Action, err error) {
+		m.Lock()
+		if err != nil {
+			result.Failed = append(result.Failed, ActionError{a, err})
+		} else {
+			result.Completed = append(result.Completed, a)
+		}
+		m.Unlock()
+	}
+
+	queue := make(chan Action)
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < s.Options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range queue {
+				report(a, s.execute(a))
+			}
+		}()
+	}
+
+	var deletes []Action
+	for _, a := range plan.Actions {
+		if a.Type == DELETE_OBJECT {
+			deletes = append(deletes, a)
+		} else {
+			queue <- a
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	for i := 0; i < len(deletes); i += MAX_DELETE_KEYS {
+		end := i + MAX_DELETE_KEYS
+		if end > len(deletes) {
+			end = len(deletes)
+		}
+		s.deleteObjects(deletes[i:end], report)
+	}
+
+	if len(result.Failed) > 0 {
+		return result, fmt.Errorf("s3sync: %d of %d actions failed. First error: %s", len(result.Failed), len(plan.Actions), result.Failed[0].Error())
+	}
+
+	return result, nil
+}
+
+/******************************************************************************
+ * Private Methods
+ */
+
+// Compares equal sized entries. Returns the reason they differ or "".
+func (s *Syncer) changed(local, remote *entry) (string, error) {
+
+	if s.Options.CompareMD5 && remote.etag != "" && !strings.Contains(remote.etag, "-") {
+		sum, err := fileMD5(local.path)
+		if err != nil {
+			return "", err
+		}
+		if sum != remote.etag {
+			return "checksum differs", nil
+		}
+		return "", nil
+	}
+
+	if s.Options.Direction == DOWNLOAD {
+		if remote.modTime.After(local.modTime) {
+			return "newer in bucket", nil
+		}
+	} else if local.modTime.After(remote.modTime) {
+		return "newer locally", nil
+	}
+
+	return "", nil
+}
+
+func (s *Syncer) execute(a Action) error {
+
+	switch a.Type {
+	case PUT_OBJECT:
+		return s.putObject(a)
+	case GET_OBJECT:
+		return s.getObject(a)
+	case DELETE_FILE:
+		return os.Remove(a.Path)
+	}
+	return errors.New("s3sync: Unsupported action " + string(a.Type))
+}
+
+func (s *Syncer) putObject(a Action) error {
+
+	file, err := os.Open(a.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	metadata := new(s3.ObjectMetadata)
+	if s.Options.ObjectMetadata != nil {
+		*metadata = *s.Options.ObjectMetadata
+	}
+	if metadata.ContentType == "" {
+		metadata.ContentType = mime.TypeByExtension(path.Ext(a.Key))
+	}
+
+	_, err = s.service.PutObject(s3.NewPutObjectRequest(s.Bucket, a.Key, file, metadata))
+	return err
+}
+
+// Downloads to a temporary file in the destination directory, then renames it into place.
+// The file's modification time is set to the object's so later syncs compare equal.
+func (s *Syncer) getObject(a Action) error {
+
+	content, _, err := s.service.GetObject(s3.NewGetObjectRequest(s.Bucket, a.Key))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	dir := filepath.Dir(a.Path)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".s3sync-")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tmp, content)
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err == nil && !a.ModTime.IsZero() {
+		err = os.Chtimes(tmp.Name(), a.ModTime, a.ModTime)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), a.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+func (s *Syncer) deleteObjects(actions []Action, report func(Action, error)) {
+
+	keys := make([]string, len(actions))
+	byKey := make(map[string]Action, len(actions))
+	for i, a := range actions {
+		keys[i] = a.Key
+		byKey[a.Key] = a
+	}
+
+	dmor := s3.NewDeleteMultipleObjectsRequest(s.Bucket, keys...)
+	dmor.Delete.Quiet = true
+
+	result, err := s.service.DeleteMultipleObjects(dmor)
+	if err != nil {
+		for _, a := range actions {
+			report(a, err)
+		}
+		return
+	}
+
+	for _, e := range result.Error {
+		if a, ok := byKey[e.Key]; ok {
+			report(a, errors.New(e.Code+": "+e.Message))
+			delete(byKey, e.Key)
+		}
+	}
+	for _, key := range keys {
+		if a, ok := byKey[key]; ok {
+			report(a, nil)
+		}
+	}
+}
+
+// Lists the local files, keyed by slash separated path relative to LocalDir.
+func (s *Syncer) listLocal() (map[string]*entry, error) {
+
+	entries := map[string]*entry{}
+
+	if _, err := os.Stat(s.LocalDir); os.IsNotExist(err) && s.Options.Direction == DOWNLOAD {
+		return entries, nil
+	}
+
+	err := filepath.Walk(s.LocalDir, func(p string, info os.FileInfo, err error) error {
+
+		if err != nil || info.IsDir() || !info.Mode().IsRegular() {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.LocalDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if s.matches(rel) {
+			entries[rel] = &entry{key: s.Prefix + rel, path: p, size: info.Size(), modTime: info.ModTime()}
+		}
+		return nil
+	})
+
+	return entries, err
+}
+
+// Lists the objects under Prefix, keyed by key relative to Prefix.
+func (s *Syncer) listRemote() (map[string]*entry, error) {
+
+	entries := map[string]*entry{}
+	lor := s3.NewListObjectsRequest(s.Bucket)
+	lor.ListObjects = &s3.ListObjects{Prefix: s.Prefix}
+
+	for {
+		objs, err := s.service.ListObjects(lor)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range objs.Contents {
+			rel := strings.TrimPrefix(c.Key, s.Prefix)
+			if rel == "" || strings.HasSuffix(rel, "/") || !s.matches(rel) {
+				continue
+			}
+			entries[rel] = &entry{
+				key:     c.Key,
+				path:    filepath.Join(s.LocalDir, filepath.FromSlash(rel)),
+				size:    c.Size,
+				modTime: c.LastModified,
+				etag:    strings.Trim(c.ETag, `"`),
+			}
+		}
+
+		if !objs.IsTruncated || len(objs.Contents) == 0 {
+			break
+		}
+		lor.ListObjects.Marker = objs.Contents[len(objs.Contents)-1].Key
+	}
+
+	return entries, nil
+}
+
+// Applies the Include and Exclude patterns. Patterns match the relative path or the base name.
+func (s *Syncer) matches(rel string) bool {
+
+	if len(s.Options.Include) > 0 && !matchAny(s.Options.Include, rel) {
+		return false
+	}
+	return !matchAny(s.Options.Exclude, rel)
+}
+
+/******************************************************************************
+ * Helper Functions
+ */
+
+func matchAny(patterns []string, rel string) bool {
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func fileMD5(name string) (string, error) {
+
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedKeys(m map[string]*entry) []string {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,280 @@
+package s3sync
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"github.com/twhello/aws-to-go/services/s3"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	older = time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer = older.Add(time.Hour)
+)
+
+type fakeObject struct {
+	content []byte
+	modTime time.Time
+	etag    string
+}
+
+// A bucket keeping the objects by key, and the DeleteMultipleObjects requests.
+type fakeBucket struct {
+	mutex   sync.Mutex
+	objects map[string]fakeObject
+	deletes []*s3.DeleteMultipleObjectsRequest
+	failKey string // A key DeleteMultipleObjects reports as not deleted.
+}
+
+func newFakeBucket() *fakeBucket {
+	return &fakeBucket{objects: map[string]fakeObject{}}
+}
+
+// Adds an object. An empty etag defaults to the MD5 of the content.
+func (b *fakeBucket) put(key, content string, modTime time.Time, etag string) {
+	if etag == "" {
+		sum := md5.Sum([]byte(content))
+		etag = hex.EncodeToString(sum[:])
+	}
+	b.objects[key] = fakeObject{[]byte(content), modTime, `"` + etag + `"`}
+}
+
+func (b *fakeBucket) DeleteMultipleObjects(dmor *s3.DeleteMultipleObjectsRequest) (*s3.DeleteResult, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.deletes = append(b.deletes, dmor)
+	result := new(s3.DeleteResult)
+	for _, o := range dmor.Delete.Object {
+		if o.Key == b.failKey {
+			result.Error = append(result.Error, s3.DeleteError{Key: o.Key, Code: "AccessDenied", Message: "Access Denied"})
+		} else {
+			delete(b.objects, o.Key)
+		}
+	}
+	return result, nil
+}
+
+func (b *fakeBucket) GetObject(gor *s3.GetObjectRequest) (io.ReadCloser, *s3.GetObjectHeaderResponse, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	o, ok := b.objects[gor.ObjectName]
+	if !ok {
+		return nil, nil, errors.New("NoSuchKey")
+	}
+	return ioutil.NopCloser(bytes.NewReader(o.content)), new(s3.GetObjectHeaderResponse), nil
+}
+
+// Returns the objects under the prefix in key order, two per page.
+func (b *fakeBucket) ListObjects(lor *s3.ListObjectsRequest) (*s3.ListObjectsResult, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var keys []string
+	for key := range b.objects {
+		if strings.HasPrefix(key, lor.ListObjects.Prefix) && key > lor.ListObjects.Marker {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := new(s3.ListObjectsResult)
+	for i, key := range keys {
+		if i == 2 {
+			result.IsTruncated = true
+			break
+		}
+		o := b.objects[key]
+		result.Contents = append(result.Contents, s3.Contents{Key: key, Size: int64(len(o.content)), LastModified: o.modTime, ETag: o.etag})
+	}
+	return result, nil
+}
+
+func (b *fakeBucket) PutObject(por *s3.PutObjectRequest) (*s3.PutObjectHeaderResponse, error) {
+	content, err := ioutil.ReadAll(por.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.put(por.ObjectName, string(content), newer, "")
+	return new(s3.PutObjectHeaderResponse), nil
+}
+
+type localFile struct {
+	content string
+	modTime time.Time
+}
+
+func TestPlan(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		local   map[string]localFile
+		remote  map[string]fakeObject
+		options Options
+		actions []string
+	}{
+		{
+			name:    "missing",
+			local:   map[string]localFile{"a.txt": {"abc", older}, "dir/b.txt": {"b", older}},
+			actions: []string{"PutObject p/a.txt missing", "PutObject p/dir/b.txt missing"},
+		},
+		{
+			name:    "size",
+			local:   map[string]localFile{"a.txt": {"abc", older}},
+			remote:  map[string]fakeObject{"p/a.txt": {[]byte("ab"), newer, ""}},
+			actions: []string{"PutObject p/a.txt size differs"},
+		},
+		{
+			name:    "mtime",
+			local:   map[string]localFile{"a.txt": {"abc", newer}, "b.txt": {"abc", older}},
+			remote:  map[string]fakeObject{"p/a.txt": {[]byte("xyz"), older, ""}, "p/b.txt": {[]byte("xyz"), newer, ""}},
+			actions: []string{"PutObject p/a.txt newer locally"},
+		},
+		{
+			name:    "md5",
+			local:   map[string]localFile{"a.txt": {"abc", older}, "b.txt": {"abc", newer}, "c.txt": {"abc", newer}},
+			remote:  map[string]fakeObject{"p/a.txt": {[]byte("xyz"), newer, ""}, "p/b.txt": {[]byte("abc"), older, ""}, "p/c.txt": {[]byte("abc"), older, "0-2"}},
+			options: Options{CompareMD5: true},
+			actions: []string{"PutObject p/a.txt checksum differs", "PutObject p/c.txt newer locally"},
+		},
+		{
+			name:    "include exclude",
+			local:   map[string]localFile{"a.txt": {"a", older}, "b.log": {"b", older}, "skip/c.txt": {"c", older}},
+			remote:  map[string]fakeObject{"p/d.log": {[]byte("d"), older, ""}},
+			options: Options{Include: []string{"*.txt"}, Exclude: []string{"skip/*"}, Delete: true},
+			actions: []string{"PutObject p/a.txt missing"},
+		},
+		{
+			name:    "delete",
+			local:   map[string]localFile{"a.txt": {"a", older}},
+			remote:  map[string]fakeObject{"p/a.txt": {[]byte("a"), older, ""}, "p/b.txt": {[]byte("b"), older, ""}, "other/c.txt": {[]byte("c"), older, ""}},
+			options: Options{Delete: true},
+			actions: []string{"DeleteObject p/b.txt not in source"},
+		},
+		{
+			name:    "download",
+			local:   map[string]localFile{"a.txt": {"abc", older}, "b.txt": {"b", older}, "c.txt": {"c", older}},
+			remote:  map[string]fakeObject{"p/a.txt": {[]byte("xyz"), newer, ""}, "p/b.txt": {[]byte("b"), older, ""}, "p/d.txt": {[]byte("d"), older, ""}},
+			options: Options{Direction: DOWNLOAD, Delete: true},
+			actions: []string{"GetObject p/a.txt newer in bucket", "GetObject p/d.txt missing", "DeleteFile p/c.txt not in source"},
+		},
+	}
+
+	for _, test := range tests {
+
+		dir := tempDir(t, test.local)
+		defer os.RemoveAll(dir)
+
+		bucket := newFakeBucket()
+		for key, o := range test.remote {
+			bucket.put(key, string(o.content), o.modTime, o.etag)
+		}
+
+		options := test.options
+		plan, err := newSyncer(bucket, dir, "bucket", "p", &options).Plan()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		var actions []string
+		for _, a := range plan.Actions {
+			actions = append(actions, string(a.Type)+" "+a.Key+" "+a.Reason)
+			if a.Type != DELETE_OBJECT && a.Path != filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(a.Key, "p/"))) {
+				t.Errorf("%s: Unexpected path %s for %s", test.name, a.Path, a.Key)
+			}
+		}
+		if strings.Join(actions, ", ") != strings.Join(test.actions, ", ") {
+			t.Errorf("%s: Expected %v, was %v", test.name, test.actions, actions)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+
+	dir := tempDir(t, map[string]localFile{"a.txt": {"abc", older}, "c.txt": {"c", older}})
+	defer os.RemoveAll(dir)
+
+	bucket := newFakeBucket()
+	bucket.put("p/b.txt", "b", newer, "")
+	bucket.failKey = "p/x0500"
+
+	plan := &Plan{Actions: []Action{
+		{Type: PUT_OBJECT, Key: "p/a.txt", Path: filepath.Join(dir, "a.txt")},
+		{Type: GET_OBJECT, Key: "p/b.txt", Path: filepath.Join(dir, "sub", "b.txt"), ModTime: newer},
+		{Type: DELETE_FILE, Key: "p/c.txt", Path: filepath.Join(dir, "c.txt")},
+	}}
+	for i := 0; i < MAX_DELETE_KEYS+1; i++ {
+		key := "p/x" + strconv.Itoa(10000 + i)[1:]
+		bucket.put(key, "x", older, "")
+		plan.Actions = append(plan.Actions, Action{Type: DELETE_OBJECT, Key: key})
+	}
+
+	result, err := newSyncer(bucket, dir, "bucket", "p/", nil).Execute(plan)
+	if err == nil {
+		t.Error("Expected an error for the failed delete.")
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Action.Key != "p/x0500" || len(result.Completed) != len(plan.Actions)-1 {
+		t.Fatalf("Unexpected result: %d completed, failed %v", len(result.Completed), result.Failed)
+	}
+
+	if len(bucket.deletes) != 2 || len(bucket.deletes[0].Delete.Object) != MAX_DELETE_KEYS ||
+		len(bucket.deletes[1].Delete.Object) != 1 || !bucket.deletes[0].Delete.Quiet {
+		t.Errorf("Expected the deletes in 2 quiet requests, was %d", len(bucket.deletes))
+	}
+	if len(bucket.objects) != 3 {
+		t.Errorf("Expected the failed key, a.txt and b.txt to remain, was %d objects", len(bucket.objects))
+	}
+
+	if o, ok := bucket.objects["p/a.txt"]; !ok || string(o.content) != "abc" {
+		t.Error("Expected a.txt to be uploaded.")
+	}
+	if info, err := os.Stat(filepath.Join(dir, "sub", "b.txt")); err != nil || !info.ModTime().Equal(newer) {
+		t.Errorf("Expected b.txt to be downloaded with the object's time: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c.txt")); !os.IsNotExist(err) {
+		t.Error("Expected c.txt to be deleted.")
+	}
+}
+
+/******************************************************************************
+ * Helper Functions
+ */
+
+// Creates a temporary directory with the files.
+func tempDir(t *testing.T, files map[string]localFile) string {
+
+	dir, err := ioutil.TempDir("", "s3sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(p), 0755); err == nil {
+			if err = ioutil.WriteFile(p, []byte(f.content), 0644); err == nil {
+				err = os.Chtimes(p, f.modTime, f.modTime)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
@@ -106,6 +106,16 @@ RETRY:
 	return
 }
 
+// Returns the delay before a retry: base doubled for each previous retry, up to 64 times base.
+// (base time.Duration) The delay before the first retry.
+// (retries uint) The number of retries so far.
+func ExponentialBackoff(base time.Duration, retries uint) time.Duration {
+	if retries > 6 {
+		retries = 6
+	}
+	return base << retries
+}
+
 func evalResponse(response *http.Response, eval *EvalServiceResponse) (*http.Response, interfaces.IServiceError) {
 
 	if response.StatusCode >= 400 {
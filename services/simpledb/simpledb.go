@@ -14,7 +14,6 @@ import (
 	"github.com/twhello/aws-to-go/interfaces"
 	"github.com/twhello/aws-to-go/regions"
 	"github.com/twhello/aws-to-go/services"
-	"github.com/twhello/aws-to-go/util/netutil"
 	"encoding/xml"
 	"io"
 	"net/http"
@@ -61,16 +60,18 @@ func (s *SDBService) SignAndDo(req interfaces.IAWSRequest, dto interface{}) (res
 	return
 }
 
+// Sends the request as a signed form POST, which every SimpleDB action accepts,
+// to keep batch attributes and select expressions out of the URL.
 func (s *SDBService) wrapperSignAndDo(action string, request, result interface{}) (err error) {
 
-	qs := netutil.MarshalValues(request)
-	qs.Add("AWSAccessKeyId", s.cred.AccessKeyId())
-	qs.Add("Timestamp", time.Now().UTC().Format(time.RFC3339)) // ISO 8601 2006-01-02T15:04:05.999Z
-	qs.Add("Version", "2009-04-15")
-	qs.Add("Action", action)
-
-	req, err := services.NewClientRequest("GET", s.Endpoint(), qs)
+	req, err := services.NewFormPostRequest(s.Endpoint(), request)
 	if err == nil {
+		vals := req.FormPostValues()
+		vals.Add("AWSAccessKeyId", s.cred.AccessKeyId())
+		vals.Add("Timestamp", time.Now().UTC().Format(time.RFC3339)) // ISO 8601 2006-01-02T15:04:05.999Z
+		vals.Add("Version", "2009-04-15")
+		vals.Add("Action", action)
+
 		_, err = s.SignAndDo(req, result)
 	}
 
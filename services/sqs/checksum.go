@@ -0,0 +1,217 @@
+package sqs
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*	The transport types of message attribute values in the attribute digest.
+	STRING_TRANSPORT_TYPE = 1 String and Number values.
+	BINARY_TRANSPORT_TYPE = 2 Binary values.
+	STRING_LIST_TRANSPORT_TYPE = 3 String list values.
+	BINARY_LIST_TRANSPORT_TYPE = 4 Binary list values.
+	[http://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-message-metadata.html#sqs-attributes-md5-message-digest-calculation] */
+const (
+	STRING_TRANSPORT_TYPE      byte = 1
+	BINARY_TRANSPORT_TYPE      byte = 2
+	STRING_LIST_TRANSPORT_TYPE byte = 3
+	BINARY_LIST_TRANSPORT_TYPE byte = 4
+)
+
+// Returned when the MD5 digest SQS returns for a message body or its attributes
+// does not match the digest of the message sent or received.
+type ChecksumError struct {
+	Id       string // The message id, or the entry id of a batch request.
+	Field    string // MD5OfBody, MD5OfMessageBody or MD5OfMessageAttributes.
+	Expected string // The digest of the message sent, or the digest returned with a received message.
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return "sqs: " + e.Field + " mismatch for " + e.Id + ". Expected: " + e.Expected + ", Actual: " + e.Actual
+}
+
+// Returned by ReceiveMessage with the verified messages when received messages do not match their MD5 digests.
+// The mismatched messages are removed from the result; they stay invisible until their visibility timeout ends.
+type ReceiveChecksumError struct {
+	Messages []Message        // The messages that do not match their digests.
+	Errors   []*ChecksumError // The mismatch of each message, in the order of Messages.
+}
+
+func (e *ReceiveChecksumError) Error() string {
+	return "sqs: " + strconv.Itoa(len(e.Messages)) + " received messages do not match their MD5 digest. First: " + e.Errors[0].Error()
+}
+
+// Computes the hex encoded MD5 digest of a message body.
+func MessageBodyMD5(body string) string {
+	sum := md5.Sum([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// Computes the hex encoded MD5 digest of message attributes. The attributes are sorted by name and each
+// is digested as its length prefixed name and data type, the transport type byte and its length prefixed
+// value or values. Returns "" if there are no attributes.
+// (names []string) The attribute names.
+// (values []MessageAttributeValue) The attribute values, in the order of names.
+func MessageAttributesMD5(names []string, values []MessageAttributeValue) string {
+
+	if len(names) == 0 {
+		return ""
+	}
+
+	order := make([]int, len(names))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Sort(byName{names, order})
+
+	h := md5.New()
+	writeBytes := func(b []byte) {
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(b)))
+		h.Write(length)
+		h.Write(b)
+	}
+
+	for _, i := range order {
+		if i >= len(values) {
+			continue
+		}
+		value := values[i]
+
+		writeBytes([]byte(names[i]))
+		writeBytes([]byte(value.DataType))
+
+		switch {
+		case value.StringListValues != nil:
+			h.Write([]byte{STRING_LIST_TRANSPORT_TYPE})
+			for _, s := range value.StringListValues {
+				writeBytes([]byte(s))
+			}
+
+		case value.BinaryListValues != nil:
+			h.Write([]byte{BINARY_LIST_TRANSPORT_TYPE})
+			for _, b := range value.BinaryListValues {
+				writeBytes(b)
+			}
+
+		case strings.HasPrefix(value.DataType, "Binary"):
+			h.Write([]byte{BINARY_TRANSPORT_TYPE})
+			writeBytes(value.BinaryValue)
+
+		default:
+			h.Write([]byte{STRING_TRANSPORT_TYPE})
+			writeBytes([]byte(value.StringValue))
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+// Verifies the digests returned for a sent message.
+func verifySendMessage(req *SendMessageRequest, result *SendMessageResult) error {
+	return verifyDigests(result.MessageId, req.MessageBody, req.MessageAttributeName, req.MessageAttributeValue,
+		result.MD5OfMessageBody, result.MD5OfMessageAttributes)
+}
+
+// Verifies the digests returned for an entry of a SendMessageBatch request.
+func verifyBatchEntry(entry *SendMessageBatchRequestEntry, result *SendMessageBatchResultEntry) error {
+	return verifyDigests(result.Id, entry.MessageBody, entry.MessageAttributeName, entry.MessageAttributeValue,
+		result.MD5OfMessageBody, result.MD5OfMessageAttributes)
+}
+
+// Verifies the digests of the successful entries of a SendMessageBatch request.
+// Returns the error of the first mismatch.
+func verifySendMessageBatch(req *SendMessageBatchRequest, result *SendMessageBatchResult) error {
+
+	entries := make(map[string]*SendMessageBatchRequestEntry, len(req.SendMessageBatchRequestEntry))
+	for i := range req.SendMessageBatchRequestEntry {
+		entries[req.SendMessageBatchRequestEntry[i].Id] = &req.SendMessageBatchRequestEntry[i]
+	}
+
+	for i := range result.Successful {
+		if entry, ok := entries[result.Successful[i].Id]; ok {
+			if err := verifyBatchEntry(entry, &result.Successful[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Verifies the digests of received messages. The messages that do not match are removed from the
+// result and returned in a *ReceiveChecksumError.
+func verifyReceiveMessage(result *ReceiveMessageResult) error {
+
+	var mismatch *ReceiveChecksumError
+	verified := result.Messages[:0]
+
+	for _, message := range result.Messages {
+		if err := verifyMessage(&message); err != nil {
+			if mismatch == nil {
+				mismatch = new(ReceiveChecksumError)
+			}
+			mismatch.Messages = append(mismatch.Messages, message)
+			mismatch.Errors = append(mismatch.Errors, err)
+		} else {
+			verified = append(verified, message)
+		}
+	}
+
+	if mismatch == nil {
+		return nil
+	}
+	result.Messages = verified
+	return mismatch
+}
+
+// Verifies the digests of a received message.
+func verifyMessage(message *Message) *ChecksumError {
+
+	if actual := MessageBodyMD5(message.Body); message.MD5OfBody != actual {
+		return &ChecksumError{message.MessageId, "MD5OfBody", message.MD5OfBody, actual}
+	}
+
+	// Only the requested attributes are returned, with the digest of the returned ones. A digest may be
+	// returned without attributes if none were requested, so it is then not verified.
+	if message.MD5OfMessageAttributes != "" && len(message.MessageAttributes) > 0 {
+		names := make([]string, len(message.MessageAttributes))
+		values := make([]MessageAttributeValue, len(message.MessageAttributes))
+		for i, attr := range message.MessageAttributes {
+			names[i], values[i] = attr.Name, attr.Value
+		}
+		if actual := MessageAttributesMD5(names, values); message.MD5OfMessageAttributes != actual {
+			return &ChecksumError{message.MessageId, "MD5OfMessageAttributes", message.MD5OfMessageAttributes, actual}
+		}
+	}
+	return nil
+}
+
+func verifyDigests(id, body string, names []string, values []MessageAttributeValue, bodyMD5, attributesMD5 string) error {
+
+	if sum := MessageBodyMD5(body); bodyMD5 != sum {
+		return &ChecksumError{id, "MD5OfMessageBody", sum, bodyMD5}
+	}
+	if sum := MessageAttributesMD5(names, values); attributesMD5 != sum {
+		return &ChecksumError{id, "MD5OfMessageAttributes", sum, attributesMD5}
+	}
+	return nil
+}
+
+// Sorts the attribute indexes by name.
+type byName struct {
+	names []string
+	order []int
+}
+
+func (s byName) Len() int           { return len(s.order) }
+func (s byName) Less(i, j int) bool { return s.names[s.order[i]] < s.names[s.order[j]] }
+func (s byName) Swap(i, j int)      { s.order[i], s.order[j] = s.order[j], s.order[i] }
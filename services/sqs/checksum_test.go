@@ -0,0 +1,97 @@
+package sqs
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func testAttributes() *SendMessageRequest {
+	req := NewSendMessageRequest("https://queue", "This is a test message")
+	req.AddMessageAttributeValue("c-attr", MessageAttributeValue{DataType: "String.custom", StringValue: "hello"})
+	req.AddMessageAttributeValue("a-attr", MessageAttributeValue{DataType: "Number", StringValue: "42"})
+	req.AddMessageAttributeValue("b-attr", MessageAttributeValue{DataType: "Binary", BinaryValue: []byte{0, 1, 2}})
+	return req
+}
+
+func TestMessageMD5(t *testing.T) {
+
+	if sum := MessageBodyMD5("This is a test message"); sum != "fafb00f5732ab283681e124bf8747ed1" {
+		t.Errorf("Unexpected body MD5 %s", sum)
+	}
+
+	req := testAttributes()
+	if sum := MessageAttributesMD5(req.MessageAttributeName, req.MessageAttributeValue); sum != "f6b3b536c0858eeb9a5dd2b0e4dde616" {
+		t.Errorf("Unexpected attributes MD5 %s", sum)
+	}
+	if sum := MessageAttributesMD5(nil, nil); sum != "" {
+		t.Errorf("Expected no attributes MD5, was %s", sum)
+	}
+}
+
+func TestVerifySendMessage(t *testing.T) {
+
+	req := testAttributes()
+	result := &SendMessageResult{"f6b3b536c0858eeb9a5dd2b0e4dde616", "fafb00f5732ab283681e124bf8747ed1", "id"}
+	if err := verifySendMessage(req, result); err != nil {
+		t.Error(err)
+	}
+
+	result.MD5OfMessageAttributes = "0"
+	err, ok := verifySendMessage(req, result).(*ChecksumError)
+	if !ok || err.Field != "MD5OfMessageAttributes" || err.Id != "id" || err.Actual != "0" {
+		t.Errorf("Expected a ChecksumError, was %v", err)
+	}
+
+	batch := NewSendMessageBatchRequest("https://queue", SendMessageBatchRequestEntry{Id: "0", MessageBody: "a"})
+	batchResult := &SendMessageBatchResult{Successful: []SendMessageBatchResultEntry{{Id: "0", MD5OfMessageBody: MessageBodyMD5("b")}}}
+	if err, ok := verifySendMessageBatch(batch, batchResult).(*ChecksumError); !ok || err.Id != "0" || err.Field != "MD5OfMessageBody" {
+		t.Errorf("Expected a ChecksumError, was %v", err)
+	}
+}
+
+func TestVerifyReceiveMessage(t *testing.T) {
+
+	body := `<ReceiveMessageResponse><ReceiveMessageResult><Message>
+		<MessageId>id</MessageId>
+		<Body>This is a test message</Body>
+		<MD5OfBody>fafb00f5732ab283681e124bf8747ed1</MD5OfBody>
+		<MD5OfMessageAttributes>f6b3b536c0858eeb9a5dd2b0e4dde616</MD5OfMessageAttributes>
+		<MessageAttribute><Name>a-attr</Name><Value><StringValue>42</StringValue><DataType>Number</DataType></Value></MessageAttribute>
+		<MessageAttribute><Name>b-attr</Name><Value><BinaryValue>AAEC</BinaryValue><DataType>Binary</DataType></Value></MessageAttribute>
+		<MessageAttribute><Name>c-attr</Name><Value><StringValue>hello</StringValue><DataType>String.custom</DataType></Value></MessageAttribute>
+	</Message></ReceiveMessageResult></ReceiveMessageResponse>`
+
+	result := new(ReceiveMessageResponse)
+	if err := xml.Unmarshal([]byte(body), result); err != nil {
+		t.Fatal(err)
+	}
+
+	message := result.ReceiveMessageResult.Messages[0]
+	if len(message.MessageAttributes) != 3 || message.MessageAttributes[1].Name != "b-attr" || string(message.MessageAttributes[1].Value.BinaryValue) != "\x00\x01\x02" {
+		t.Fatalf("Unexpected attributes %v", message.MessageAttributes)
+	}
+	if err := verifyReceiveMessage(&result.ReceiveMessageResult); err != nil {
+		t.Error(err)
+	}
+
+	// The attribute digest is not verified without attributes.
+	unrequested := message
+	unrequested.MessageAttributes = nil
+	result.ReceiveMessageResult.Messages = []Message{unrequested}
+	if err := verifyReceiveMessage(&result.ReceiveMessageResult); err != nil || len(result.ReceiveMessageResult.Messages) != 1 {
+		t.Errorf("Expected the message without attributes to be verified, was %v", err)
+	}
+
+	// Only the tampered message is removed from the result.
+	tampered := message
+	tampered.MessageId, tampered.Body = "tampered", "Tampered"
+	result.ReceiveMessageResult.Messages = []Message{tampered, message}
+
+	err, ok := verifyReceiveMessage(&result.ReceiveMessageResult).(*ReceiveChecksumError)
+	if !ok || len(err.Messages) != 1 || err.Messages[0].MessageId != "tampered" || err.Errors[0].Field != "MD5OfBody" {
+		t.Fatalf("Expected a ReceiveChecksumError for the tampered message, was %v", err)
+	}
+	if messages := result.ReceiveMessageResult.Messages; len(messages) != 1 || messages[0].MessageId != "id" {
+		t.Errorf("Expected the verified message to remain, was %v", messages)
+	}
+}
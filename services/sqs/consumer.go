@@ -0,0 +1,378 @@
+package sqs
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	MAX_BATCH_ENTRIES = 10 // The maximum number of entries in a batch request, and of messages received at once.
+
+	DEFAULT_CONSUMER_WORKERS   = 10 // The default number of concurrent handlers of a Consumer.
+	DEFAULT_WAIT_TIME_SECONDS  = 20 // The default long poll duration of a Consumer.
+	DEFAULT_VISIBILITY_TIMEOUT = 30 // The default visibility timeout of a Consumer in seconds.
+
+	consumerFlushInterval = time.Second // The longest a delete or visibility change is buffered.
+)
+
+// The queue operations used by a Consumer.
+type consumerAPI interface {
+	ChangeMessageVisibilityBatch(*ChangeMessageVisibilityBatchRequest) (*ChangeMessageVisibilityBatchResponse, error)
+	DeleteMessageBatch(*DeleteMessageBatchRequest) (*DeleteMessageBatchResponse, error)
+	ReceiveMessage(*ReceiveMessageRequest) (*ReceiveMessageResponse, error)
+}
+
+// Consumes the messages of a queue with a bounded pool of handlers. The queue is long polled for as many
+// messages as there are idle handlers. While a handler runs, the visibility of its message is extended
+// every half visibility timeout. Handled messages are deleted, and failed messages are returned to the
+// queue after the backoff, both with batch requests.
+// Use sqs.NewConsumer().
+type Consumer struct {
+	api               consumerAPI
+	queueUrl          string
+	workers           int
+	waitTimeSeconds   int
+	visibilityTimeout int
+	backoff           func(message *Message, err error) time.Duration
+	onError           func(err error)
+	stop              chan struct{}
+	stopOnce          sync.Once
+}
+
+// Creates a new Consumer.
+// (service *SQSService) The service to consume with.
+// (queueUrl string) The URL of the queue.
+func NewConsumer(service *SQSService, queueUrl string) *Consumer {
+	return newConsumer(service, queueUrl)
+}
+
+func newConsumer(api consumerAPI, queueUrl string) *Consumer {
+	return &Consumer{
+		api:               api,
+		queueUrl:          queueUrl,
+		workers:           DEFAULT_CONSUMER_WORKERS,
+		waitTimeSeconds:   DEFAULT_WAIT_TIME_SECONDS,
+		visibilityTimeout: DEFAULT_VISIBILITY_TIMEOUT,
+		onError:           func(err error) { log.Printf("sqs: Consumer error: %s\n", err) },
+		stop:              make(chan struct{}),
+	}
+}
+
+// Sets the number of concurrent handlers. Defaults to DEFAULT_CONSUMER_WORKERS.
+func (c *Consumer) SetWorkers(workers int) {
+	if workers > 0 {
+		c.workers = workers
+	}
+}
+
+// Sets the long poll duration of ReceiveMessage, from 0 to 20 seconds. Defaults to DEFAULT_WAIT_TIME_SECONDS.
+func (c *Consumer) SetWaitTimeSeconds(seconds int) {
+	if seconds >= 0 && seconds <= 20 {
+		c.waitTimeSeconds = seconds
+	}
+}
+
+// Sets the visibility timeout of received messages in seconds, which is extended while they are handled.
+// Defaults to DEFAULT_VISIBILITY_TIMEOUT.
+func (c *Consumer) SetVisibilityTimeout(seconds int) {
+	if seconds > 0 {
+		c.visibilityTimeout = seconds
+	}
+}
+
+// Sets a function returning how long a failed message stays invisible before it is received again,
+// e.g. an exponential backoff. By default failed messages are visible again immediately.
+func (c *Consumer) SetBackoff(fn func(message *Message, err error) time.Duration) {
+	c.backoff = fn
+}
+
+// Sets a function that is called with the errors of the receive, visibility and delete requests,
+// which are retried. Defaults to logging them.
+func (c *Consumer) SetErrorHandler(fn func(err error)) {
+	if fn != nil {
+		c.onError = fn
+	}
+}
+
+// Stops receiving messages. Run returns when the handlers in progress are done.
+// A stopped Consumer cannot be run again.
+func (c *Consumer) Stop() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// Receives and handles messages until Stop is called. A message is deleted if the handler returns nil,
+// or else returned to the queue after the backoff. The handlers are called concurrently. Messages that
+// do not match their MD5 digests are reported and returned to the queue after the backoff, unhandled.
+// Returns when the handlers in progress are done and their deletes and visibility changes are sent.
+func (c *Consumer) Run(handler func(message *Message) error) {
+
+	idle := make(chan struct{}, c.workers)
+	for i := 0; i < c.workers; i++ {
+		idle <- struct{}{}
+	}
+
+	inflight := &inflightMessages{messages: make(map[string]*Message)}
+	deletes := make(chan *Message)
+	changes := make(chan ChangeMessageVisibilityBatchRequestEntry)
+
+	var wg, flushers sync.WaitGroup
+	flushers.Add(2)
+	go func() { defer flushers.Done(); c.deleteMessages(deletes) }()
+	go func() { defer flushers.Done(); c.changeVisibility(changes, inflight) }()
+
+	for {
+		// Wait for an idle handler, then take the others that are idle.
+		select {
+		case <-c.stop:
+		case <-idle:
+		}
+		if c.isStopped() {
+			break
+		}
+
+		n := 1
+		for n < MAX_BATCH_ENTRIES && len(idle) > 0 {
+			<-idle
+			n++
+		}
+
+		messages, mismatched := c.receive(n)
+		for i := len(messages); i < n; i++ {
+			idle <- struct{}{}
+		}
+
+		// Messages that do not match their digests are returned to the queue like failed messages.
+		if mismatched != nil {
+			for i := range mismatched.Messages {
+				changes <- c.returnEntry(&mismatched.Messages[i], mismatched.Errors[i])
+			}
+		}
+
+		for i := range messages {
+			message := &messages[i]
+			inflight.add(message)
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				err := handler(message)
+				inflight.remove(message)
+
+				if err == nil {
+					deletes <- message
+				} else {
+					changes <- c.returnEntry(message, err)
+				}
+				idle <- struct{}{}
+			}()
+		}
+	}
+
+	wg.Wait()
+	close(deletes)
+	close(changes)
+	flushers.Wait()
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+func (c *Consumer) isStopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Receives up to n messages. Errors are reported and retried after a second, unless stopped.
+// A *ReceiveChecksumError is reported and returned with the verified messages.
+func (c *Consumer) receive(n int) ([]Message, *ReceiveChecksumError) {
+
+	rmr := NewReceiveMessageRequest(c.queueUrl)
+	rmr.MaxNumberOfMessages = n
+	rmr.VisibilityTimeout = c.visibilityTimeout
+	rmr.WaitTimeSeconds = c.waitTimeSeconds
+
+	for {
+		result, err := c.api.ReceiveMessage(rmr)
+		if err == nil {
+			return result.ReceiveMessageResult.Messages, nil
+		}
+		c.onError(err)
+
+		if mismatch, ok := err.(*ReceiveChecksumError); ok {
+			return result.ReceiveMessageResult.Messages, mismatch
+		}
+
+		select {
+		case <-c.stop:
+			return nil, nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// Returns the visibility change returning a failed message to the queue after the backoff.
+func (c *Consumer) returnEntry(message *Message, err error) ChangeMessageVisibilityBatchRequestEntry {
+
+	var delay time.Duration
+	if c.backoff != nil {
+		delay = c.backoff(message, err)
+	}
+	return ChangeMessageVisibilityBatchRequestEntry{
+		ReceiptHandle:     message.ReceiptHandle,
+		VisibilityTimeout: int(delay / time.Second),
+	}
+}
+
+// Deletes the handled messages in batches, flushed when full, every consumerFlushInterval and when closed.
+func (c *Consumer) deleteMessages(deletes <-chan *Message) {
+
+	var batch []DeleteMessageBatchRequestEntry
+
+	flush := func() {
+		if len(batch) > 0 {
+			result, err := c.api.DeleteMessageBatch(NewDeleteMessageBatchRequest(c.queueUrl, batch...))
+			c.reportBatch(err, func() []BatchResultErrorEntry { return result.DeleteMessageBatchResult.Failed })
+			batch = nil
+		}
+	}
+
+	ticker := time.NewTicker(consumerFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message, ok := <-deletes:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, DeleteMessageBatchRequestEntry{strconv.Itoa(len(batch)), message.ReceiptHandle})
+			if len(batch) == MAX_BATCH_ENTRIES {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Returns the failed messages to the queue in batches, like deleteMessages, and extends the
+// visibility of the messages in progress every half visibility timeout.
+func (c *Consumer) changeVisibility(changes <-chan ChangeMessageVisibilityBatchRequestEntry, inflight *inflightMessages) {
+
+	var batch []ChangeMessageVisibilityBatchRequestEntry
+
+	flush := func() {
+		for len(batch) > 0 {
+			n := len(batch)
+			if n > MAX_BATCH_ENTRIES {
+				n = MAX_BATCH_ENTRIES
+			}
+			entries := batch[:n]
+			for i := range entries {
+				entries[i].Id = strconv.Itoa(i)
+			}
+			result, err := c.api.ChangeMessageVisibilityBatch(NewChangeMessageVisibilityBatchRequest(c.queueUrl, entries...))
+			c.reportBatch(err, func() []BatchResultErrorEntry { return result.ChangeMessageVisibilityBatchResult.Failed })
+			batch = batch[n:]
+		}
+		batch = nil
+	}
+
+	extendEvery := time.Duration(c.visibilityTimeout) * time.Second / 2
+	extended := time.Now()
+
+	interval := consumerFlushInterval
+	if extendEvery < interval {
+		interval = extendEvery
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry, ok := <-changes:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) == MAX_BATCH_ENTRIES {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+
+			if time.Since(extended) >= extendEvery {
+				for _, message := range inflight.list() {
+					batch = append(batch, ChangeMessageVisibilityBatchRequestEntry{
+						ReceiptHandle:     message.ReceiptHandle,
+						VisibilityTimeout: c.visibilityTimeout,
+					})
+				}
+				flush()
+				extended = time.Now()
+			}
+		}
+	}
+}
+
+// Reports the error of a batch request, or else its failed entries.
+func (c *Consumer) reportBatch(err error, failed func() []BatchResultErrorEntry) {
+	if err != nil {
+		c.onError(err)
+		return
+	}
+	for _, entry := range failed() {
+		c.onError(&BatchEntryError{entry})
+	}
+}
+
+/*****************************************************************************/
+
+// A failed entry of a batch request.
+type BatchEntryError struct {
+	BatchResultErrorEntry
+}
+
+func (e *BatchEntryError) Error() string {
+	return "sqs: Batch entry " + e.Id + " failed: " + e.Code + " " + e.Message
+}
+
+// The messages being handled, by receipt handle.
+type inflightMessages struct {
+	mutex    sync.Mutex
+	messages map[string]*Message
+}
+
+func (m *inflightMessages) add(message *Message) {
+	m.mutex.Lock()
+	m.messages[message.ReceiptHandle] = message
+	m.mutex.Unlock()
+}
+
+func (m *inflightMessages) remove(message *Message) {
+	m.mutex.Lock()
+	delete(m.messages, message.ReceiptHandle)
+	m.mutex.Unlock()
+}
+
+func (m *inflightMessages) list() []*Message {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	list := make([]*Message, 0, len(m.messages))
+	for _, message := range m.messages {
+		list = append(list, message)
+	}
+	return list
+}
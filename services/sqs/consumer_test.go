@@ -0,0 +1,187 @@
+package sqs
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// A queue serving count messages, recording the batch requests of a Consumer.
+// The messages with a body in corrupt do not match their MD5 digest.
+type fakeQueue struct {
+	mutex    sync.Mutex
+	next     int
+	count    int
+	maxBatch int
+	deleted  map[string]bool
+	changes  map[string][]int
+	corrupt  map[string]bool
+}
+
+func newFakeQueue(count int) *fakeQueue {
+	return &fakeQueue{count: count, deleted: make(map[string]bool), changes: make(map[string][]int)}
+}
+
+func (q *fakeQueue) ReceiveMessage(req *ReceiveMessageRequest) (*ReceiveMessageResponse, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if req.MaxNumberOfMessages > q.maxBatch {
+		q.maxBatch = req.MaxNumberOfMessages
+	}
+	result := new(ReceiveMessageResponse)
+	for i := 0; i < req.MaxNumberOfMessages && q.next < q.count; i++ {
+		id := strconv.Itoa(q.next)
+		message := Message{Body: id, MD5OfBody: MessageBodyMD5(id), MessageId: id, ReceiptHandle: "r" + id}
+		if q.corrupt[id] {
+			message.MD5OfBody = MessageBodyMD5("corrupt")
+		}
+		result.ReceiveMessageResult.Messages = append(result.ReceiveMessageResult.Messages, message)
+		q.next++
+	}
+	if len(result.ReceiveMessageResult.Messages) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return result, verifyReceiveMessage(&result.ReceiveMessageResult)
+}
+
+func (q *fakeQueue) DeleteMessageBatch(req *DeleteMessageBatchRequest) (*DeleteMessageBatchResponse, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(req.DeleteMessageBatchRequestEntry) > MAX_BATCH_ENTRIES {
+		return nil, errors.New("too many entries")
+	}
+	for _, entry := range req.DeleteMessageBatchRequestEntry {
+		q.deleted[entry.ReceiptHandle] = true
+	}
+	return new(DeleteMessageBatchResponse), nil
+}
+
+func (q *fakeQueue) ChangeMessageVisibilityBatch(req *ChangeMessageVisibilityBatchRequest) (*ChangeMessageVisibilityBatchResponse, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	result := new(ChangeMessageVisibilityBatchResponse)
+	for _, entry := range req.ChangeMessageVisibilityBatchRequestEntry {
+		q.changes[entry.ReceiptHandle] = append(q.changes[entry.ReceiptHandle], entry.VisibilityTimeout)
+		if entry.ReceiptHandle == "gone" {
+			result.ChangeMessageVisibilityBatchResult.Failed = append(result.ChangeMessageVisibilityBatchResult.Failed,
+				BatchResultErrorEntry{Code: "ReceiptHandleIsInvalid", Id: entry.Id})
+		}
+	}
+	return result, nil
+}
+
+func TestConsumer(t *testing.T) {
+
+	queue := newFakeQueue(25)
+	c := newConsumer(queue, "https://queue")
+	c.SetWorkers(4)
+	c.SetWaitTimeSeconds(0)
+	c.SetBackoff(func(message *Message, err error) time.Duration { return 5 * time.Second })
+
+	var handled, running, maxRunning int32
+
+	c.Run(func(message *Message) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&running, -1)
+
+		if atomic.AddInt32(&handled, 1) == 25 {
+			c.Stop()
+		}
+		if message.Body == "3" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if handled != 25 || maxRunning > 4 || queue.maxBatch > 4 {
+		t.Errorf("Unexpected handling %d %d %d", handled, maxRunning, queue.maxBatch)
+	}
+	if len(queue.deleted) != 24 || queue.deleted["r3"] {
+		t.Errorf("Expected 24 deleted messages, got %v", queue.deleted)
+	}
+	if timeouts := queue.changes["r3"]; len(timeouts) != 1 || timeouts[0] != 5 {
+		t.Errorf("Expected the failed message to be returned after 5 seconds, got %v", timeouts)
+	}
+}
+
+func TestConsumerChecksumMismatch(t *testing.T) {
+
+	queue := newFakeQueue(10)
+	queue.corrupt = map[string]bool{"2": true, "7": true}
+
+	c := newConsumer(queue, "https://queue")
+	c.SetWaitTimeSeconds(0)
+	c.SetBackoff(func(message *Message, err error) time.Duration { return 10 * time.Second })
+
+	var errs []error
+	var errMutex sync.Mutex
+	c.SetErrorHandler(func(err error) {
+		errMutex.Lock()
+		errs = append(errs, err)
+		errMutex.Unlock()
+	})
+
+	var handled int32
+	c.Run(func(message *Message) error {
+		if queue.corrupt[message.Body] {
+			t.Errorf("Unexpected corrupt message %s", message.Body)
+		}
+		if atomic.AddInt32(&handled, 1) == 8 {
+			c.Stop()
+		}
+		return nil
+	})
+
+	if handled != 8 || len(queue.deleted) != 8 || queue.deleted["r2"] || queue.deleted["r7"] {
+		t.Errorf("Expected the 8 verified messages to be handled and deleted, got %d %v", handled, queue.deleted)
+	}
+	for _, handle := range []string{"r2", "r7"} {
+		if timeouts := queue.changes[handle]; len(timeouts) != 1 || timeouts[0] != 10 {
+			t.Errorf("Expected %s to be returned after 10 seconds, got %v", handle, timeouts)
+		}
+	}
+	if len(errs) == 0 {
+		t.Fatal("Expected the mismatch to be reported.")
+	}
+	for _, err := range errs {
+		if _, ok := err.(*ReceiveChecksumError); !ok {
+			t.Errorf("Expected a ReceiveChecksumError, was %v", err)
+		}
+	}
+}
+
+func TestConsumerExtendsVisibility(t *testing.T) {
+
+	queue := newFakeQueue(1)
+	c := newConsumer(queue, "https://queue")
+	c.SetWaitTimeSeconds(0)
+	c.SetVisibilityTimeout(1)
+
+	var errs []error
+	c.SetErrorHandler(func(err error) { errs = append(errs, err) })
+
+	c.Run(func(message *Message) error {
+		time.Sleep(1200 * time.Millisecond)
+		c.Stop()
+		return nil
+	})
+
+	if timeouts := queue.changes["r0"]; len(timeouts) < 1 || timeouts[0] != 1 {
+		t.Errorf("Expected the visibility to be extended, got %v", timeouts)
+	}
+	if !queue.deleted["r0"] || len(errs) != 0 {
+		t.Errorf("Expected the message to be deleted, got %v %v", queue.deleted, errs)
+	}
+}
@@ -1,6 +1,9 @@
 package sqs
 
-import ()
+import (
+	"encoding/base64"
+	"encoding/xml"
+)
 
 /******************************************************************************
  * Constants
@@ -78,21 +81,21 @@ type BatchResultErrorEntry struct {
 type ChangeMessageVisibilityBatchRequestEntry struct {
 	Id                string `name:"Id"`
 	ReceiptHandle     string `name:"ReceiptHandle"`
-	VisibilityTimeout int    `name:"VisibilityTimeout, omitempty"`
+	VisibilityTimeout int    `name:"VisibilityTimeout" default:"0"`
 }
 
 // For each message in the batch, the response contains a ChangeMessageVisibilityBatchResultEntry
 // tag if the message succeeds or a BatchResultErrorEntry tag if the message fails.
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ChangeMessageVisibilityBatchResult.html]
 type ChangeMessageVisibilityBatchResult struct {
-	Failed     []BatchResultErrorEntry                   `name:"BatchResultErrorEntry.#."`
-	Successful []ChangeMessageVisibilityBatchResultEntry `name:"ChangeMessageVisibilityBatchResultEntry.#."`
+	Failed     []BatchResultErrorEntry                   `xml:"BatchResultErrorEntry"`
+	Successful []ChangeMessageVisibilityBatchResultEntry `xml:"ChangeMessageVisibilityBatchResultEntry"`
 }
 
 // Encloses the id of an entry in ChangeMessageVisibilityBatch.
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ChangeMessageVisibilityBatchResultEntry.html]
 type ChangeMessageVisibilityBatchResultEntry struct {
-	Id string `name:"ChangeMessageVisibilityBatchResultEntry"`
+	Id string `xml:"Id"`
 }
 
 // Returns the QueueUrl element of the created queue.
@@ -112,8 +115,8 @@ type DeleteMessageBatchRequestEntry struct {
 // if the message is deleted or a BatchResultErrorEntry tag if the message cannot be deleted.
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_DeleteMessageBatchResult.html]
 type DeleteMessageBatchResult struct {
-	Failed     []BatchResultErrorEntry                   `name:"BatchResultErrorEntry.#."`
-	Successful []ChangeMessageVisibilityBatchResultEntry `name:"ChangeMessageVisibilityBatchResultEntry.#."`
+	Failed     []BatchResultErrorEntry         `xml:"BatchResultErrorEntry"`
+	Successful []DeleteMessageBatchResultEntry `xml:"DeleteMessageBatchResultEntry"`
 }
 
 // Encloses the id an entry in DeleteMessageBatch.
@@ -153,28 +156,64 @@ type Message struct {
 	Body                   string                  `xml:"Body,omitempty"`
 	MD5OfBody              string                  `xml:"MD5OfBody,omitempty"`
 	MD5OfMessageAttributes string                  `xml:"MD5OfMessageAttributes,omitempty"`
-	MessageAttributes      []MessageAttributeValue `xml:"MessageAttributes,omitempty"`
+	MessageAttributes      []MessageAttribute      `xml:"MessageAttribute,omitempty"`
 	MessageId              string                  `xml:"MessageId,omitempty"`
 	ReceiptHandle          string                  `xml:"ReceiptHandle,omitempty"`
 }
 
+// A named message attribute of a received message.
+type MessageAttribute struct {
+	Name  string                `xml:"Name"`
+	Value MessageAttributeValue `xml:"Value"`
+}
+
 // The user-specified message attribute value. For string data types, the value attribute has
 // the same restrictions on the content as the message body.
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_MessageAttributeValue.html]
 // Message attribute data types:
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/SQSMessageAttributes.html#SQSMessageAttributes.DataTypes]
 type MessageAttributeValue struct {
-	BinaryListValues [][]byte `xml:"BinaryListValues,omitempty"`
-	BinaryValue      []byte   `xml:"BinaryValue,omitempty"`
-	DataType         string   `xml:"DataType"`
-	StringListValues []string `xml:"StringListValues,omitempty"`
-	StringValue      string   `xml:"StringValue,omitempty"`
+	BinaryListValues [][]byte `xml:"BinaryListValues,omitempty" name:"-"`
+	BinaryValue      []byte   `xml:"BinaryValue,omitempty" name:"BinaryValue,omitempty"`
+	DataType         string   `xml:"DataType" name:"DataType"`
+	StringListValues []string `xml:"StringListValues,omitempty" name:"-"`
+	StringValue      string   `xml:"StringValue,omitempty" name:"StringValue,omitempty"`
+}
+
+// Unmarshals a received attribute value, decoding the base64 encoded binary values.
+func (v *MessageAttributeValue) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+
+	var raw struct {
+		BinaryListValues []string `xml:"BinaryListValue"`
+		BinaryValue      *string  `xml:"BinaryValue"`
+		DataType         string   `xml:"DataType"`
+		StringListValues []string `xml:"StringListValue"`
+		StringValue      string   `xml:"StringValue"`
+	}
+	if err = d.DecodeElement(&raw, &start); err != nil {
+		return
+	}
+
+	v.DataType, v.StringValue, v.StringListValues = raw.DataType, raw.StringValue, raw.StringListValues
+	if raw.BinaryValue != nil {
+		if v.BinaryValue, err = base64.StdEncoding.DecodeString(*raw.BinaryValue); err != nil {
+			return
+		}
+	}
+	for _, s := range raw.BinaryListValues {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		v.BinaryListValues = append(v.BinaryListValues, b)
+	}
+	return
 }
 
 // A list of received messages.
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ReceiveMessageResult.html]
 type ReceiveMessageResult struct {
-	Messages []Message `xml:"Messages"`
+	Messages []Message `xml:"Message"`
 }
 
 // Contains the details of a single Amazon SQS message along with a Id.
@@ -182,10 +221,17 @@ type ReceiveMessageResult struct {
 // Message Attribute Items and Validation
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/SQSMessageAttributes.html#SQSMessageAttributesNTV]
 type SendMessageBatchRequestEntry struct {
-	DelaySeconds      int                              `name:"DelaySeconds,omitempty"`
-	Id                string                           `name:"Id"`
-	MessageAttributes map[string]MessageAttributeValue `name:"MessageAttributes,omitempty"`
-	MessageBody       string                           `name:"MessageBody"`
+	DelaySeconds          int                     `name:"DelaySeconds,omitempty"`
+	Id                    string                  `name:"Id"`
+	MessageAttributeName  []string                `name:"MessageAttribute.#.Name,omitempty" base:"1"`
+	MessageAttributeValue []MessageAttributeValue `name:"MessageAttribute.#.Value.,omitempty" base:"1"`
+	MessageBody           string                  `name:"MessageBody"`
+}
+
+// Add MessageAttributeValue.
+func (e *SendMessageBatchRequestEntry) AddMessageAttributeValue(name string, value MessageAttributeValue) {
+	e.MessageAttributeName = append(e.MessageAttributeName, name)
+	e.MessageAttributeValue = append(e.MessageAttributeValue, value)
 }
 
 // For each message in the batch, the response contains a SendMessageBatchResultEntry tag
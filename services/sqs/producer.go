@@ -0,0 +1,311 @@
+package sqs
+
+import (
+	"errors"
+	"github.com/twhello/aws-to-go/interfaces"
+	"github.com/twhello/aws-to-go/services"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	MAX_BATCH_PAYLOAD_SIZE = 256 * 1024 // The maximum size of the messages of a SendMessageBatch request together.
+
+	DEFAULT_PRODUCER_LINGER  = 50 * time.Millisecond // The default time a Producer buffers a message.
+	DEFAULT_PRODUCER_RETRIES = 3                     // The default number of retries of a failed message.
+)
+
+var (
+	ErrMessageTooLarge = errors.New("sqs: The message exceeds the maximum payload size.")
+	ErrProducerClosed  = errors.New("sqs: The producer is closed.")
+	ErrMissingEntry    = errors.New("sqs: The message is missing from the SendMessageBatch result.")
+)
+
+// The result of a message sent by a Producer. Use SendFuture.Result().
+type SendFuture struct {
+	done   chan struct{}
+	result *SendMessageResult
+	err    error
+}
+
+// Returns a channel that is closed when the message was sent or failed.
+func (f *SendFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Waits for the message to be sent and returns its result, or the error of the failed message.
+func (f *SendFuture) Result() (*SendMessageResult, error) {
+	<-f.done
+	return f.result, f.err
+}
+
+/*****************************************************************************/
+
+// Sends messages asynchronously with SendMessageBatch. Messages are buffered per queue and a batch is sent
+// when it reaches MAX_BATCH_ENTRIES messages or MAX_BATCH_PAYLOAD_SIZE bytes, or when its first message has
+// lingered for the linger time. Failed entries and requests are retried, except for sender faults.
+// Use sqs.NewProducer().
+type Producer struct {
+	sendBatch  func(*SendMessageBatchRequest) (*SendMessageBatchResponse, error)
+	linger     time.Duration
+	maxRetries uint
+	mutex      sync.Mutex
+	buffers    map[string]*producerBuffer
+	inflight   sync.WaitGroup
+	closed     bool
+}
+
+// The buffered messages of a queue.
+type producerBuffer struct {
+	messages   []*producerMessage
+	size       int
+	generation int // Incremented by every flush, to ignore the linger timer of a flushed batch.
+}
+
+type producerMessage struct {
+	entry    SendMessageBatchRequestEntry
+	size     int
+	future   *SendFuture
+	callback func(result *SendMessageResult, err error)
+}
+
+// Creates a new Producer.
+// (service *SQSService) The service to send with.
+func NewProducer(service *SQSService) *Producer {
+	return newProducer(service.SendMessageBatch)
+}
+
+func newProducer(sendBatch func(*SendMessageBatchRequest) (*SendMessageBatchResponse, error)) *Producer {
+	return &Producer{
+		sendBatch:  sendBatch,
+		linger:     DEFAULT_PRODUCER_LINGER,
+		maxRetries: DEFAULT_PRODUCER_RETRIES,
+		buffers:    make(map[string]*producerBuffer),
+	}
+}
+
+// Sets the time a message is buffered for more messages of its queue. Defaults to DEFAULT_PRODUCER_LINGER.
+func (p *Producer) SetLinger(linger time.Duration) {
+	p.linger = linger
+}
+
+// Sets the number of retries of a failed message. Defaults to DEFAULT_PRODUCER_RETRIES.
+func (p *Producer) SetMaxRetries(maxRetries uint) {
+	p.maxRetries = maxRetries
+}
+
+// Buffers the message for its queue and returns its future result.
+// (req *SendMessageRequest) The message to send.
+// (callback func(result *SendMessageResult, err error)) Can be nil. Called with the result when the message was sent or failed.
+func (p *Producer) Send(req *SendMessageRequest, callback func(result *SendMessageResult, err error)) *SendFuture {
+
+	message := &producerMessage{
+		entry: SendMessageBatchRequestEntry{
+			DelaySeconds:          req.DelaySeconds,
+			MessageAttributeName:  req.MessageAttributeName,
+			MessageAttributeValue: req.MessageAttributeValue,
+			MessageBody:           req.MessageBody,
+		},
+		future:   &SendFuture{done: make(chan struct{})},
+		callback: callback,
+	}
+	message.size = messageSize(&message.entry)
+
+	if message.size > MAX_BATCH_PAYLOAD_SIZE {
+		message.complete(nil, ErrMessageTooLarge)
+		return message.future
+	}
+
+	p.mutex.Lock()
+
+	// The callback may call the producer, so it must run without the mutex.
+	if p.closed {
+		p.mutex.Unlock()
+		message.complete(nil, ErrProducerClosed)
+		return message.future
+	}
+
+	buffer, ok := p.buffers[req.QueueUrl]
+	if !ok {
+		buffer = &producerBuffer{}
+		p.buffers[req.QueueUrl] = buffer
+	}
+
+	if buffer.size+message.size > MAX_BATCH_PAYLOAD_SIZE {
+		p.flush(req.QueueUrl, buffer)
+	}
+
+	buffer.messages = append(buffer.messages, message)
+	buffer.size += message.size
+
+	if len(buffer.messages) == MAX_BATCH_ENTRIES {
+		p.flush(req.QueueUrl, buffer)
+
+	} else if len(buffer.messages) == 1 {
+		queueUrl, generation := req.QueueUrl, buffer.generation
+		time.AfterFunc(p.linger, func() {
+			p.mutex.Lock()
+			if buffer.generation == generation {
+				p.flush(queueUrl, buffer)
+			}
+			p.mutex.Unlock()
+		})
+	}
+
+	p.mutex.Unlock()
+	return message.future
+}
+
+// Sends the buffered messages and waits for all messages in progress.
+func (p *Producer) Flush() {
+
+	p.mutex.Lock()
+	for queueUrl, buffer := range p.buffers {
+		p.flush(queueUrl, buffer)
+	}
+	p.mutex.Unlock()
+
+	p.inflight.Wait()
+}
+
+// Flushes the producer and fails further messages with ErrProducerClosed.
+func (p *Producer) Close() {
+
+	p.mutex.Lock()
+	p.closed = true
+	p.mutex.Unlock()
+
+	p.Flush()
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+// Sends the buffered messages of the queue in a goroutine. Must be called with the mutex held.
+func (p *Producer) flush(queueUrl string, buffer *producerBuffer) {
+
+	buffer.generation++
+	if len(buffer.messages) == 0 {
+		return
+	}
+
+	messages := buffer.messages
+	buffer.messages, buffer.size = nil, 0
+
+	p.inflight.Add(1)
+	go func() {
+		defer p.inflight.Done()
+		p.send(queueUrl, messages)
+	}()
+}
+
+// Sends a batch, retrying the failed entries and requests.
+func (p *Producer) send(queueUrl string, messages []*producerMessage) {
+
+	for retries := uint(0); len(messages) > 0; retries++ {
+
+		entries := make([]SendMessageBatchRequestEntry, len(messages))
+		for i, message := range messages {
+			entries[i] = message.entry
+			entries[i].Id = strconv.Itoa(i)
+		}
+
+		result, err := p.sendBatch(NewSendMessageBatchRequest(queueUrl, entries...))
+
+		// The messages were sent, so a mismatch fails only the entries concerned.
+		_, mismatch := err.(*ChecksumError)
+		if mismatch {
+			err = nil
+		}
+
+		if err != nil {
+			if retries >= p.maxRetries || !isRetryable(err) {
+				for _, message := range messages {
+					message.complete(nil, err)
+				}
+				return
+			}
+			time.Sleep(services.ExponentialBackoff(100*time.Millisecond, retries))
+			continue
+		}
+
+		for _, entry := range result.SendMessageBatchResult.Successful {
+			if i, err := strconv.Atoi(entry.Id); err == nil && i < len(messages) && messages[i] != nil {
+				var verifyErr error
+				if mismatch {
+					verifyErr = verifyBatchEntry(&entries[i], &entry)
+				}
+				if verifyErr != nil {
+					messages[i].complete(nil, verifyErr)
+				} else {
+					messages[i].complete(&SendMessageResult{entry.MD5OfMessageAttributes, entry.MD5OfMessageBody, entry.MessageId}, nil)
+				}
+				messages[i] = nil
+			}
+		}
+
+		var failed []*producerMessage
+		for _, entry := range result.SendMessageBatchResult.Failed {
+			if i, err := strconv.Atoi(entry.Id); err == nil && i < len(messages) && messages[i] != nil {
+				if entry.SenderFault || retries >= p.maxRetries {
+					messages[i].complete(nil, &BatchEntryError{entry})
+				} else {
+					failed = append(failed, messages[i])
+				}
+				messages[i] = nil
+			}
+		}
+
+		// Entries missing from the result are retried as well.
+		for _, message := range messages {
+			if message == nil {
+				continue
+			}
+			if retries >= p.maxRetries {
+				message.complete(nil, ErrMissingEntry)
+			} else {
+				failed = append(failed, message)
+			}
+		}
+
+		messages = failed
+		if len(messages) > 0 {
+			time.Sleep(services.ExponentialBackoff(100*time.Millisecond, retries))
+		}
+	}
+}
+
+func (m *producerMessage) complete(result *SendMessageResult, err error) {
+	m.future.result, m.future.err = result, err
+	close(m.future.done)
+	if m.callback != nil {
+		m.callback(result, err)
+	}
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+// Returns the size of the message body and attributes, as counted by SQS.
+func messageSize(entry *SendMessageBatchRequestEntry) int {
+
+	size := len(entry.MessageBody)
+	for i, value := range entry.MessageAttributeValue {
+		if i < len(entry.MessageAttributeName) {
+			size += len(entry.MessageAttributeName[i])
+		}
+		size += len(value.DataType) + len(value.StringValue) + len(value.BinaryValue)
+	}
+	return size
+}
+
+// Returns true for throttling and other retryable errors, and for errors other than service errors.
+func isRetryable(err error) bool {
+	if srvErr, ok := err.(interfaces.IServiceError); ok {
+		return srvErr.IsRetry()
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package sqs
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Records the batches of a Producer and fails the entries with a body starting with "fail" or "retry" once.
+// Entries with a body starting with "omit" are missing from the result.
+type fakeSender struct {
+	mutex   sync.Mutex
+	batches [][]string
+	retried map[string]bool
+}
+
+func (s *fakeSender) send(req *SendMessageBatchRequest) (*SendMessageBatchResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.retried == nil {
+		s.retried = make(map[string]bool)
+	}
+
+	var bodies []string
+	result := new(SendMessageBatchResponse)
+	for _, entry := range req.SendMessageBatchRequestEntry {
+		bodies = append(bodies, entry.MessageBody)
+
+		switch {
+		case strings.HasPrefix(entry.MessageBody, "omit"):
+		case strings.HasPrefix(entry.MessageBody, "fail"):
+			result.SendMessageBatchResult.Failed = append(result.SendMessageBatchResult.Failed,
+				BatchResultErrorEntry{Code: "InvalidMessageContents", Id: entry.Id, SenderFault: true})
+		case strings.HasPrefix(entry.MessageBody, "retry") && !s.retried[entry.MessageBody]:
+			s.retried[entry.MessageBody] = true
+			result.SendMessageBatchResult.Failed = append(result.SendMessageBatchResult.Failed,
+				BatchResultErrorEntry{Code: "InternalError", Id: entry.Id})
+		default:
+			result.SendMessageBatchResult.Successful = append(result.SendMessageBatchResult.Successful,
+				SendMessageBatchResultEntry{Id: entry.Id, MessageId: "id-" + entry.MessageBody})
+		}
+	}
+	s.batches = append(s.batches, bodies)
+	return result, nil
+}
+
+func TestProducerBatches(t *testing.T) {
+
+	sender := &fakeSender{}
+	p := newProducer(sender.send)
+	p.SetLinger(time.Hour)
+
+	futures := make([]*SendFuture, 12)
+	for i := range futures {
+		futures[i] = p.Send(NewSendMessageRequest("https://queue", string('a'+rune(i))), nil)
+	}
+
+	<-futures[9].Done()
+	select {
+	case <-futures[10].Done():
+		t.Fatal("Expected the 11th message to be buffered.")
+	default:
+	}
+
+	p.Flush()
+
+	if len(sender.batches) != 2 || len(sender.batches[0]) != 10 || len(sender.batches[1]) != 2 {
+		t.Fatalf("Unexpected batches: %v", sender.batches)
+	}
+	for i, future := range futures {
+		result, err := future.Result()
+		if err != nil || result.MessageId != "id-"+string('a'+rune(i)) {
+			t.Errorf("Message %d: %v %v", i, result, err)
+		}
+	}
+}
+
+func TestProducerLinger(t *testing.T) {
+
+	sender := &fakeSender{}
+	p := newProducer(sender.send)
+	p.SetLinger(10 * time.Millisecond)
+
+	done := make(chan string, 1)
+	p.Send(NewSendMessageRequest("https://queue", "a"), func(result *SendMessageResult, err error) {
+		done <- result.MessageId
+	})
+
+	select {
+	case id := <-done:
+		if id != "id-a" {
+			t.Errorf("Expected id-a, was %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the message to be sent after the linger time.")
+	}
+}
+
+func TestProducerPayloadSize(t *testing.T) {
+
+	sender := &fakeSender{}
+	p := newProducer(sender.send)
+	p.SetLinger(time.Hour)
+
+	body := strings.Repeat("x", 100*1024)
+	for i := 0; i < 3; i++ {
+		p.Send(NewSendMessageRequest("https://queue", body), nil)
+	}
+
+	_, err := p.Send(NewSendMessageRequest("https://queue", body+body+body), nil).Result()
+	if err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge, was %v", err)
+	}
+
+	p.Flush()
+
+	if len(sender.batches) != 2 || len(sender.batches[0])+len(sender.batches[1]) != 3 {
+		t.Errorf("Expected batches of 2 and 1 messages, was %d batches", len(sender.batches))
+	}
+}
+
+func TestProducerRetries(t *testing.T) {
+
+	sender := &fakeSender{}
+	p := newProducer(sender.send)
+
+	retry := p.Send(NewSendMessageRequest("https://queue", "retry"), nil)
+	fail := p.Send(NewSendMessageRequest("https://queue", "fail"), nil)
+	p.Close()
+
+	if result, err := retry.Result(); err != nil || result.MessageId != "id-retry" {
+		t.Errorf("Expected the retried message to be sent: %v %v", result, err)
+	}
+	if _, err := fail.Result(); err == nil {
+		t.Error("Expected a sender fault to fail the message.")
+	} else if entryErr, ok := err.(*BatchEntryError); !ok || entryErr.Code != "InvalidMessageContents" {
+		t.Errorf("Expected a BatchEntryError, was %v", err)
+	}
+	if len(sender.batches) != 2 || len(sender.batches[1]) != 1 {
+		t.Errorf("Expected only the failed entry to be retried: %v", sender.batches)
+	}
+
+	if _, err := p.Send(NewSendMessageRequest("https://queue", "a"), nil).Result(); err != ErrProducerClosed {
+		t.Errorf("Expected ErrProducerClosed, was %v", err)
+	}
+}
+
+func TestProducerMissingEntries(t *testing.T) {
+
+	sender := &fakeSender{}
+	p := newProducer(sender.send)
+	p.SetMaxRetries(2)
+
+	future := p.Send(NewSendMessageRequest("https://queue", "omit"), nil)
+	sent := p.Send(NewSendMessageRequest("https://queue", "a"), nil)
+
+	done := make(chan struct{})
+	go func() {
+		p.Flush()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the flush to return.")
+	}
+
+	if _, err := future.Result(); err != ErrMissingEntry {
+		t.Errorf("Expected ErrMissingEntry, was %v", err)
+	}
+	if _, err := sent.Result(); err != nil {
+		t.Error(err)
+	}
+	if len(sender.batches) != 3 {
+		t.Errorf("Expected the missing entry to be retried twice, was %v", sender.batches)
+	}
+}
+
+func TestProducerClosedCallback(t *testing.T) {
+
+	p := newProducer((&fakeSender{}).send)
+	p.Close()
+
+	// The callback runs without the producer's mutex, so it can call the producer.
+	done := make(chan error, 2)
+	go p.Send(NewSendMessageRequest("https://queue", "a"), func(result *SendMessageResult, err error) {
+		p.Send(NewSendMessageRequest("https://queue", "b"), func(result *SendMessageResult, err error) {
+			done <- err
+		})
+		done <- err
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != ErrProducerClosed {
+				t.Errorf("Expected ErrProducerClosed, was %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the callbacks to be called.")
+		}
+	}
+}
@@ -216,7 +216,7 @@ func NewRemovePermissionRequest(queueUrl string, label string) *RemovePermission
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessage.html]
 type SendMessageRequest struct {
 	DelaySeconds          int                     `name:"DelaySeconds,omitempty"`
-	MessageAttributeName  []string                `name:"MessageAttribute.#.Name.,omitempty" base:"1"`
+	MessageAttributeName  []string                `name:"MessageAttribute.#.Name,omitempty" base:"1"`
 	MessageAttributeValue []MessageAttributeValue `name:"MessageAttribute.#.Value.,omitempty" base:"1"`
 	MessageBody           string                  `name:"MessageBody"`
 	QueueUrl              string                  `name:"-"`
@@ -11,7 +11,6 @@ import (
 	"github.com/twhello/aws-to-go/interfaces"
 	"github.com/twhello/aws-to-go/regions"
 	"github.com/twhello/aws-to-go/services"
-	"github.com/twhello/aws-to-go/util/netutil"
 	"net/http"
 	"time"
 )
@@ -50,16 +49,18 @@ func (s *SQSService) SignAndDo(req interfaces.IAWSRequest, dto interface{}) (res
 	return
 }
 
+// Sends the request as a signed form POST, which every SQS action accepts,
+// to keep message bodies and batch entries out of the URL.
 func (s *SQSService) wrapperSignAndDo(url, action string, request, result interface{}) (err error) {
-	
-	qs := netutil.MarshalValues(request)
-	qs.Add("AWSAccessKeyId", s.cred.AccessKeyId())
-	qs.Add("Expires", time.Now().Add(time.Second*300).Format("2006-01-02T15:04:05MST")) // 2011-10-24T22:52:43PST
-	qs.Add("Version", "2012-11-05")
-	qs.Add("Action", action)
-
-	req, err := services.NewClientRequest("GET", url, qs)
+
+	req, err := services.NewFormPostRequest(url, request)
 	if err == nil {
+		vals := req.FormPostValues()
+		vals.Add("AWSAccessKeyId", s.cred.AccessKeyId())
+		vals.Add("Expires", time.Now().Add(time.Second*300).Format("2006-01-02T15:04:05MST")) // 2011-10-24T22:52:43PST
+		vals.Add("Version", "2012-11-05")
+		vals.Add("Action", action)
+
 		_, err = s.SignAndDo(req, result)
 	}
 
@@ -175,11 +176,16 @@ func (s *SQSService) ListQueues(req *ListQueuesRequest) (result *ListQueuesRespo
 
 // Retrieves one or more messages, with a maximum limit of 10 messages, from the specified queue.
 // Long poll support is enabled by using the WaitTimeSeconds parameter.
+// Messages whose body or attributes do not match their MD5 digest are removed from the result, and returned
+// in a *ReceiveChecksumError with the result of the verified messages.
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ReceiveMessage.html]
 func (s *SQSService) ReceiveMessage(req *ReceiveMessageRequest) (result *ReceiveMessageResponse, err error) {
 
 	result = new(ReceiveMessageResponse)
 	err = s.wrapperSignAndDo(req.QueueUrl, "ReceiveMessage", req, result)
+	if err == nil {
+		err = verifyReceiveMessage(&result.ReceiveMessageResult)
+	}
 	return
 }
 
@@ -194,20 +200,28 @@ func (s *SQSService) RemovePermission(req *RemovePermissionRequest) (result *Rem
 }
 
 // Delivers a message to the specified queue.
+// Returns a *ChecksumError with the result if the digests returned do not match the message.
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessage.html]
 func (s *SQSService) SendMessage(req *SendMessageRequest) (result *SendMessageResponse, err error) {
 
 	result = new(SendMessageResponse)
 	err = s.wrapperSignAndDo(req.QueueUrl, "SendMessage", req, result)
+	if err == nil {
+		err = verifySendMessage(req, &result.SendMessageResult)
+	}
 	return
 }
 
 // Delivers up to ten messages to the specified queue.
+// Returns a *ChecksumError with the result for the first successful entry whose digests do not match the message.
 // [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessageBatch.html]
 func (s *SQSService) SendMessageBatch(req *SendMessageBatchRequest) (result *SendMessageBatchResponse, err error) {
 
 	result = new(SendMessageBatchResponse)
 	err = s.wrapperSignAndDo(req.QueueUrl, "SendMessageBatch", req, result)
+	if err == nil {
+		err = verifySendMessageBatch(req, &result.SendMessageBatchResult)
+	}
 	return
 }
 
@@ -0,0 +1,45 @@
+package sqs
+
+import (
+	"github.com/twhello/aws-to-go/auth"
+	"github.com/twhello/aws-to-go/regions"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendMessagePost(t *testing.T) {
+
+	body := strings.Repeat("x", 10000)
+	value := MessageAttributeValue{DataType: "String", StringValue: "value"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.RawQuery != "" {
+			t.Errorf("Expected a POST without query string, was %s %s", r.Method, r.URL)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("Expected a signed request, was %s", r.Header.Get("Authorization"))
+		}
+		if r.FormValue("Action") != "SendMessage" || r.FormValue("MessageBody") != body ||
+			r.FormValue("MessageAttribute.1.Name") != "attr" || r.FormValue("MessageAttribute.1.Value.StringValue") != "value" {
+			t.Errorf("Unexpected form %v", r.PostForm)
+		}
+		w.Write([]byte(`<SendMessageResponse><SendMessageResult>
+			<MD5OfMessageBody>` + MessageBodyMD5(body) + `</MD5OfMessageBody>
+			<MD5OfMessageAttributes>` + MessageAttributesMD5([]string{"attr"}, []MessageAttributeValue{value}) + `</MD5OfMessageAttributes>
+			<MessageId>id</MessageId>
+		</SendMessageResult></SendMessageResponse>`))
+	}))
+	defer server.Close()
+
+	s := NewService(auth.NewCredentials("AKID", "SECRET"), regions.Config(regions.DEFAULT_REGION))
+
+	req := NewSendMessageRequest(server.URL+"/123/queue", body)
+	req.AddMessageAttributeValue("attr", value)
+
+	result, err := s.SendMessage(req)
+	if err != nil || result.SendMessageResult.MessageId != "id" {
+		t.Errorf("Unexpected result %v %v", result, err)
+	}
+}
@@ -0,0 +1,453 @@
+// Sends and receives SQS messages with payloads larger than SQS accepts.
+//
+// A message body over the size threshold is stored as an object in an S3 bucket, and a pointer
+// to the object is sent instead with the reserved ExtendedPayloadSize message attribute. Received
+// pointers are resolved to the stored body, and the object is deleted with the message. The pointer
+// and receipt handle formats are those of the Amazon SQS Extended Client Library for Java.
+//
+// [http://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-s3-messages.html]
+package sqsextended
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/twhello/aws-to-go/services/s3"
+	"github.com/twhello/aws-to-go/services/sqs"
+	"github.com/twhello/aws-to-go/util/uuid"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+const (
+	RESERVED_ATTRIBUTE_NAME        = "ExtendedPayloadSize" // The message attribute holding the size of a payload stored in S3.
+	DEFAULT_MESSAGE_SIZE_THRESHOLD = 256 * 1024            // The default size above which a payload is stored in S3.
+
+	POINTER_CLASS = "software.amazon.payloadoffloading.PayloadS3Pointer" // The class name in the JSON pointer body.
+
+	bucketMarker = "-..s3BucketName..-"
+	keyMarker    = "-..s3Key..-"
+)
+
+var (
+	ErrReservedAttribute = errors.New("sqsextended: The message uses the reserved attribute " + RESERVED_ATTRIBUTE_NAME + ".")
+	ErrInvalidPointer    = errors.New("sqsextended: The message body is not a valid S3 pointer.")
+)
+
+// The SQS operations used by a Client.
+type sqsAPI interface {
+	ChangeMessageVisibility(*sqs.ChangeMessageVisibilityRequest) (*sqs.ChangeMessageVisibilityResponse, error)
+	ChangeMessageVisibilityBatch(*sqs.ChangeMessageVisibilityBatchRequest) (*sqs.ChangeMessageVisibilityBatchResponse, error)
+	DeleteMessage(*sqs.DeleteMessageRequest) (*sqs.DeleteMessageResponse, error)
+	DeleteMessageBatch(*sqs.DeleteMessageBatchRequest) (*sqs.DeleteMessageBatchResponse, error)
+	ReceiveMessage(*sqs.ReceiveMessageRequest) (*sqs.ReceiveMessageResponse, error)
+	SendMessage(*sqs.SendMessageRequest) (*sqs.SendMessageResponse, error)
+	SendMessageBatch(*sqs.SendMessageBatchRequest) (*sqs.SendMessageBatchResponse, error)
+}
+
+// The S3 operations used by a Client.
+type s3API interface {
+	DeleteObject(*s3.DeleteObjectRequest) (*s3.DeleteObjectHeaderResponse, error)
+	GetObject(*s3.GetObjectRequest) (io.ReadCloser, *s3.GetObjectHeaderResponse, error)
+	PutObject(*s3.PutObjectRequest) (*s3.PutObjectHeaderResponse, error)
+}
+
+// The JSON pointer to a payload stored in S3.
+type payloadPointer struct {
+	S3BucketName string `json:"s3BucketName"`
+	S3Key        string `json:"s3Key"`
+}
+
+// Returned by ReceiveMessage with the resolved messages when some received messages cannot be resolved:
+// their pointer is invalid, their payload cannot be loaded from S3, or they do not match their MD5 digests.
+// These messages are removed from the result and keep their receipt handles as received, so they can be
+// returned to the queue or left to become visible again.
+type ReceiveError struct {
+	Messages []sqs.Message // The messages that cannot be resolved.
+	Errors   []error       // The error of each message: ErrInvalidPointer, the S3 error or an *sqs.ChecksumError.
+}
+
+func (e *ReceiveError) Error() string {
+	return "sqsextended: " + strconv.Itoa(len(e.Messages)) + " received messages cannot be resolved. First: " + e.Errors[0].Error()
+}
+
+/*****************************************************************************/
+
+// Wraps an SQSService to store large message bodies in an S3 bucket. Use sqsextended.NewClient().
+type Client struct {
+	sqs             sqsAPI
+	s3              s3API
+	bucketName      string
+	threshold       int
+	alwaysThroughS3 bool
+	deletePayloads  bool
+}
+
+// Creates a new Client.
+// (sqsService *sqs.SQSService) The service to send and receive with.
+// (s3Service *s3.S3Service) The service to store payloads with.
+// (bucketName string) The bucket to store payloads in.
+func NewClient(sqsService *sqs.SQSService, s3Service *s3.S3Service, bucketName string) *Client {
+	return newClient(sqsService, s3Service, bucketName)
+}
+
+func newClient(sqsService sqsAPI, s3Service s3API, bucketName string) *Client {
+	return &Client{
+		sqs:            sqsService,
+		s3:             s3Service,
+		bucketName:     bucketName,
+		threshold:      DEFAULT_MESSAGE_SIZE_THRESHOLD,
+		deletePayloads: true,
+	}
+}
+
+// Sets the size of body and attributes above which the body is stored in S3.
+// Defaults to DEFAULT_MESSAGE_SIZE_THRESHOLD.
+func (c *Client) SetMessageSizeThreshold(bytes int) {
+	if bytes >= 0 {
+		c.threshold = bytes
+	}
+}
+
+// Stores every message body in S3, regardless of its size.
+func (c *Client) SetAlwaysThroughS3(always bool) {
+	c.alwaysThroughS3 = always
+}
+
+// Sets whether deleting a message deletes its payload in S3. Defaults to true.
+// Disable it if the payload is shared, e.g. by messages fanned out by SNS.
+func (c *Client) SetDeletePayloads(deletePayloads bool) {
+	c.deletePayloads = deletePayloads
+}
+
+// Sends the message, storing the body in S3 if the message is too large. The request is not modified.
+// The result digests are those of the pointer message. The stored body is deleted if the message is not sent.
+func (c *Client) SendMessage(req *sqs.SendMessageRequest) (*sqs.SendMessageResponse, error) {
+
+	if containsReserved(req.MessageAttributeName) {
+		return nil, ErrReservedAttribute
+	}
+
+	if !c.isLarge(req.MessageBody, req.MessageAttributeName, req.MessageAttributeValue) {
+		return c.sqs.SendMessage(req)
+	}
+
+	pointer, body, err := c.storePayload(req.MessageBody)
+	if err != nil {
+		return nil, err
+	}
+
+	smr := *req
+	smr.MessageBody = body
+	smr.MessageAttributeName = append(append([]string{}, req.MessageAttributeName...), RESERVED_ATTRIBUTE_NAME)
+	smr.MessageAttributeValue = append(append([]sqs.MessageAttributeValue{}, req.MessageAttributeValue...), sizeAttribute(req.MessageBody))
+
+	result, err := c.sqs.SendMessage(&smr)
+	if isNotSent(err) {
+		c.deletePayload(pointer)
+	}
+	return result, err
+}
+
+// Sends the messages, storing the bodies of the messages that are too large in S3. The request is not modified.
+// The stored bodies are deleted if the batch is not sent, and those of the failed entries if it is.
+func (c *Client) SendMessageBatch(req *sqs.SendMessageBatchRequest) (*sqs.SendMessageBatchResponse, error) {
+
+	for _, entry := range req.SendMessageBatchRequestEntry {
+		if containsReserved(entry.MessageAttributeName) {
+			return nil, ErrReservedAttribute
+		}
+	}
+
+	entries := make([]sqs.SendMessageBatchRequestEntry, len(req.SendMessageBatchRequestEntry))
+	pointers := make(map[string]*payloadPointer)
+
+	for i, entry := range req.SendMessageBatchRequestEntry {
+
+		if c.isLarge(entry.MessageBody, entry.MessageAttributeName, entry.MessageAttributeValue) {
+			pointer, body, err := c.storePayload(entry.MessageBody)
+			if err != nil {
+				for _, stored := range pointers {
+					c.deletePayload(stored)
+				}
+				return nil, err
+			}
+			pointers[entry.Id] = pointer
+			entry.MessageAttributeName = append(append([]string{}, entry.MessageAttributeName...), RESERVED_ATTRIBUTE_NAME)
+			entry.MessageAttributeValue = append(append([]sqs.MessageAttributeValue{}, entry.MessageAttributeValue...), sizeAttribute(entry.MessageBody))
+			entry.MessageBody = body
+		}
+		entries[i] = entry
+	}
+
+	result, err := c.sqs.SendMessageBatch(sqs.NewSendMessageBatchRequest(req.QueueUrl, entries...))
+	if isNotSent(err) {
+		for _, pointer := range pointers {
+			c.deletePayload(pointer)
+		}
+	} else if len(pointers) > 0 {
+		for _, entry := range result.SendMessageBatchResult.Failed {
+			if pointer, ok := pointers[entry.Id]; ok {
+				c.deletePayload(pointer)
+			}
+		}
+	}
+	return result, err
+}
+
+// Receives messages and replaces the pointers with the bodies stored in S3. The receipt handles of these
+// messages embed the pointer, so they must be deleted or changed with this Client. The reserved attribute
+// is requested if needed and removed from the messages. The request is not modified.
+// Messages that cannot be resolved are removed from the result, and returned in a *ReceiveError with the
+// result of the resolved messages.
+func (c *Client) ReceiveMessage(req *sqs.ReceiveMessageRequest) (*sqs.ReceiveMessageResponse, error) {
+
+	rmr := *req
+	if !containsName(req.MessageAttributeName, "All") && !containsName(req.MessageAttributeName, ".*") {
+		rmr.MessageAttributeName = append(append([]string{}, req.MessageAttributeName...), RESERVED_ATTRIBUTE_NAME)
+	}
+
+	var unresolved *ReceiveError
+
+	result, err := c.sqs.ReceiveMessage(&rmr)
+	if mismatch, ok := err.(*sqs.ReceiveChecksumError); ok {
+		unresolved = &ReceiveError{Messages: mismatch.Messages}
+		for _, e := range mismatch.Errors {
+			unresolved.Errors = append(unresolved.Errors, e)
+		}
+	} else if err != nil {
+		return result, err
+	}
+
+	messages := result.ReceiveMessageResult.Messages
+	resolved := messages[:0]
+
+	for _, message := range messages {
+		if err := c.resolve(&message); err != nil {
+			if unresolved == nil {
+				unresolved = new(ReceiveError)
+			}
+			unresolved.Messages = append(unresolved.Messages, message)
+			unresolved.Errors = append(unresolved.Errors, err)
+		} else {
+			resolved = append(resolved, message)
+		}
+	}
+
+	if unresolved == nil {
+		return result, nil
+	}
+	result.ReceiveMessageResult.Messages = resolved
+	return result, unresolved
+}
+
+// Deletes the message, and then its payload in S3 if it has one.
+func (c *Client) DeleteMessage(req *sqs.DeleteMessageRequest) (*sqs.DeleteMessageResponse, error) {
+
+	receiptHandle, pointer := extractPointer(req.ReceiptHandle)
+
+	dmr := *req
+	dmr.ReceiptHandle = receiptHandle
+
+	result, err := c.sqs.DeleteMessage(&dmr)
+	if err == nil && pointer != nil && c.deletePayloads {
+		_, err = c.s3.DeleteObject(s3.NewDeleteObjectRequest(pointer.S3BucketName, pointer.S3Key))
+	}
+	return result, err
+}
+
+// Deletes the messages, and then the payloads in S3 of the deleted messages.
+// Returns the error of the first payload that could not be deleted.
+func (c *Client) DeleteMessageBatch(req *sqs.DeleteMessageBatchRequest) (*sqs.DeleteMessageBatchResponse, error) {
+
+	entries := make([]sqs.DeleteMessageBatchRequestEntry, len(req.DeleteMessageBatchRequestEntry))
+	pointers := make(map[string]*payloadPointer)
+
+	for i, entry := range req.DeleteMessageBatchRequestEntry {
+		receiptHandle, pointer := extractPointer(entry.ReceiptHandle)
+		entries[i] = sqs.DeleteMessageBatchRequestEntry{Id: entry.Id, ReceiptHandle: receiptHandle}
+		if pointer != nil {
+			pointers[entry.Id] = pointer
+		}
+	}
+
+	result, err := c.sqs.DeleteMessageBatch(sqs.NewDeleteMessageBatchRequest(req.QueueUrl, entries...))
+	if err != nil || !c.deletePayloads {
+		return result, err
+	}
+
+	for _, entry := range result.DeleteMessageBatchResult.Successful {
+		if pointer, ok := pointers[entry.Id]; ok {
+			if _, e := c.s3.DeleteObject(s3.NewDeleteObjectRequest(pointer.S3BucketName, pointer.S3Key)); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return result, err
+}
+
+// Changes the visibility timeout of the message.
+func (c *Client) ChangeMessageVisibility(req *sqs.ChangeMessageVisibilityRequest) (*sqs.ChangeMessageVisibilityResponse, error) {
+
+	cmvr := *req
+	cmvr.ReceiptHandle, _ = extractPointer(req.ReceiptHandle)
+	return c.sqs.ChangeMessageVisibility(&cmvr)
+}
+
+// Changes the visibility timeout of the messages.
+func (c *Client) ChangeMessageVisibilityBatch(req *sqs.ChangeMessageVisibilityBatchRequest) (*sqs.ChangeMessageVisibilityBatchResponse, error) {
+
+	entries := make([]sqs.ChangeMessageVisibilityBatchRequestEntry, len(req.ChangeMessageVisibilityBatchRequestEntry))
+	for i, entry := range req.ChangeMessageVisibilityBatchRequestEntry {
+		entry.ReceiptHandle, _ = extractPointer(entry.ReceiptHandle)
+		entries[i] = entry
+	}
+	return c.sqs.ChangeMessageVisibilityBatch(sqs.NewChangeMessageVisibilityBatchRequest(req.QueueUrl, entries...))
+}
+
+/*****************************************************************************
+ * Private Methods
+ */
+
+// Returns true if the body must be stored in S3.
+func (c *Client) isLarge(body string, names []string, values []sqs.MessageAttributeValue) bool {
+
+	if c.alwaysThroughS3 {
+		return true
+	}
+
+	size := len(body)
+	for i, value := range values {
+		if i < len(names) {
+			size += len(names[i])
+		}
+		size += len(value.DataType) + len(value.StringValue) + len(value.BinaryValue)
+	}
+	return size > c.threshold
+}
+
+// Stores the body in a new object and returns the pointer to it, and the JSON pointer body.
+func (c *Client) storePayload(body string) (*payloadPointer, string, error) {
+
+	pointer := &payloadPointer{c.bucketName, uuid.NewUUID().String()[1:37]}
+
+	por := s3.NewPutObjectRequest(pointer.S3BucketName, pointer.S3Key, strings.NewReader(body), nil)
+	if _, err := c.s3.PutObject(por); err != nil {
+		return nil, "", err
+	}
+
+	b, err := json.Marshal([]interface{}{POINTER_CLASS, pointer})
+	return pointer, string(b), err
+}
+
+// Deletes the stored payload of a message that was not sent. Errors are ignored, as the send error is returned.
+func (c *Client) deletePayload(pointer *payloadPointer) {
+	c.s3.DeleteObject(s3.NewDeleteObjectRequest(pointer.S3BucketName, pointer.S3Key))
+}
+
+// Replaces the pointer body of a received message with the stored body. Messages without the reserved
+// attribute are left as they are.
+func (c *Client) resolve(message *sqs.Message) error {
+
+	attributes := message.MessageAttributes
+	for i := range attributes {
+		if attributes[i].Name != RESERVED_ATTRIBUTE_NAME {
+			continue
+		}
+
+		pointer, err := parsePointer(message.Body)
+		if err != nil {
+			return err
+		}
+
+		body, err := c.loadPayload(pointer)
+		if err != nil {
+			return err
+		}
+
+		message.Body = body
+		message.MD5OfBody = sqs.MessageBodyMD5(body)
+		message.MessageAttributes = append(append([]sqs.MessageAttribute{}, attributes[:i]...), attributes[i+1:]...)
+		message.ReceiptHandle = embedPointer(message.ReceiptHandle, pointer)
+		return nil
+	}
+	return nil
+}
+
+func (c *Client) loadPayload(pointer *payloadPointer) (string, error) {
+
+	content, _, err := c.s3.GetObject(s3.NewGetObjectRequest(pointer.S3BucketName, pointer.S3Key))
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	b, err := ioutil.ReadAll(content)
+	return string(b), err
+}
+
+/*****************************************************************************
+ * Helper Functions
+ */
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsReserved(names []string) bool {
+	return containsName(names, RESERVED_ATTRIBUTE_NAME)
+}
+
+// Returns true if a send error means the messages were not sent. A *sqs.ChecksumError is returned
+// for messages that were sent.
+func isNotSent(err error) bool {
+	_, mismatch := err.(*sqs.ChecksumError)
+	return err != nil && !mismatch
+}
+
+// The reserved attribute holding the size of the body.
+func sizeAttribute(body string) sqs.MessageAttributeValue {
+	return sqs.MessageAttributeValue{DataType: "Number", StringValue: strconv.Itoa(len(body))}
+}
+
+// Parses a pointer body of the form ["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"b","s3Key":"k"}].
+func parsePointer(body string) (*payloadPointer, error) {
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal([]byte(body), &parts); err != nil || len(parts) != 2 {
+		return nil, ErrInvalidPointer
+	}
+
+	pointer := new(payloadPointer)
+	if err := json.Unmarshal(parts[1], pointer); err != nil || pointer.S3BucketName == "" || pointer.S3Key == "" {
+		return nil, ErrInvalidPointer
+	}
+	return pointer, nil
+}
+
+// Embeds the pointer in the receipt handle.
+func embedPointer(receiptHandle string, pointer *payloadPointer) string {
+	return bucketMarker + pointer.S3BucketName + bucketMarker + keyMarker + pointer.S3Key + keyMarker + receiptHandle
+}
+
+// Returns the receipt handle without the embedded pointer, and the pointer or nil.
+func extractPointer(receiptHandle string) (string, *payloadPointer) {
+
+	parts := strings.SplitN(receiptHandle, bucketMarker, 3)
+	if len(parts) != 3 || parts[0] != "" {
+		return receiptHandle, nil
+	}
+
+	keyParts := strings.SplitN(parts[2], keyMarker, 3)
+	if len(keyParts) != 3 || keyParts[0] != "" {
+		return receiptHandle, nil
+	}
+
+	return keyParts[2], &payloadPointer{parts[1], keyParts[1]}
+}
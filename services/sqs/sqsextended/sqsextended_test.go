@@ -0,0 +1,305 @@
+package sqsextended
+
+import (
+	"bytes"
+	"errors"
+	"github.com/twhello/aws-to-go/services/s3"
+	"github.com/twhello/aws-to-go/services/sqs"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// A queue keeping the sent messages, and the receipt handles of the deleted and changed ones.
+// Sends fail with sendErr, and the sent bodies in corrupt are received as not matching their MD5 digest.
+type fakeQueue struct {
+	messages []sqs.Message
+	deleted  []string
+	changed  []string
+	sendErr  error
+	corrupt  map[string]bool
+}
+
+func (q *fakeQueue) send(body string, names []string, values []sqs.MessageAttributeValue) {
+	message := sqs.Message{Body: body, ReceiptHandle: "r" + strconv.Itoa(len(q.messages))}
+	for i, name := range names {
+		message.MessageAttributes = append(message.MessageAttributes, sqs.MessageAttribute{Name: name, Value: values[i]})
+	}
+	q.messages = append(q.messages, message)
+}
+
+func (q *fakeQueue) SendMessage(req *sqs.SendMessageRequest) (*sqs.SendMessageResponse, error) {
+	if q.sendErr != nil {
+		return nil, q.sendErr
+	}
+	q.send(req.MessageBody, req.MessageAttributeName, req.MessageAttributeValue)
+	return new(sqs.SendMessageResponse), nil
+}
+
+func (q *fakeQueue) SendMessageBatch(req *sqs.SendMessageBatchRequest) (*sqs.SendMessageBatchResponse, error) {
+	if q.sendErr != nil {
+		return nil, q.sendErr
+	}
+	for _, entry := range req.SendMessageBatchRequestEntry {
+		q.send(entry.MessageBody, entry.MessageAttributeName, entry.MessageAttributeValue)
+	}
+	return new(sqs.SendMessageBatchResponse), nil
+}
+
+// Returns the sent messages with the requested attributes only.
+func (q *fakeQueue) ReceiveMessage(req *sqs.ReceiveMessageRequest) (*sqs.ReceiveMessageResponse, error) {
+	result := new(sqs.ReceiveMessageResponse)
+	for _, message := range q.messages {
+		var attributes []sqs.MessageAttribute
+		for _, attr := range message.MessageAttributes {
+			if containsName(req.MessageAttributeName, attr.Name) {
+				attributes = append(attributes, attr)
+			}
+		}
+		message.MessageAttributes = attributes
+		result.ReceiveMessageResult.Messages = append(result.ReceiveMessageResult.Messages, message)
+	}
+
+	// Like the SQSService, mismatched messages are removed from the result.
+	var mismatch *sqs.ReceiveChecksumError
+	messages := result.ReceiveMessageResult.Messages[:0]
+	for _, message := range result.ReceiveMessageResult.Messages {
+		if q.corrupt[message.Body] {
+			if mismatch == nil {
+				mismatch = new(sqs.ReceiveChecksumError)
+			}
+			mismatch.Messages = append(mismatch.Messages, message)
+			mismatch.Errors = append(mismatch.Errors, &sqs.ChecksumError{Id: message.MessageId, Field: "MD5OfBody"})
+		} else {
+			messages = append(messages, message)
+		}
+	}
+	result.ReceiveMessageResult.Messages = messages
+	if mismatch != nil {
+		return result, mismatch
+	}
+	return result, nil
+}
+
+func (q *fakeQueue) DeleteMessage(req *sqs.DeleteMessageRequest) (*sqs.DeleteMessageResponse, error) {
+	q.deleted = append(q.deleted, req.ReceiptHandle)
+	return new(sqs.DeleteMessageResponse), nil
+}
+
+func (q *fakeQueue) DeleteMessageBatch(req *sqs.DeleteMessageBatchRequest) (*sqs.DeleteMessageBatchResponse, error) {
+	result := new(sqs.DeleteMessageBatchResponse)
+	for _, entry := range req.DeleteMessageBatchRequestEntry {
+		q.deleted = append(q.deleted, entry.ReceiptHandle)
+		result.DeleteMessageBatchResult.Successful = append(result.DeleteMessageBatchResult.Successful, sqs.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+	return result, nil
+}
+
+func (q *fakeQueue) ChangeMessageVisibility(req *sqs.ChangeMessageVisibilityRequest) (*sqs.ChangeMessageVisibilityResponse, error) {
+	q.changed = append(q.changed, req.ReceiptHandle)
+	return new(sqs.ChangeMessageVisibilityResponse), nil
+}
+
+func (q *fakeQueue) ChangeMessageVisibilityBatch(req *sqs.ChangeMessageVisibilityBatchRequest) (*sqs.ChangeMessageVisibilityBatchResponse, error) {
+	for _, entry := range req.ChangeMessageVisibilityBatchRequestEntry {
+		q.changed = append(q.changed, entry.ReceiptHandle)
+	}
+	return new(sqs.ChangeMessageVisibilityBatchResponse), nil
+}
+
+// A bucket keeping the objects by bucket and key. Contents starting with "fail" are not stored.
+type fakeBucket map[string][]byte
+
+func (b fakeBucket) PutObject(por *s3.PutObjectRequest) (*s3.PutObjectHeaderResponse, error) {
+	content, err := ioutil.ReadAll(por.Content)
+	if bytes.HasPrefix(content, []byte("fail")) {
+		return nil, errors.New("AccessDenied")
+	}
+	b[por.BucketName+"/"+por.ObjectName] = content
+	return new(s3.PutObjectHeaderResponse), err
+}
+
+func (b fakeBucket) GetObject(gor *s3.GetObjectRequest) (io.ReadCloser, *s3.GetObjectHeaderResponse, error) {
+	content, ok := b[gor.BucketName+"/"+gor.ObjectName]
+	if !ok {
+		return nil, nil, errors.New("NoSuchKey")
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), new(s3.GetObjectHeaderResponse), nil
+}
+
+func (b fakeBucket) DeleteObject(dor *s3.DeleteObjectRequest) (*s3.DeleteObjectHeaderResponse, error) {
+	delete(b, dor.BucketName+"/"+dor.ObjectName)
+	return new(s3.DeleteObjectHeaderResponse), nil
+}
+
+func TestClient(t *testing.T) {
+
+	queue, bucket := &fakeQueue{}, fakeBucket{}
+	c := newClient(queue, bucket, "payloads")
+	c.SetMessageSizeThreshold(100)
+
+	large := strings.Repeat("x", 101)
+
+	req := sqs.NewSendMessageRequest("https://queue", large)
+	req.AddMessageAttributeValue("attr", sqs.MessageAttributeValue{DataType: "String", StringValue: "value"})
+	if _, err := c.SendMessage(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendMessageBatch(sqs.NewSendMessageBatchRequest("https://queue",
+		sqs.SendMessageBatchRequestEntry{Id: "0", MessageBody: "small"},
+		sqs.SendMessageBatchRequestEntry{Id: "1", MessageBody: large})); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bucket) != 2 || !strings.HasPrefix(queue.messages[0].Body, `["`+POINTER_CLASS+`",{"s3BucketName":"payloads","s3Key":"`) {
+		t.Fatalf("Expected 2 payloads in S3, was %d: %s", len(bucket), queue.messages[0].Body)
+	}
+	if queue.messages[1].Body != "small" || len(queue.messages[1].MessageAttributes) != 0 {
+		t.Errorf("Expected the small message to be sent as is: %v", queue.messages[1])
+	}
+	if len(req.MessageAttributeName) != 1 {
+		t.Error("Expected the request not to be modified.")
+	}
+
+	rmr := sqs.NewReceiveMessageRequest("https://queue")
+	rmr.MessageAttributeName = []string{"attr"}
+	result, err := c.ReceiveMessage(rmr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := result.ReceiveMessageResult.Messages
+	for _, i := range []int{0, 2} {
+		if messages[i].Body != large || messages[i].MD5OfBody != sqs.MessageBodyMD5(large) {
+			t.Errorf("Expected message %d to have the stored body, was %s", i, messages[i].Body)
+		}
+		if !strings.HasPrefix(messages[i].ReceiptHandle, bucketMarker+"payloads"+bucketMarker+keyMarker) {
+			t.Errorf("Expected the pointer in the receipt handle %s", messages[i].ReceiptHandle)
+		}
+	}
+	if len(messages[0].MessageAttributes) != 1 || messages[0].MessageAttributes[0].Name != "attr" {
+		t.Errorf("Expected only the user attribute, was %v", messages[0].MessageAttributes)
+	}
+	if messages[1].Body != "small" || messages[1].ReceiptHandle != "r1" {
+		t.Errorf("Expected the small message as sent: %v", messages[1])
+	}
+
+	cmvr := sqs.NewChangeMessageVisibilityRequest("https://queue", messages[0].ReceiptHandle, 10)
+	if _, err := c.ChangeMessageVisibility(cmvr); err != nil || queue.changed[0] != "r0" {
+		t.Errorf("Expected the original receipt handle: %v %v", queue.changed, err)
+	}
+
+	if _, err := c.DeleteMessage(&sqs.DeleteMessageRequest{QueueUrl: "https://queue", ReceiptHandle: messages[0].ReceiptHandle}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.DeleteMessageBatch(sqs.NewDeleteMessageBatchRequest("https://queue",
+		sqs.DeleteMessageBatchRequestEntry{Id: "0", ReceiptHandle: messages[1].ReceiptHandle},
+		sqs.DeleteMessageBatchRequestEntry{Id: "1", ReceiptHandle: messages[2].ReceiptHandle})); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(queue.deleted, ",") != "r0,r1,r2" {
+		t.Errorf("Expected the original receipt handles, was %v", queue.deleted)
+	}
+	if len(bucket) != 0 {
+		t.Errorf("Expected the payloads to be deleted, %d remain", len(bucket))
+	}
+}
+
+func TestClientReceiveUnresolved(t *testing.T) {
+
+	queue, bucket := &fakeQueue{corrupt: map[string]bool{"corrupt": true}}, fakeBucket{}
+	c := newClient(queue, bucket, "payloads")
+	c.SetAlwaysThroughS3(true)
+
+	for _, body := range []string{"a", "missing", "b"} {
+		if _, err := c.SendMessage(sqs.NewSendMessageRequest("https://queue", body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, content := range bucket {
+		if string(content) == "missing" {
+			delete(bucket, key)
+		}
+	}
+	queue.send("not a pointer", []string{RESERVED_ATTRIBUTE_NAME}, []sqs.MessageAttributeValue{sizeAttribute("x")})
+	queue.send("corrupt", nil, nil)
+
+	result, err := c.ReceiveMessage(sqs.NewReceiveMessageRequest("https://queue"))
+
+	unresolved, ok := err.(*ReceiveError)
+	if !ok || len(unresolved.Messages) != 3 || len(unresolved.Errors) != 3 {
+		t.Fatalf("Expected a ReceiveError for 3 messages, was %v", err)
+	}
+	handles := map[string]error{}
+	for i, message := range unresolved.Messages {
+		handles[message.ReceiptHandle] = unresolved.Errors[i]
+	}
+	if _, ok := handles["r4"].(*sqs.ChecksumError); !ok || handles["r1"] == nil || handles["r3"] != ErrInvalidPointer {
+		t.Errorf("Expected the errors of the unresolved messages by receipt handle, was %v", handles)
+	}
+
+	messages := result.ReceiveMessageResult.Messages
+	if len(messages) != 2 || messages[0].Body != "a" || messages[1].Body != "b" {
+		t.Errorf("Expected the resolved messages, was %v", messages)
+	}
+}
+
+func TestClientSendFailure(t *testing.T) {
+
+	queue, bucket := &fakeQueue{}, fakeBucket{}
+	c := newClient(queue, bucket, "payloads")
+	c.SetAlwaysThroughS3(true)
+
+	// A payload that cannot be stored fails the batch, deleting the payloads stored before.
+	if _, err := c.SendMessageBatch(sqs.NewSendMessageBatchRequest("https://queue",
+		sqs.SendMessageBatchRequestEntry{Id: "0", MessageBody: "a"},
+		sqs.SendMessageBatchRequestEntry{Id: "1", MessageBody: "b"},
+		sqs.SendMessageBatchRequestEntry{Id: "2", MessageBody: "fail"})); err == nil {
+		t.Error("Expected the store error.")
+	}
+	if len(bucket) != 0 || len(queue.messages) != 0 {
+		t.Errorf("Expected nothing stored or sent, was %d payloads and %d messages", len(bucket), len(queue.messages))
+	}
+
+	queue.sendErr = errors.New("AccessDenied")
+	if _, err := c.SendMessageBatch(sqs.NewSendMessageBatchRequest("https://queue",
+		sqs.SendMessageBatchRequestEntry{Id: "0", MessageBody: "a"},
+		sqs.SendMessageBatchRequestEntry{Id: "1", MessageBody: "b"})); err != queue.sendErr {
+		t.Errorf("Expected the send error, was %v", err)
+	}
+	if _, err := c.SendMessage(sqs.NewSendMessageRequest("https://queue", "c")); err != queue.sendErr {
+		t.Errorf("Expected the send error, was %v", err)
+	}
+	if len(bucket) != 0 {
+		t.Errorf("Expected the payloads of the messages not sent to be deleted, %d remain", len(bucket))
+	}
+}
+
+func TestClientReservedAttribute(t *testing.T) {
+
+	c := newClient(&fakeQueue{}, fakeBucket{}, "payloads")
+
+	req := sqs.NewSendMessageRequest("https://queue", "body")
+	req.AddMessageAttributeValue(RESERVED_ATTRIBUTE_NAME, sqs.MessageAttributeValue{DataType: "Number", StringValue: "1"})
+	if _, err := c.SendMessage(req); err != ErrReservedAttribute {
+		t.Errorf("Expected ErrReservedAttribute, was %v", err)
+	}
+}
+
+func TestReceiptHandlePointer(t *testing.T) {
+
+	handle := embedPointer("AQEB-handle", &payloadPointer{"bucket", "key"})
+	if receiptHandle, pointer := extractPointer(handle); receiptHandle != "AQEB-handle" || pointer == nil ||
+		pointer.S3BucketName != "bucket" || pointer.S3Key != "key" {
+		t.Errorf("Unexpected %s %v", receiptHandle, pointer)
+	}
+	if receiptHandle, pointer := extractPointer("AQEB-handle"); receiptHandle != "AQEB-handle" || pointer != nil {
+		t.Errorf("Unexpected %s %v", receiptHandle, pointer)
+	}
+	if _, err := parsePointer(`{"s3Key":"k"}`); err != ErrInvalidPointer {
+		t.Errorf("Expected ErrInvalidPointer, was %v", err)
+	}
+}
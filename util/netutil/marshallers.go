@@ -1,6 +1,7 @@
 package netutil
 
 import (
+	"encoding/base64"
 	"log"
 	"net/http"
 	"net/url"
@@ -35,10 +36,12 @@ Example of supported types:
 		IntArray     []int64           `name:"Int.Array.#"`
 		StructVal    StructVal         `name:"StructVal."`
 		StructValPrt *StructVal        `name:"StructValPtr."`
+		StructArray  []StructVal       `name:"Struct.Array.#." base:"1"`
 	}
 
 Supports the following field types and arrays:
 bool, float64, int, int64, map[string]string, string.
+A []byte is base64 encoded.
 
 Also supports the `time.Time` and Structs (including pointers) composed of the supported types.
 
@@ -62,6 +65,11 @@ Tags for marshalling hints:
 	// Produces: myName.2="A"&myName.3="B"&myName.4="C"
 	Field = []string{"A","B","C"}
 
+	// For arrays of Structs, the Struct's values are appended to the indexed name.
+	Field []StructVal `name:"myName.#." base:"1"`
+	// Produces: myName.1.ValueS="A"&myName.1.IntVal="1"&myName.2.ValueS="B"...
+	Field = []StructVal{{"A", 1, false}, {"B", 2, false}}
+
 	// For Time, the required `format` tag is the layout value for
 	// [http://golang.org/pkg/time/#Time.Format] */
 func MarshalValues(in interface{}) url.Values {
@@ -153,6 +161,10 @@ func marshaller(in interface{}, out map[string][]string) {
 			timeVal, _ := field.Interface().(*time.Time)
 			out[name] = []string{timeVal.Format(fieldType.Tag.Get("format"))}
 			continue
+
+		case "[]uint8":
+			out[name] = []string{base64.StdEncoding.EncodeToString(field.Bytes())}
+			continue
 		}
 
 		switch field.Kind() {
@@ -177,8 +189,13 @@ func marshaller(in interface{}, out map[string][]string) {
 			}
 			for j := 0; j < field.Len(); j++ {
 				rv := field.Index(j)
-				if _, val, o := parseField(&rv, &fieldType); !o {
-					out[strings.Replace(name, "#", strconv.FormatInt(int64(j+indexStart), 10), -1)] = []string{val}
+				key := strings.Replace(name, "#", strconv.FormatInt(int64(j+indexStart), 10), -1)
+				if rv.Kind() == reflect.Struct {
+					for k, val := range MarshalValues(rv) {
+						out[key+k] = []string{val[0]}
+					}
+				} else if _, val, o := parseField(&rv, &fieldType); !o {
+					out[key] = []string{val}
 				}
 			}
 			continue
@@ -52,3 +52,29 @@ func TestMarshalUnmarshal(t *testing.T) {
 	UnmarshalHeader(hdr, marBack)
 	t.Logf("UnmarshalHeader:\n%+v %+v\n", marBack, marBack.StructValPrt)
 }
+
+func TestMarshalStructArray(t *testing.T) {
+
+	vals := MarshalValues(&struct {
+		Entries []StructVal `name:"Entry.#." base:"1"`
+	}{[]StructVal{{"A", 1, true}, {"B", 0, false}}})
+
+	if vals.Get("Entry.1.ValueS") != "A" || vals.Get("Entry.1.ValueI") != "1" || vals.Get("Entry.2.ValueS") != "B" {
+		t.Errorf("Unexpected values %v", vals)
+	}
+}
+
+func TestMarshalBytes(t *testing.T) {
+
+	vals := MarshalValues(&struct {
+		Bytes []byte `name:"Bytes,omitempty"`
+		Empty []byte `name:"Empty,omitempty"`
+	}{Bytes: []byte("hello")})
+
+	if vals.Get("Bytes") != "aGVsbG8=" {
+		t.Errorf("Expected aGVsbG8=, was %s", vals.Get("Bytes"))
+	}
+	if _, ok := vals["Empty"]; ok {
+		t.Error("Expected the empty []byte to be omitted.")
+	}
+}
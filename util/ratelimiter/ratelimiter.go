@@ -68,6 +68,16 @@ func (l *RateLimiter) Rate() int {
 	return int(l.pps)
 }
 
+// Changes the stable throughput, given as "permits per second". Permits already acquired are kept.
+func (l *RateLimiter) SetRate(permitsPerSecond uint32) {
+	if permitsPerSecond == 0 {
+		permitsPerSecond = 1
+	}
+	l.m.Lock()
+	l.pps = float64(permitsPerSecond)
+	l.m.Unlock()
+}
+
 // Acquires the given number of permits from this RateLimiter, blocking until the request can be granted.
 func (l *RateLimiter) Aquire(permits int) {
 	l.m.Lock()